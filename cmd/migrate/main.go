@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/database"
+)
+
+const usage = `usage: go run ./cmd/migrate <command>
+
+commands:
+  up            apply every pending migration
+  down          roll back the last applied migration
+  status        list migrations and whether they were applied
+  to <version>  migrate up or down to the given version (0 rolls back everything)`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	migrator, err := database.NewMigrator(database.Open(cfg.Database, cfg.Log.Level))
+	if err != nil {
+		log.Fatalf("failed to read migrations: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		done, err := migrator.Up()
+		report("applied", done)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("database is up to date")
+		}
+
+	case "down":
+		migration, err := migrator.Down()
+		if errors.Is(err, database.ErrNothingToRollBack) {
+			fmt.Println("no migration to roll back")
+			return
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("rolled back %04d_%s\n", migration.Version, migration.Name)
+
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+		if err := migrator.Check(); err != nil {
+			fmt.Println(err)
+		}
+
+	case "to":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		version, err := strconv.ParseUint(os.Args[2], 10, 32)
+		if err != nil {
+			log.Fatalf("invalid version %q", os.Args[2])
+		}
+		done, err := migrator.To(uint(version))
+		report("migrated", done)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func report(verb string, migrations []database.Migration) {
+	for _, m := range migrations {
+		fmt.Printf("%s %04d_%s\n", verb, m.Version, m.Name)
+	}
+}
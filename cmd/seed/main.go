@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/database"
+	"github.com/andresidrim/cesupa-hospital/database/seed"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+)
+
+func main() {
+	adminName := flag.String("admin-name", os.Getenv("SEED_ADMIN_NAME"), "name of the first admin (SEED_ADMIN_NAME)")
+	adminCPF := flag.String("admin-cpf", os.Getenv("SEED_ADMIN_CPF"), "CPF of the first admin (SEED_ADMIN_CPF)")
+	adminPassword := flag.String("admin-password", os.Getenv("SEED_ADMIN_PASSWORD"), "initial password of the first admin (SEED_ADMIN_PASSWORD)")
+	demo := flag.Bool("demo", false, "also create demo doctors, pacients and appointments")
+	seedValue := flag.Int64("seed", envInt64("SEED_DEMO_SEED", 1), "random seed of the demo data (SEED_DEMO_SEED)")
+	pacients := flag.Int("pacients", 300, "number of demo pacients")
+	weeks := flag.Int("weeks", 6, "number of weeks the demo appointments are spread over")
+	demoPassword := flag.String("demo-password", envString("SEED_DEMO_PASSWORD", "Demo-senha1"), "password of the demo users (SEED_DEMO_PASSWORD)")
+	flag.Parse()
+
+	if *adminCPF == "" && !*demo {
+		fmt.Fprintln(os.Stderr, "nothing to seed: set SEED_ADMIN_CPF (or -admin-cpf) and/or pass -demo")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db := database.Connect(cfg.Database, cfg.Log.Level)
+	policy := passwords.NewPolicy(cfg.Password)
+
+	if *adminCPF != "" {
+		admin, created, err := seed.Admin(db, policy, seed.AdminInput{Name: *adminName, CPF: *adminCPF, Password: *adminPassword})
+		if err != nil {
+			log.Fatalf("failed to seed admin: %v", err)
+		}
+		if created {
+			fmt.Printf("admin %s created (id %d); the password must be changed on first login\n", admin.Name, admin.ID)
+		} else {
+			fmt.Printf("admin %s already exists (id %d)\n", admin.Name, admin.ID)
+		}
+	}
+
+	if *demo {
+		result, err := seed.Demo(db, policy, seed.DemoOptions{
+			Seed:     *seedValue,
+			Pacients: *pacients,
+			Weeks:    *weeks,
+			From:     seed.DefaultFrom(time.Now().In(cfg.Location), *weeks),
+			Password: *demoPassword,
+		})
+		if err != nil {
+			log.Fatalf("failed to seed demo data: %v", err)
+		}
+		fmt.Printf("demo data (seed %d): %d specialties, %d doctors, %d staff, %d pacients, %d appointments created\n",
+			*seedValue, result.Specialties, result.Doctors, result.Staff, result.Pacients, result.Appointments)
+	}
+}
+
+func envString(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envInt64(name string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
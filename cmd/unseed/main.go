@@ -1,11 +1,30 @@
 package main
 
 import (
+	"log"
 	"os"
 
-	"github.com/andresidrim/cesupa-hospital/env"
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/database"
 )
 
 func main() {
-	os.Remove(env.DB_URL)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if file, ok := database.SQLiteFile(cfg.Database.URL); ok {
+		os.Remove(file)
+		return
+	}
+
+	// Postgres: não dá para apagar o arquivo, então desfaz todas as migrações
+	migrator, err := database.NewMigrator(database.Open(cfg.Database, cfg.Log.Level))
+	if err != nil {
+		log.Fatalf("failed to read migrations: %v", err)
+	}
+	if _, err := migrator.To(0); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,323 @@
+// Package config reúne a configuração da API em um struct tipado. Os valores
+// vêm dos padrões abaixo, de um arquivo YAML opcional (CONFIG_FILE) e das
+// variáveis de ambiente, nessa ordem de prioridade crescente, e são validados
+// antes de qualquer componente subir.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server    Server    `yaml:"server"`
+	Database  Database  `yaml:"database"`
+	Log       Log       `yaml:"log"`
+	Timezone  string    `yaml:"timezone"`
+	JWT       JWT       `yaml:"jwt"`
+	Session   Session   `yaml:"session"`
+	Login     Login     `yaml:"login"`
+	Password  Password  `yaml:"password"`
+	TOTP      TOTP      `yaml:"totp"`
+	Emergency Emergency `yaml:"emergency"`
+
+	// Location é o fuso de Timezone, usado para interpretar os turnos dos
+	// médicos; preenchido por Validate
+	Location *time.Location `yaml:"-"`
+}
+
+// Server é a porta HTTP e as origens aceitas pelo CORS
+type Server struct {
+	Port           string   `yaml:"port"`
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// Database é o DSN e o pool de conexões: máximo de conexões abertas e
+// ociosas, e por quanto tempo uma conexão pode ser reaproveitada ou ficar
+// ociosa
+type Database struct {
+	URL             string        `yaml:"url"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// Log define o nível de log: debug, info, warn ou error
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+// JWT é a emissão e validação dos access tokens. Algorithm aceita HS256
+// (assina com SecretKey) ou RS256 (assina com PrivateKeyFile, identificada
+// por KeyID; PublicKeysDir guarda <kid>.pem de chaves anteriores).
+type JWT struct {
+	SecretKey      string        `yaml:"secret_key"`
+	Issuer         string        `yaml:"issuer"`
+	Audience       string        `yaml:"audience"`
+	Algorithm      string        `yaml:"algorithm"`
+	KeyID          string        `yaml:"key_id"`
+	PrivateKeyFile string        `yaml:"private_key_file"`
+	PublicKeysDir  string        `yaml:"public_keys_dir"`
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl"`
+}
+
+// Session é a validade da sessão, renovada a cada uso do refresh token
+type Session struct {
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+// Login é a proteção contra força bruta: MaxAttempts falhas seguidas
+// bloqueiam o CPF por Lockout, dobrando a cada nova falha até LockoutMax
+type Login struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	Lockout     time.Duration `yaml:"lockout"`
+	LockoutMax  time.Duration `yaml:"lockout_max"`
+}
+
+// Password é a política de senha: tamanho mínimo, quantos tipos de caractere
+// exigir e quantas senhas anteriores não podem ser repetidas. ResetTTL é a
+// validade do token de redefinição emitido por um admin.
+type Password struct {
+	MinLength  int           `yaml:"min_length"`
+	MinClasses int           `yaml:"min_classes"`
+	History    int           `yaml:"history"`
+	ResetTTL   time.Duration `yaml:"reset_ttl"`
+}
+
+// TOTP é o segundo fator: Issuer é o nome mostrado no aplicativo
+// autenticador; RequiredRoles os papéis que não podem usar o sistema sem ele
+type TOTP struct {
+	Issuer        string       `yaml:"issuer"`
+	RequiredRoles []enums.Role `yaml:"required_roles"`
+}
+
+// Emergency é a quebra de vidro: por quanto tempo o acesso de emergência de
+// um médico a um paciente vale, e o webhook avisado a cada acesso (opcional)
+type Emergency struct {
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	WebhookURL string        `yaml:"webhook_url"`
+}
+
+// Default devolve a configuração usada quando nada é informado. Ela só não
+// passa em Validate por falta da SECRET_KEY.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:           "8080",
+			AllowedOrigins: []string{"http://localhost:3000"},
+		},
+		Database: Database{
+			URL:             "dev.db",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+			ConnMaxIdleTime: 10 * time.Minute,
+		},
+		Log:      Log{Level: "info"},
+		Timezone: "America/Belem",
+		JWT: JWT{
+			Issuer:         "cesupa-hospital",
+			Audience:       "cesupa-hospital-api",
+			Algorithm:      "HS256",
+			AccessTokenTTL: 15 * time.Minute,
+		},
+		Session: Session{RefreshTokenTTL: 7 * 24 * time.Hour},
+		Login: Login{
+			MaxAttempts: 5,
+			Lockout:     time.Minute,
+			LockoutMax:  time.Hour,
+		},
+		Password: Password{
+			MinLength:  8,
+			MinClasses: 3,
+			History:    5,
+			ResetTTL:   time.Hour,
+		},
+		TOTP: TOTP{
+			Issuer:        "CESUPA Hospital",
+			RequiredRoles: []enums.Role{enums.Admin},
+		},
+		Emergency: Emergency{AccessTTL: 4 * time.Hour},
+		Location:  mustLoadLocation("America/Belem"),
+	}
+}
+
+// Load monta a configuração a partir do arquivo em CONFIG_FILE (se houver) e
+// das variáveis de ambiente, e a valida. O erro lista todos os problemas de
+// uma vez, para que a API não suba com uma configuração pela metade.
+func Load() (*Config, error) {
+	return load(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+}
+
+func load(file string, lookup func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	if file != "" {
+		if err := cfg.readFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	problems := cfg.readEnv(lookup)
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.(*ValidationError).Problems...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// readFile aplica o arquivo YAML por cima dos valores atuais; chaves
+// desconhecidas são erro, para que um nome digitado errado não passe calado
+func (c *Config) readFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("unable to read config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", file, err)
+	}
+	return nil
+}
+
+// readEnv aplica as variáveis de ambiente definidas e devolve as que não
+// puderam ser lidas
+func (c *Config) readEnv(lookup func(string) (string, bool)) []string {
+	r := envReader{lookup: lookup}
+
+	r.string("PORT", &c.Server.Port)
+	r.list("ALLOWED_ORIGINS", &c.Server.AllowedOrigins)
+
+	r.string("DB_URL", &c.Database.URL)
+	r.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	r.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	r.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	r.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+
+	r.string("LOG_LEVEL", &c.Log.Level)
+	r.string("TIMEZONE", &c.Timezone)
+
+	r.string("SECRET_KEY", &c.JWT.SecretKey)
+	r.string("JWT_ISSUER", &c.JWT.Issuer)
+	r.string("JWT_AUDIENCE", &c.JWT.Audience)
+	r.string("JWT_ALGORITHM", &c.JWT.Algorithm)
+	r.string("JWT_KEY_ID", &c.JWT.KeyID)
+	r.string("JWT_PRIVATE_KEY_FILE", &c.JWT.PrivateKeyFile)
+	r.string("JWT_PUBLIC_KEYS_DIR", &c.JWT.PublicKeysDir)
+	r.duration("ACCESS_TOKEN_TTL", &c.JWT.AccessTokenTTL)
+	r.duration("REFRESH_TOKEN_TTL", &c.Session.RefreshTokenTTL)
+
+	r.int("LOGIN_MAX_ATTEMPTS", &c.Login.MaxAttempts)
+	r.duration("LOGIN_LOCKOUT", &c.Login.Lockout)
+	r.duration("LOGIN_LOCKOUT_MAX", &c.Login.LockoutMax)
+
+	r.int("PASSWORD_MIN_LENGTH", &c.Password.MinLength)
+	r.int("PASSWORD_MIN_CLASSES", &c.Password.MinClasses)
+	r.int("PASSWORD_HISTORY", &c.Password.History)
+	r.duration("PASSWORD_RESET_TTL", &c.Password.ResetTTL)
+
+	r.string("TOTP_ISSUER", &c.TOTP.Issuer)
+	var roles []string
+	if r.list("TOTP_REQUIRED_ROLES", &roles) {
+		c.TOTP.RequiredRoles = make([]enums.Role, len(roles))
+		for i, role := range roles {
+			c.TOTP.RequiredRoles[i] = enums.Role(role)
+		}
+	}
+
+	r.duration("EMERGENCY_ACCESS_TTL", &c.Emergency.AccessTTL)
+	r.string("EMERGENCY_WEBHOOK_URL", &c.Emergency.WebhookURL)
+
+	return r.problems
+}
+
+// envReader lê variáveis vazias como ausentes e acumula os valores que não
+// têm o tipo esperado
+type envReader struct {
+	lookup   func(string) (string, bool)
+	problems []string
+}
+
+func (r *envReader) value(name string) (string, bool) {
+	value, ok := r.lookup(name)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+func (r *envReader) string(name string, target *string) {
+	if value, ok := r.value(name); ok {
+		*target = value
+	}
+}
+
+func (r *envReader) int(name string, target *int) {
+	value, ok := r.value(name)
+	if !ok {
+		return
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s: %q is not an integer", name, value))
+		return
+	}
+	*target = n
+}
+
+func (r *envReader) duration(name string, target *time.Duration) {
+	value, ok := r.value(name)
+	if !ok {
+		return
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s: %q is not a duration (e.g. 15m, 1h)", name, value))
+		return
+	}
+	*target = d
+}
+
+// list lê uma lista separada por vírgulas; "none" deixa a lista vazia
+func (r *envReader) list(name string, target *[]string) bool {
+	value, ok := r.value(name)
+	if !ok {
+		return false
+	}
+	if value == "none" {
+		*target = nil
+		return true
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*target = items
+	return true
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// environment simula as variáveis de ambiente do processo
+func environment(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestDefaultOnlyMissesSecret(t *testing.T) {
+	err := Default().Validate()
+
+	var invalid *ValidationError
+	if assert.ErrorAs(t, err, &invalid) {
+		assert.Len(t, invalid.Problems, 1)
+		assert.Contains(t, invalid.Problems[0], "SECRET_KEY")
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	cfg, err := load("", environment(map[string]string{
+		"SECRET_KEY":          testSecret,
+		"PORT":                "9090",
+		"ALLOWED_ORIGINS":     "https://app.example.com, http://localhost:5173",
+		"DB_URL":              "postgres://user:secret@db:5432/hospital",
+		"DB_MAX_OPEN_CONNS":   "20",
+		"ACCESS_TOKEN_TTL":    "5m",
+		"LOG_LEVEL":           "debug",
+		"TIMEZONE":            "America/Sao_Paulo",
+		"TOTP_REQUIRED_ROLES": "admin, doctor",
+		"PASSWORD_HISTORY":    "",
+	}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, []string{"https://app.example.com", "http://localhost:5173"}, cfg.Server.AllowedOrigins)
+	assert.Equal(t, "postgres://user:secret@db:5432/hospital", cfg.Database.URL)
+	assert.Equal(t, 20, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 5*time.Minute, cfg.JWT.AccessTokenTTL)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "America/Sao_Paulo", cfg.Location.String())
+	assert.Equal(t, []enums.Role{enums.Admin, enums.Doctor}, cfg.TOTP.RequiredRoles)
+	// vazia conta como ausente
+	assert.Equal(t, 5, cfg.Password.History)
+
+	cfg, err = load("", environment(map[string]string{"SECRET_KEY": testSecret, "TOTP_REQUIRED_ROLES": "none"}))
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.TOTP.RequiredRoles)
+}
+
+func TestLoadFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+server:
+  port: "9000"
+  allowed_origins: [https://hospital.example.com]
+jwt:
+  secret_key: from-the-file-0123456789abcdef0123
+  access_token_ttl: 10m
+login:
+  lockout: 2m
+`), 0o600))
+
+	cfg, err := load(file, environment(map[string]string{"PORT": "9100"}))
+	assert.NoError(t, err)
+
+	// o ambiente vence o arquivo, que vence os padrões
+	assert.Equal(t, "9100", cfg.Server.Port)
+	assert.Equal(t, []string{"https://hospital.example.com"}, cfg.Server.AllowedOrigins)
+	assert.Equal(t, 10*time.Minute, cfg.JWT.AccessTokenTTL)
+	assert.Equal(t, 2*time.Minute, cfg.Login.Lockout)
+	assert.Equal(t, time.Hour, cfg.Login.LockoutMax)
+
+	t.Run("unknown key", func(t *testing.T) {
+		typo := filepath.Join(t.TempDir(), "typo.yaml")
+		assert.NoError(t, os.WriteFile(typo, []byte("jwt:\n  secret: x\n"), 0o600))
+		_, err := load(typo, environment(nil))
+		assert.ErrorContains(t, err, "secret")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := load(filepath.Join(t.TempDir(), "missing.yaml"), environment(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	_, err := load("", environment(map[string]string{
+		"SECRET_KEY":          "short",
+		"DB_MAX_OPEN_CONNS":   "ten",
+		"ACCESS_TOKEN_TTL":    "soon",
+		"ALLOWED_ORIGINS":     "*",
+		"LOG_LEVEL":           "verbose",
+		"TOTP_REQUIRED_ROLES": "admin,janitor",
+	}))
+
+	var invalid *ValidationError
+	if assert.ErrorAs(t, err, &invalid) {
+		for _, name := range []string{"SECRET_KEY", "DB_MAX_OPEN_CONNS", "ACCESS_TOKEN_TTL", "ALLOWED_ORIGINS", "LOG_LEVEL", "TOTP_REQUIRED_ROLES"} {
+			assert.Contains(t, err.Error(), name)
+		}
+		assert.Len(t, invalid.Problems, 6)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		problem string
+	}{
+		{name: "valid", change: func(c *Config) {}},
+		{name: "rs256 without key", change: func(c *Config) { c.JWT.Algorithm = "RS256"; c.JWT.SecretKey = "" }, problem: "JWT_PRIVATE_KEY_FILE"},
+		{name: "unknown algorithm", change: func(c *Config) { c.JWT.Algorithm = "HS512" }, problem: "JWT_ALGORITHM"},
+		{name: "refresh shorter than access", change: func(c *Config) { c.Session.RefreshTokenTTL = time.Minute }, problem: "REFRESH_TOKEN_TTL"},
+		{name: "zero ttl", change: func(c *Config) { c.Password.ResetTTL = 0 }, problem: "PASSWORD_RESET_TTL"},
+		{name: "origin with path", change: func(c *Config) { c.Server.AllowedOrigins = []string{"https://app.example.com/login"} }, problem: "ALLOWED_ORIGINS"},
+		{name: "no origins", change: func(c *Config) { c.Server.AllowedOrigins = nil }, problem: "ALLOWED_ORIGINS"},
+		{name: "invalid port", change: func(c *Config) { c.Server.Port = "80a" }, problem: "PORT"},
+		{name: "unsupported database", change: func(c *Config) { c.Database.URL = "mysql://db/hospital" }, problem: "DB_URL"},
+		{name: "more idle than open", change: func(c *Config) { c.Database.MaxIdleConns = 50 }, problem: "DB_MAX_IDLE_CONNS"},
+		{name: "lockout max below lockout", change: func(c *Config) { c.Login.LockoutMax = time.Second }, problem: "LOGIN_LOCKOUT_MAX"},
+		{name: "weak password policy", change: func(c *Config) { c.Password.MinLength = 4 }, problem: "PASSWORD_MIN_LENGTH"},
+		{name: "relative webhook", change: func(c *Config) { c.Emergency.WebhookURL = "/hooks" }, problem: "EMERGENCY_WEBHOOK_URL"},
+		{name: "unknown timezone", change: func(c *Config) { c.Timezone = "Mars/Olympus" }, problem: "TIMEZONE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.JWT.SecretKey = testSecret
+			tt.change(cfg)
+
+			err := cfg.Validate()
+			if tt.problem == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.problem)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+)
+
+// MinSecretKeyLength é o tamanho mínimo da SECRET_KEY em HS256: 32 bytes,
+// o mesmo do hash usado na assinatura
+const MinSecretKeyLength = 32
+
+// LogLevels são os níveis aceitos em Log.Level, do mais ao menos verboso
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// ValidationError lista todos os problemas encontrados na configuração
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate confere a configuração inteira e preenche Location. Os nomes nas
+// mensagens são os das variáveis de ambiente, que valem também para o arquivo.
+func (c *Config) Validate() error {
+	v := validator{}
+
+	c.validateServer(&v)
+	c.validateDatabase(&v)
+	c.validateJWT(&v)
+	c.validateSecurity(&v)
+
+	if !slices.Contains(LogLevels, c.Log.Level) {
+		v.add("LOG_LEVEL: %q must be one of %s", c.Log.Level, strings.Join(LogLevels, ", "))
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil || c.Timezone == "" {
+		v.add("TIMEZONE: %q is not an IANA time zone (e.g. America/Belem)", c.Timezone)
+	} else {
+		c.Location = loc
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (c *Config) validateServer(v *validator) {
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.add("PORT: %q must be a number between 1 and 65535", c.Server.Port)
+	}
+
+	if len(c.Server.AllowedOrigins) == 0 {
+		v.add("ALLOWED_ORIGINS: at least one origin is required")
+	}
+	for _, origin := range c.Server.AllowedOrigins {
+		if err := checkOrigin(origin); err != nil {
+			v.add("ALLOWED_ORIGINS: %q %v", origin, err)
+		}
+	}
+}
+
+// checkOrigin aceita só esquema e host, como o navegador envia em Origin. O
+// curinga "*" não é aceito porque o CORS libera credenciais.
+func checkOrigin(origin string) error {
+	if origin == "*" {
+		return fmt.Errorf("is not allowed together with credentials; list the origins explicitly")
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http(s) origin such as https://app.example.com")
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("must not have a path, query or credentials")
+	}
+	return nil
+}
+
+func (c *Config) validateDatabase(v *validator) {
+	db := c.Database
+
+	if db.URL == "" {
+		v.add("DB_URL: is required")
+	} else if scheme, _, found := strings.Cut(db.URL, "://"); found &&
+		!slices.Contains([]string{"sqlite", "sqlite3", "postgres", "postgresql"}, scheme) {
+		v.add("DB_URL: unsupported scheme %q (use sqlite:// or postgres://)", scheme)
+	}
+
+	if db.MaxOpenConns < 1 {
+		v.add("DB_MAX_OPEN_CONNS: must be at least 1, got %d", db.MaxOpenConns)
+	}
+	if db.MaxIdleConns < 0 || db.MaxIdleConns > db.MaxOpenConns {
+		v.add("DB_MAX_IDLE_CONNS: must be between 0 and DB_MAX_OPEN_CONNS (%d), got %d", db.MaxOpenConns, db.MaxIdleConns)
+	}
+	v.positive("DB_CONN_MAX_LIFETIME", db.ConnMaxLifetime)
+	v.positive("DB_CONN_MAX_IDLE_TIME", db.ConnMaxIdleTime)
+}
+
+func (c *Config) validateJWT(v *validator) {
+	jwt := c.JWT
+
+	switch strings.ToUpper(jwt.Algorithm) {
+	case "HS256":
+		if len(jwt.SecretKey) < MinSecretKeyLength {
+			v.add("SECRET_KEY: must have at least %d characters for HS256, got %d (generate one with `openssl rand -hex 32`)", MinSecretKeyLength, len(jwt.SecretKey))
+		}
+	case "RS256":
+		if jwt.KeyID == "" || jwt.PrivateKeyFile == "" {
+			v.add("JWT_KEY_ID and JWT_PRIVATE_KEY_FILE: both are required for RS256")
+		}
+	default:
+		v.add("JWT_ALGORITHM: %q must be HS256 or RS256", jwt.Algorithm)
+	}
+
+	if jwt.Issuer == "" {
+		v.add("JWT_ISSUER: is required")
+	}
+	if jwt.Audience == "" {
+		v.add("JWT_AUDIENCE: is required")
+	}
+
+	v.positive("ACCESS_TOKEN_TTL", jwt.AccessTokenTTL)
+	v.positive("REFRESH_TOKEN_TTL", c.Session.RefreshTokenTTL)
+	if jwt.AccessTokenTTL > 0 && c.Session.RefreshTokenTTL > 0 && c.Session.RefreshTokenTTL <= jwt.AccessTokenTTL {
+		v.add("REFRESH_TOKEN_TTL: must be longer than ACCESS_TOKEN_TTL (%s), got %s", jwt.AccessTokenTTL, c.Session.RefreshTokenTTL)
+	}
+}
+
+// validateSecurity confere login, senha, segundo fator e quebra de vidro
+func (c *Config) validateSecurity(v *validator) {
+	if c.Login.MaxAttempts < 1 {
+		v.add("LOGIN_MAX_ATTEMPTS: must be at least 1, got %d", c.Login.MaxAttempts)
+	}
+	v.positive("LOGIN_LOCKOUT", c.Login.Lockout)
+	v.positive("LOGIN_LOCKOUT_MAX", c.Login.LockoutMax)
+	if c.Login.LockoutMax > 0 && c.Login.LockoutMax < c.Login.Lockout {
+		v.add("LOGIN_LOCKOUT_MAX: must not be shorter than LOGIN_LOCKOUT (%s), got %s", c.Login.Lockout, c.Login.LockoutMax)
+	}
+
+	if c.Password.MinLength < 8 {
+		v.add("PASSWORD_MIN_LENGTH: must be at least 8, got %d", c.Password.MinLength)
+	}
+	if c.Password.MinClasses < 1 || c.Password.MinClasses > 4 {
+		v.add("PASSWORD_MIN_CLASSES: must be between 1 and 4, got %d", c.Password.MinClasses)
+	}
+	if c.Password.History < 1 {
+		v.add("PASSWORD_HISTORY: must be at least 1, got %d", c.Password.History)
+	}
+	v.positive("PASSWORD_RESET_TTL", c.Password.ResetTTL)
+
+	if c.TOTP.Issuer == "" {
+		v.add("TOTP_ISSUER: is required")
+	}
+	for _, role := range c.TOTP.RequiredRoles {
+		if !slices.Contains(enums.Roles, role) {
+			v.add("TOTP_REQUIRED_ROLES: unknown role %q", role)
+		}
+	}
+
+	v.positive("EMERGENCY_ACCESS_TTL", c.Emergency.AccessTTL)
+	if webhook := c.Emergency.WebhookURL; webhook != "" {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add("EMERGENCY_WEBHOOK_URL: %q must be an absolute http(s) URL", webhook)
+		}
+	}
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) positive(name string, d time.Duration) {
+	if d <= 0 {
+		v.add("%s: must be a positive duration, got %s", name, d)
+	}
+}
@@ -0,0 +1,149 @@
+package cpf
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+)
+
+// CPF é o número do Cadastro de Pessoas Físicas. O valor canônico contém
+// apenas os 11 dígitos; a máscara (000.000.000-00) só é usada na exibição,
+// inclusive no JSON das respostas.
+type CPF string
+
+var ErrInvalid = errors.New("invalid CPF")
+
+var pattern = regexp.MustCompile(`^(\d{11}|\d{3}\.\d{3}\.\d{3}-\d{2})$`)
+
+// Parse aceita o CPF com ou sem máscara e devolve apenas os dígitos,
+// conferindo os dígitos verificadores.
+func Parse(value string) (CPF, error) {
+	value = strings.TrimSpace(value)
+	if !pattern.MatchString(value) {
+		return "", ErrInvalid
+	}
+
+	digits := onlyDigits(value)
+	if !validDigits(digits) {
+		return "", ErrInvalid
+	}
+	return CPF(digits), nil
+}
+
+// IsValid informa se o valor é um CPF válido, com ou sem máscara.
+func IsValid(value string) bool {
+	_, err := Parse(value)
+	return err == nil
+}
+
+// Generate sorteia um CPF válido a partir do gerador informado, para dados de
+// demonstração: a mesma semente produz sempre a mesma sequência.
+func Generate(rng *rand.Rand) CPF {
+	for {
+		digits := fmt.Sprintf("%09d", rng.Intn(1_000_000_000))
+		digits += string(checkDigit(digits))
+		digits += string(checkDigit(digits))
+		if validDigits(digits) {
+			return CPF(digits)
+		}
+	}
+}
+
+func (c CPF) String() string {
+	return string(c)
+}
+
+// Digits devolve só os dígitos, com ou sem máscara na entrada
+func (c CPF) Digits() string {
+	return onlyDigits(string(c))
+}
+
+// Formatted devolve o CPF no formato 000.000.000-00. Valores que não têm
+// 11 dígitos são devolvidos sem alteração.
+func (c CPF) Formatted() string {
+	digits := onlyDigits(string(c))
+	if len(digits) != 11 {
+		return string(c)
+	}
+	return fmt.Sprintf("%s.%s.%s-%s", digits[0:3], digits[3:6], digits[6:9], digits[9:11])
+}
+
+// MarshalJSON devolve o CPF com máscara nas respostas da API; o banco e os
+// filtros continuam usando só os dígitos.
+func (c CPF) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Formatted())
+}
+
+// UnmarshalJSON normaliza CPFs válidos para a forma canônica. Valores
+// inválidos são mantidos como vieram para que a validação de binding os rejeite.
+func (c *CPF) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	if parsed, err := Parse(value); err == nil {
+		*c = parsed
+	} else {
+		*c = CPF(value)
+	}
+	return nil
+}
+
+// Value grava somente os dígitos, para que o mesmo CPF com e sem máscara
+// caia no mesmo registro (e na mesma restrição unique).
+func (c CPF) Value() (driver.Value, error) {
+	return c.Digits(), nil
+}
+
+func (c *CPF) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		*c = CPF(v)
+	case []byte:
+		*c = CPF(v)
+	case nil:
+		*c = ""
+	default:
+		return fmt.Errorf("unable to scan %T into CPF", value)
+	}
+	return nil
+}
+
+func onlyDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func validDigits(digits string) bool {
+	if len(digits) != 11 || strings.Count(digits, digits[:1]) == 11 {
+		return false
+	}
+
+	return checkDigit(digits[:9]) == digits[9] && checkDigit(digits[:10]) == digits[10]
+}
+
+// checkDigit calcula o próximo dígito verificador pelo módulo 11.
+func checkDigit(digits string) byte {
+	sum := 0
+	weight := len(digits) + 1
+	for i := range len(digits) {
+		sum += int(digits[i]-'0') * weight
+		weight--
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
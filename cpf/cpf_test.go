@@ -0,0 +1,90 @@
+package cpf
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected CPF
+		wantErr  bool
+	}{
+		{name: "digits only", input: "52998224725", expected: "52998224725"},
+		{name: "formatted", input: "529.982.247-25", expected: "52998224725"},
+		{name: "surrounding spaces", input: " 529.982.247-25 ", expected: "52998224725"},
+		{name: "wrong check digit", input: "52998224724", wantErr: true},
+		{name: "too short", input: "123", wantErr: true},
+		{name: "repeated digits", input: "11111111111", wantErr: true},
+		{name: "partial mask", input: "529982247-25", wantErr: true},
+		{name: "letters", input: "5299822472a", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	first := rand.New(rand.NewSource(42))
+	second := rand.New(rand.NewSource(42))
+
+	for range 100 {
+		generated := Generate(first)
+		assert.True(t, IsValid(string(generated)), generated)
+		assert.Equal(t, generated, Generate(second))
+	}
+}
+
+func TestFormatted(t *testing.T) {
+	assert.Equal(t, "529.982.247-25", CPF("52998224725").Formatted())
+	assert.Equal(t, "123", CPF("123").Formatted())
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	var payload struct {
+		CPF CPF `json:"cpf"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"cpf":"529.982.247-25"}`), &payload))
+	assert.Equal(t, CPF("52998224725"), payload.CPF)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"cpf":"123"}`), &payload))
+	assert.Equal(t, CPF("123"), payload.CPF)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"cpf":123}`), &payload))
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		CPF CPF `json:"cpf"`
+	}{CPF: "52998224725"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"cpf":"529.982.247-25"}`, string(data))
+
+	var payload struct {
+		CPF CPF `json:"cpf"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &payload))
+	assert.Equal(t, CPF("52998224725"), payload.CPF)
+}
+
+func TestValue(t *testing.T) {
+	value, err := CPF("529.982.247-25").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "52998224725", value)
+}
@@ -0,0 +1,64 @@
+package crm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// UFs são as unidades federativas com Conselho Regional de Medicina
+var UFs = []string{
+	"AC", "AL", "AM", "AP", "BA", "CE", "DF", "ES", "GO", "MA", "MG", "MS", "MT", "PA",
+	"PB", "PE", "PI", "PR", "RJ", "RN", "RO", "RR", "RS", "SC", "SE", "SP", "TO",
+}
+
+var (
+	ErrInvalid   = errors.New("invalid CRM number")
+	ErrInvalidUF = errors.New("invalid CRM UF")
+)
+
+// pattern aceita de 1 a 7 dígitos, com pontos opcionais (ex.: 123.456)
+var pattern = regexp.MustCompile(`^\d{1,3}(\.?\d{3}){0,2}$`)
+
+// Parse aceita o número com ou sem pontos e devolve apenas os dígitos, sem
+// zeros à esquerda, que é a forma gravada no banco.
+func Parse(number string) (string, error) {
+	number = strings.TrimSpace(number)
+	if !pattern.MatchString(number) {
+		return "", ErrInvalid
+	}
+
+	digits := strings.TrimLeft(strings.ReplaceAll(number, ".", ""), "0")
+	if digits == "" || len(digits) > 7 {
+		return "", ErrInvalid
+	}
+	return digits, nil
+}
+
+// ParseUF normaliza a UF para maiúsculas e confere se ela existe
+func ParseUF(uf string) (string, error) {
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	if !slices.Contains(UFs, uf) {
+		return "", ErrInvalidUF
+	}
+	return uf, nil
+}
+
+// IsValid informa se o valor é um número de CRM válido
+func IsValid(number string) bool {
+	_, err := Parse(number)
+	return err == nil
+}
+
+// IsValidUF informa se o valor é uma UF válida, em qualquer caixa
+func IsValidUF(uf string) bool {
+	_, err := ParseUF(uf)
+	return err == nil
+}
+
+// Format devolve o registro como aparece em documentos: CRM/PA 123456
+func Format(number, uf string) string {
+	return fmt.Sprintf("CRM/%s %s", uf, number)
+}
@@ -0,0 +1,50 @@
+package crm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "digits only", input: "123456", expected: "123456"},
+		{name: "with dots", input: "1.234.567", expected: "1234567"},
+		{name: "leading zeros", input: "00042", expected: "42"},
+		{name: "surrounding spaces", input: " 12345 ", expected: "12345"},
+		{name: "too long", input: "12345678", wantErr: true},
+		{name: "only zeros", input: "0000", wantErr: true},
+		{name: "misplaced dot", input: "12.34", wantErr: true},
+		{name: "letters", input: "12A45", wantErr: true},
+		{name: "with UF", input: "12345/PA", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestParseUF(t *testing.T) {
+	uf, err := ParseUF(" pa ")
+	assert.NoError(t, err)
+	assert.Equal(t, "PA", uf)
+
+	_, err = ParseUF("XX")
+	assert.ErrorIs(t, err, ErrInvalidUF)
+	assert.False(t, IsValidUF(""))
+	assert.Equal(t, "CRM/PA 123456", Format("123456", "PA"))
+}
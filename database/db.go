@@ -1,33 +1,109 @@
 package database
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
-	"github.com/andresidrim/cesupa-hospital/env"
-	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/config"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-func Connect() *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(env.DB_URL), &gorm.Config{})
+// Dialector escolhe o driver pelo esquema do DSN: sqlite://<arquivo> ou
+// postgres://... (postgresql:// também). Sem esquema, o DSN é o caminho de
+// um arquivo SQLite, como era antes.
+func Dialector(dsn string) (gorm.Dialector, error) {
+	scheme, rest, found := strings.Cut(dsn, "://")
+	if !found {
+		return sqlite.Open(dsn), nil
+	}
+
+	switch scheme {
+	case "sqlite", "sqlite3":
+		return sqlite.Open(rest), nil
+	case "postgres", "postgresql":
+		return postgres.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("unsupported database scheme %q", scheme)
+}
+
+// SQLiteFile devolve o arquivo do banco quando o DSN é SQLite
+func SQLiteFile(dsn string) (string, bool) {
+	scheme, rest, found := strings.Cut(dsn, "://")
+	if !found {
+		return dsn, true
+	}
+	if scheme == "sqlite" || scheme == "sqlite3" {
+		return rest, true
+	}
+	return "", false
+}
+
+// Open conecta ao banco sem conferir o esquema; usado pelo cmd/migrate. O
+// nível de log da aplicação decide o que o GORM registra: em debug, todo SQL.
+func Open(cfg config.Database, logLevel string) *gorm.DB {
+	dialector, err := Dialector(cfg.URL)
+	if err != nil {
+		log.Fatalf("invalid DB_URL: %v", err)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(gormLogLevel(logLevel))})
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
 
-	log.Println("Database connected successfully")
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("failed to configure connection pool: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	log.Printf("Database connected successfully (%s)", db.Dialector.Name())
+
+	return db
+}
+
+// Connect conecta ao banco e se recusa a seguir se o esquema não estiver
+// na versão das migrações embutidas no binário
+func Connect(cfg config.Database, logLevel string) *gorm.DB {
+	db := Open(cfg, logLevel)
 
-	autoMigrate(db)
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		log.Fatalf("failed to read migrations: %v", err)
+	}
+	if err := migrator.Check(); err != nil {
+		log.Fatalf("database schema is not up to date: %v (run `go run ./cmd/migrate up`)", err)
+	}
+
+	log.Printf("Database schema at version %d", migrator.Latest())
 
 	return db
 }
 
-func autoMigrate(db *gorm.DB) {
-	if err := db.AutoMigrate(
-		&models.Pacient{},
-	); err != nil {
-		log.Fatalf("failed to auto migrate: %v", err)
+func gormLogLevel(level string) logger.LogLevel {
+	switch level {
+	case "debug":
+		return logger.Info
+	case "error":
+		return logger.Error
+	default:
+		return logger.Warn
 	}
+}
 
-	log.Println("Database auto migrated")
+// Contains filtra os registros cuja coluna contém o texto, sem diferenciar
+// maiúsculas: ILIKE no Postgres; no SQLite o LIKE já ignora a caixa.
+func Contains(query *gorm.DB, column, text string) *gorm.DB {
+	operator := "LIKE"
+	if query.Dialector.Name() == "postgres" {
+		operator = "ILIKE"
+	}
+	return query.Where(column+" "+operator+" ?", "%"+text+"%")
 }
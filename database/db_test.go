@@ -0,0 +1,60 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/database"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestDialector(t *testing.T) {
+	tests := []struct {
+		dsn       string
+		want      string
+		file      string
+		wantError bool
+	}{
+		{dsn: "dev.db", want: "sqlite", file: "dev.db"},
+		{dsn: "sqlite://dev.db", want: "sqlite", file: "dev.db"},
+		{dsn: "sqlite:///var/lib/hospital.db", want: "sqlite", file: "/var/lib/hospital.db"},
+		{dsn: "file::memory:?cache=shared", want: "sqlite", file: "file::memory:?cache=shared"},
+		{dsn: "postgres://hospital:secret@db:5432/hospital?sslmode=disable", want: "postgres"},
+		{dsn: "postgresql://db/hospital", want: "postgres"},
+		{dsn: "mysql://db/hospital", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			dialector, err := database.Dialector(tt.dsn)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, dialector.Name())
+
+			file, ok := database.SQLiteFile(tt.dsn)
+			assert.Equal(t, tt.want == "sqlite", ok)
+			assert.Equal(t, tt.file, file)
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	for dsn, want := range map[string]string{
+		"sqlite://:memory:":  "name LIKE",
+		"postgres://db/test": "name ILIKE",
+	} {
+		dialector, err := database.Dialector(dsn)
+		assert.NoError(t, err)
+		db, err := gorm.Open(dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+		assert.NoError(t, err)
+
+		var pacients []models.Pacient
+		stmt := database.Contains(db.Model(&models.Pacient{}), "name", "ana").Find(&pacients).Statement
+		assert.Contains(t, stmt.SQL.String(), want, dsn)
+		assert.Contains(t, stmt.Vars, "%ana%", dsn)
+	}
+}
@@ -0,0 +1,112 @@
+// Package dbtest abre bancos isolados para os testes dos serviços. Por padrão
+// cada teste usa um SQLite em memória; com TEST_DB_URL=postgres://... cada
+// teste ganha um schema próprio no Postgres, apagado ao final, e as mesmas
+// suítes rodam contra os dois bancos.
+package dbtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const envURL = "TEST_DB_URL"
+
+// Open devolve um banco vazio com as tabelas dos modelos informados
+func Open(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+
+	var db *gorm.DB
+	if dsn := os.Getenv(envURL); dsn != "" && !isSQLite(dsn) {
+		db = openPostgres(t, dsn)
+	} else {
+		db = openSQLite(t)
+	}
+
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			t.Fatalf("failed to migrate test database: %v", err)
+		}
+	}
+	return db
+}
+
+func isSQLite(dsn string) bool {
+	_, ok := database.SQLiteFile(dsn)
+	return ok
+}
+
+func openSQLite(t testing.TB) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	closeOnCleanup(t, db)
+
+	// cada conexão com :memory: é um banco novo e vazio; com uma só, testes
+	// concorrentes esperam a vez em vez de enxergarem bancos diferentes
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func openPostgres(t testing.TB, dsn string) *gorm.DB {
+	dialector, err := database.Dialector(dsn)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", envURL, err)
+	}
+	admin, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", envURL, err)
+	}
+	closeOnCleanup(t, admin)
+
+	schema := "test_" + randomSuffix(t)
+	if err := admin.Exec(`CREATE SCHEMA "` + schema + `"`).Error; err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(`DROP SCHEMA "` + schema + `" CASCADE`)
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", envURL, err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := gorm.Open(postgres.Open(u.String()), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to schema %s: %v", schema, err)
+	}
+	closeOnCleanup(t, db)
+	return db
+}
+
+func closeOnCleanup(t testing.TB, db *gorm.DB) {
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+func randomSuffix(t testing.TB) string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatalf("failed to generate schema name: %v", err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,10 @@
+package database
+
+import "errors"
+
+var (
+	ErrPendingMigrations = errors.New("database has pending migrations")
+	ErrUnknownMigration  = errors.New("database has migrations unknown to this binary")
+	ErrNoSuchMigration   = errors.New("no such migration version")
+	ErrNothingToRollBack = errors.New("no migration to roll back")
+)
@@ -0,0 +1,318 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Os scripts ficam em migrations/<dialeto>/NNNN_nome.up.sql e
+// NNNN_nome.down.sql e vão embutidos no binário.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration é uma versão do esquema, com o SQL para aplicá-la e desfazê-la
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration registra uma migração aplicada no banco
+type SchemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus diz se uma migração conhecida já foi aplicada
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations lê os scripts embutidos do dialeto, em ordem de versão
+func Migrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %w", dialect, err)
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(file, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(file, "."+direction+".sql"), "_")
+		version, err := strconv.ParseUint(prefix, 10, 32)
+		if !ok || err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[uint(version)]
+		if !exists {
+			m = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down scripts", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Migrator aplica e desfaz as migrações do dialeto do banco, registrando
+// cada versão em schema_migrations. Cada passo roda em uma transação.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Migrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version integer PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamp NOT NULL
+	)`).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest é a versão mais recente conhecida pelo binário
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Applied lista as migrações registradas no banco, em ordem de versão
+func (m *Migrator) Applied() ([]SchemaMigration, error) {
+	var applied []SchemaMigration
+	if err := m.db.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Current é a maior versão aplicada (0 em um banco vazio)
+func (m *Migrator) Current() (uint, error) {
+	applied, err := m.Applied()
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Status junta as migrações conhecidas com as aplicadas
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.appliedByVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i].Migration = migration
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status[i].AppliedAt = &appliedAt
+		}
+	}
+	return status, nil
+}
+
+// Check falha se o banco não estiver exatamente na versão do binário
+func (m *Migrator) Check() error {
+	applied, err := m.appliedByVersion()
+	if err != nil {
+		return err
+	}
+
+	known := map[uint]bool{}
+	pending := 0
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+
+	for version := range applied {
+		if !known[version] {
+			return fmt.Errorf("%w: version %d", ErrUnknownMigration, version)
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d of %d not applied", ErrPendingMigrations, pending, len(m.migrations))
+	}
+	return nil
+}
+
+// Up aplica todas as migrações pendentes
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.Latest())
+}
+
+// Down desfaz a última migração aplicada
+func (m *Migrator) Down() (*Migration, error) {
+	current, err := m.Current()
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, ErrNothingToRollBack
+	}
+
+	migration, err := m.find(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.rollback(migration); err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
+
+// To leva o banco até a versão pedida, aplicando ou desfazendo migrações.
+// A versão 0 desfaz todas. Devolve as migrações executadas, na ordem.
+func (m *Migrator) To(version uint) ([]Migration, error) {
+	if version != 0 {
+		if _, err := m.find(version); err != nil {
+			return nil, err
+		}
+	}
+
+	applied, err := m.appliedByVersion()
+	if err != nil {
+		return nil, err
+	}
+	for v := range applied {
+		if _, err := m.find(v); err != nil {
+			return nil, fmt.Errorf("%w: version %d", ErrUnknownMigration, v)
+		}
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := m.apply(migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := m.rollback(migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if check, ok := preflights[migration.Name]; ok {
+			if err := check(tx); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+		}
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+		}
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		return tx.Where("version = ?", migration.Version).Delete(&SchemaMigration{}).Error
+	})
+}
+
+func (m *Migrator) find(version uint) (Migration, error) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("%w: %d", ErrNoSuchMigration, version)
+}
+
+func (m *Migrator) appliedByVersion() (map[uint]SchemaMigration, error) {
+	applied, err := m.Applied()
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]SchemaMigration, len(applied))
+	for _, row := range applied {
+		byVersion[row.Version] = row
+	}
+	return byVersion, nil
+}
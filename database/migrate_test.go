@@ -0,0 +1,281 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/database"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// schemaModels são os modelos que as migrações precisam cobrir
+var schemaModels = []any{
+	&models.User{},
+	&models.Specialty{},
+	&models.DoctorProfile{},
+	&models.Pacient{},
+	&models.Appointment{},
+	&models.AppointmentEvent{},
+	&models.Shift{},
+	&models.Session{},
+	&models.LoginAttempt{},
+	&models.PasswordHistory{},
+	&models.PasswordResetToken{},
+	&models.RecoveryCode{},
+	&models.LoginChallenge{},
+	&models.AuditLog{},
+	&models.RolePermission{},
+	&models.PermissionSeed{},
+	&models.EmergencyAccess{},
+	&models.AvailabilityBlock{},
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t)
+}
+
+func TestMigrations(t *testing.T) {
+	for _, dialect := range []string{"sqlite", "postgres"} {
+		migrations, err := database.Migrations(dialect)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+
+		for i, m := range migrations {
+			assert.NotEmpty(t, m.Name)
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+			if i > 0 {
+				assert.Greater(t, m.Version, migrations[i-1].Version)
+			}
+		}
+	}
+
+	// os dois dialetos precisam andar juntos
+	sqliteMigrations, _ := database.Migrations("sqlite")
+	postgresMigrations, _ := database.Migrations("postgres")
+	assert.Len(t, postgresMigrations, len(sqliteMigrations))
+	for i := range sqliteMigrations {
+		assert.Equal(t, sqliteMigrations[i].Version, postgresMigrations[i].Version)
+		assert.Equal(t, sqliteMigrations[i].Name, postgresMigrations[i].Name)
+	}
+
+	_, err := database.Migrations("oracle")
+	assert.Error(t, err)
+}
+
+func TestMigrationsCoverModels(t *testing.T) {
+	db := setupTestDB(t)
+	migrator, err := database.NewMigrator(db)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	for _, model := range schemaModels {
+		stmt := &gorm.Statement{DB: db}
+		assert.NoError(t, stmt.Parse(model))
+		table := stmt.Schema.Table
+
+		if !assert.True(t, db.Migrator().HasTable(table), "missing table %s", table) {
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, db.Migrator().HasColumn(model, field.DBName), "missing column %s.%s", table, field.DBName)
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			assert.True(t, db.Migrator().HasIndex(model, index.Name), "missing index %s on %s", index.Name, table)
+		}
+		for _, rel := range stmt.Schema.Relationships.Relations {
+			if rel.Type == schema.Many2Many {
+				assert.True(t, db.Migrator().HasTable(rel.JoinTable.Table), "missing join table %s", rel.JoinTable.Table)
+			}
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db := setupTestDB(t)
+	migrator, err := database.NewMigrator(db)
+	assert.NoError(t, err)
+	latest := migrator.Latest()
+	migrations, err := database.Migrations(db.Dialector.Name())
+	assert.NoError(t, err)
+
+	// banco vazio: tudo pendente
+	assert.ErrorIs(t, migrator.Check(), database.ErrPendingMigrations)
+	current, err := migrator.Current()
+	assert.NoError(t, err)
+	assert.Zero(t, current)
+
+	done, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Len(t, done, len(migrations))
+	assert.NoError(t, migrator.Check())
+
+	status, err := migrator.Status()
+	assert.NoError(t, err)
+	for _, s := range status {
+		assert.NotNil(t, s.AppliedAt, "migration %d", s.Version)
+	}
+
+	// aplicar de novo não faz nada
+	done, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Empty(t, done)
+
+	// o esquema funciona de verdade
+	assert.NoError(t, db.Create(&models.Specialty{Name: "Cardiologia"}).Error)
+
+	rolledBack, err := migrator.Down()
+	assert.NoError(t, err)
+	assert.Equal(t, latest, rolledBack.Version)
+	assert.ErrorIs(t, migrator.Check(), database.ErrPendingMigrations)
+
+	_, err = migrator.To(0)
+	assert.NoError(t, err)
+	for _, model := range schemaModels {
+		assert.False(t, db.Migrator().HasTable(model))
+	}
+	assert.True(t, db.Migrator().HasTable(&database.SchemaMigration{}))
+
+	_, err = migrator.Down()
+	assert.ErrorIs(t, err, database.ErrNothingToRollBack)
+
+	_, err = migrator.To(latest + 1)
+	assert.ErrorIs(t, err, database.ErrNoSuchMigration)
+
+	done, err = migrator.To(latest)
+	assert.NoError(t, err)
+	assert.Len(t, done, len(migrations))
+
+	// banco migrado por um binário mais novo
+	assert.NoError(t, db.Create(&database.SchemaMigration{Version: latest + 1, Name: "future"}).Error)
+	assert.ErrorIs(t, migrator.Check(), database.ErrUnknownMigration)
+	_, err = migrator.Up()
+	assert.ErrorIs(t, err, database.ErrUnknownMigration)
+}
+
+// TestMigrationsKeepAuditAppendOnly confere que o próprio banco recusa alterar
+// ou apagar a trilha de auditoria, mesmo por SQL direto sem os hooks do modelo
+func TestMigrationsKeepAuditAppendOnly(t *testing.T) {
+	db := setupTestDB(t)
+	migrator, err := database.NewMigrator(db)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	entry := models.AuditLog{UserID: 1, Action: enums.AuditRead, Method: "GET", Route: "/pacients", Path: "/pacients", ResourceType: enums.AuditPacient}
+	assert.NoError(t, db.Create(&entry).Error)
+
+	assert.ErrorContains(t, db.Exec("UPDATE audit_logs SET user_id = 2 WHERE id = ?", entry.ID).Error, "append-only")
+	assert.ErrorContains(t, db.Exec("DELETE FROM audit_logs WHERE id = ?", entry.ID).Error, "append-only")
+
+	var saved models.AuditLog
+	assert.NoError(t, db.First(&saved, entry.ID).Error)
+	assert.Equal(t, uint(1), saved.UserID)
+}
+
+// legacyPacient é a tabela de pacientes como o AutoMigrate a criava antes
+// das migrações, o único esquema que existia nesses bancos
+type legacyPacient struct {
+	gorm.Model
+	Name        string    `gorm:"not null"`
+	BirthDate   time.Time `gorm:"type:date;not null"`
+	CPF         string    `gorm:"unique;not null"`
+	Sex         string    `gorm:"not null"`
+	PhoneNumber string    `gorm:"not null"`
+	Address     string    `gorm:"not null"`
+	Email       *string
+	BloodType   *string
+	Allergies   *string
+}
+
+func (legacyPacient) TableName() string {
+	return "pacients"
+}
+
+func TestMigratorUpgradesBaselineDatabase(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&legacyPacient{}))
+
+	birth := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	active := legacyPacient{Name: "Maria", BirthDate: birth, CPF: "529.982.247-25", Sex: "female", PhoneNumber: "91999999999", Address: "Rua A"}
+	removed := legacyPacient{Name: "João", BirthDate: birth, CPF: "11144477735", Sex: "male", PhoneNumber: "91988888888", Address: "Rua B"}
+	assert.NoError(t, db.Create(&active).Error)
+	assert.NoError(t, db.Create(&removed).Error)
+	assert.NoError(t, db.Delete(&removed).Error)
+
+	migrator, err := database.NewMigrator(db)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.NoError(t, migrator.Check())
+
+	var pacients []models.Pacient
+	assert.NoError(t, db.Order("id").Find(&pacients).Error)
+	if assert.Len(t, pacients, 2) {
+		assert.Equal(t, enums.PacientActive, pacients[0].Status)
+		assert.Equal(t, "52998224725", pacients[0].CPF.Digits())
+		assert.Nil(t, pacients[0].InactivatedAt)
+
+		// o soft delete antigo vira inativação
+		assert.Equal(t, enums.PacientInactive, pacients[1].Status)
+		assert.NotNil(t, pacients[1].InactivatedAt)
+	}
+
+	// o resto do esquema funciona sobre os dados antigos
+	doctor := models.User{Name: "Dra. Ana", CPF: "39053344705", Password: "hash", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	assert.NoError(t, db.Create(&models.Appointment{PacientID: pacients[0].ID, UserID: doctor.ID, Date: birth, EndDate: birth.Add(time.Hour)}).Error)
+
+	// e dá para voltar ao esquema antigo sem perder os pacientes
+	_, err = migrator.To(1)
+	assert.NoError(t, err)
+	assert.False(t, db.Migrator().HasColumn("pacients", "status"))
+	var count int64
+	assert.NoError(t, db.Model(&legacyPacient{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	assert.NoError(t, db.Unscoped().Model(&legacyPacient{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestMigratorReportsLegacyCPFs(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&legacyPacient{}))
+
+	birth := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, document := range []string{"529.982.247-25", "52998224725", "123.456.789-00", "11144477735"} {
+		assert.NoError(t, db.Create(&legacyPacient{Name: "Maria", BirthDate: birth, CPF: document, Sex: "female", PhoneNumber: "91999999999", Address: "Rua A"}).Error)
+	}
+
+	migrator, err := database.NewMigrator(db)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+
+	var report *database.LegacyCPFError
+	if assert.ErrorAs(t, err, &report) {
+		assert.Equal(t, map[string][]database.LegacyCPF{
+			"52998224725": {{ID: 1, CPF: "529.982.247-25"}, {ID: 2, CPF: "52998224725"}},
+		}, report.Duplicates)
+		assert.Equal(t, []database.LegacyCPF{{ID: 3, CPF: "123.456.789-00"}}, report.Invalid)
+		assert.ErrorContains(t, err, `duplicate CPF 52998224725: id 1 ("529.982.247-25"), id 2 ("52998224725")`)
+	}
+
+	// nada da migração foi aplicado, e ela roda depois de os dados serem corrigidos
+	current, err := migrator.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), current)
+	assert.False(t, db.Migrator().HasColumn("pacients", "status"))
+
+	assert.NoError(t, db.Unscoped().Delete(&legacyPacient{}, 2).Error)
+	assert.NoError(t, db.Model(&legacyPacient{}).Where("id = ?", 3).Update("cpf", "390.533.447-05").Error)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+}
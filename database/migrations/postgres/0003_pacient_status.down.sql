@@ -0,0 +1,8 @@
+UPDATE "pacients"
+SET "deleted_at" = "inactivated_at"
+WHERE "status" = 'inactive';
+
+DROP INDEX IF EXISTS "idx_pacients_status";
+ALTER TABLE "pacients" DROP COLUMN "inactivation_reason";
+ALTER TABLE "pacients" DROP COLUMN "inactivated_at";
+ALTER TABLE "pacients" DROP COLUMN "status";
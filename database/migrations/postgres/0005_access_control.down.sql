@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "emergency_accesses";
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "audit_logs";
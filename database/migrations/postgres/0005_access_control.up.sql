@@ -0,0 +1,39 @@
+-- Trilha de auditoria, matriz de permissões por papel e acessos de emergência
+CREATE TABLE "audit_logs" (
+    "id" bigserial PRIMARY KEY,
+    "created_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "action" text NOT NULL,
+    "method" text NOT NULL,
+    "route" text NOT NULL,
+    "path" text NOT NULL,
+    "resource_type" text NOT NULL,
+    "resource_id" bigint,
+    "status" bigint,
+    "ip" text,
+    "user_agent" text,
+    "changes" text,
+    "emergency" boolean NOT NULL DEFAULT false
+);
+CREATE INDEX "idx_audit_logs_emergency" ON "audit_logs"("emergency");
+CREATE INDEX "idx_audit_resource" ON "audit_logs"("resource_type","resource_id");
+CREATE INDEX "idx_audit_logs_user_id" ON "audit_logs"("user_id");
+CREATE INDEX "idx_audit_logs_created_at" ON "audit_logs"("created_at");
+
+CREATE TABLE "role_permissions" (
+    "id" bigserial PRIMARY KEY,
+    "role" text NOT NULL,
+    "permission" text NOT NULL
+);
+CREATE UNIQUE INDEX "idx_role_permission" ON "role_permissions"("role","permission");
+
+CREATE TABLE "emergency_accesses" (
+    "id" bigserial PRIMARY KEY,
+    "created_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "pacient_id" bigint NOT NULL,
+    "justification" text NOT NULL,
+    "expires_at" timestamptz NOT NULL
+);
+CREATE INDEX "idx_emergency_access" ON "emergency_accesses"("user_id","pacient_id");
+CREATE INDEX "idx_emergency_accesses_created_at" ON "emergency_accesses"("created_at");
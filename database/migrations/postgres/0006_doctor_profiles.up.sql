@@ -0,0 +1,28 @@
+-- Perfis de médico com CRM e o catálogo de especialidades
+CREATE TABLE "specialties" (
+    "id" bigserial PRIMARY KEY,
+    "created_at" timestamptz,
+    "name" text NOT NULL
+);
+CREATE UNIQUE INDEX "idx_specialties_name" ON "specialties"("name");
+
+CREATE TABLE "doctor_profiles" (
+    "id" bigserial PRIMARY KEY,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "crm" text NOT NULL,
+    "crm_uf" text NOT NULL,
+    "consultation_minutes" bigint NOT NULL DEFAULT 30,
+    CONSTRAINT "fk_users_doctor_profile" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "idx_doctor_crm" ON "doctor_profiles"("crm","crm_uf");
+CREATE UNIQUE INDEX "idx_doctor_profiles_user_id" ON "doctor_profiles"("user_id");
+
+CREATE TABLE "doctor_specialties" (
+    "doctor_profile_id" bigint,
+    "specialty_id" bigint,
+    PRIMARY KEY ("doctor_profile_id","specialty_id"),
+    CONSTRAINT "fk_doctor_specialties_specialty" FOREIGN KEY ("specialty_id") REFERENCES "specialties"("id"),
+    CONSTRAINT "fk_doctor_specialties_doctor_profile" FOREIGN KEY ("doctor_profile_id") REFERENCES "doctor_profiles"("id")
+);
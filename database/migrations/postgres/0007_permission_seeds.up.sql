@@ -0,0 +1,9 @@
+-- Concessões da matriz padrão já gravadas, para que uma permissão revogada
+-- pelo administrador não seja concedida de novo na próxima inicialização
+CREATE TABLE "permission_seeds" (
+    "id" bigserial PRIMARY KEY,
+    "created_at" timestamptz,
+    "role" text NOT NULL,
+    "permission" text NOT NULL
+);
+CREATE UNIQUE INDEX "idx_permission_seed" ON "permission_seeds"("role","permission");
@@ -0,0 +1,12 @@
+-- A trilha de auditoria só recebe inserções, também para quem escreve direto
+-- no banco sem passar pelos hooks do modelo; TRUNCATE não dispara os
+-- gatilhos de linha e é barrado à parte
+CREATE FUNCTION "audit_logs_append_only"() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "audit_logs_no_update_delete" BEFORE UPDATE OR DELETE ON "audit_logs"
+    FOR EACH ROW EXECUTE FUNCTION "audit_logs_append_only"();
+CREATE TRIGGER "audit_logs_no_truncate" BEFORE TRUNCATE ON "audit_logs"
+    FOR EACH STATEMENT EXECUTE FUNCTION "audit_logs_append_only"();
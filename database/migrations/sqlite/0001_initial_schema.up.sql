@@ -0,0 +1,20 @@
+-- Esquema de antes das migrações: só a tabela de pacientes, como o AutoMigrate
+-- a criava. Usa IF NOT EXISTS para que esses bancos sejam adotados sem perder
+-- dados; tudo o que veio depois entra nas migrações seguintes.
+CREATE TABLE IF NOT EXISTS `pacients` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text NOT NULL,
+    `birth_date` date NOT NULL,
+    `cpf` text NOT NULL,
+    `sex` text NOT NULL,
+    `phone_number` text NOT NULL,
+    `address` text NOT NULL,
+    `email` text,
+    `blood_type` text,
+    `allergies` text,
+    CONSTRAINT `uni_pacients_cpf` UNIQUE (`cpf`)
+);
+CREATE INDEX IF NOT EXISTS `idx_pacients_deleted_at` ON `pacients`(`deleted_at`);
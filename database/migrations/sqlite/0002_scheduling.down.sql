@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `availability_blocks`;
+DROP TABLE IF EXISTS `shifts`;
+DROP TABLE IF EXISTS `appointment_events`;
+DROP TABLE IF EXISTS `appointments`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,78 @@
+-- Usuários, agenda dos médicos e consultas, com o histórico de cada uma
+CREATE TABLE `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text NOT NULL,
+    `cpf` text NOT NULL,
+    `password` text NOT NULL,
+    `role` text NOT NULL,
+    `deactivated_at` datetime,
+    `must_change_password` numeric NOT NULL DEFAULT false,
+    `totp_secret` text,
+    `totp_enabled_at` datetime,
+    `totp_last_step` integer NOT NULL DEFAULT 0,
+    CONSTRAINT `uni_users_cpf` UNIQUE (`cpf`)
+);
+CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE `appointments` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `pacient_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `date` datetime NOT NULL,
+    `end_date` datetime NOT NULL,
+    `status` text NOT NULL DEFAULT 'scheduled',
+    `cancel_reason` text,
+    CONSTRAINT `fk_users_appointments` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_pacients_appointments` FOREIGN KEY (`pacient_id`) REFERENCES `pacients`(`id`) ON DELETE CASCADE
+);
+CREATE INDEX `idx_appointments_status` ON `appointments`(`status`);
+CREATE INDEX `idx_appointments_user_id` ON `appointments`(`user_id`);
+CREATE INDEX `idx_appointments_pacient_id` ON `appointments`(`pacient_id`);
+CREATE INDEX `idx_appointments_deleted_at` ON `appointments`(`deleted_at`);
+
+CREATE TABLE `appointment_events` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `appointment_id` integer NOT NULL,
+    `actor_id` integer,
+    `from_status` text,
+    `to_status` text NOT NULL,
+    `from_date` datetime,
+    `to_date` datetime,
+    `reason` text,
+    CONSTRAINT `fk_appointments_history` FOREIGN KEY (`appointment_id`) REFERENCES `appointments`(`id`) ON DELETE CASCADE
+);
+CREATE INDEX `idx_appointment_events_appointment_id` ON `appointment_events`(`appointment_id`);
+
+CREATE TABLE `shifts` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer NOT NULL,
+    `weekday` integer NOT NULL,
+    `start_time` text NOT NULL,
+    `end_time` text NOT NULL
+);
+CREATE INDEX `idx_shifts_user_id` ON `shifts`(`user_id`);
+CREATE INDEX `idx_shifts_deleted_at` ON `shifts`(`deleted_at`);
+
+CREATE TABLE `availability_blocks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer NOT NULL,
+    `starts_at` datetime NOT NULL,
+    `ends_at` datetime NOT NULL,
+    `reason` text NOT NULL,
+    `notes` text
+);
+CREATE INDEX `idx_availability_blocks_user_id` ON `availability_blocks`(`user_id`);
+CREATE INDEX `idx_availability_blocks_deleted_at` ON `availability_blocks`(`deleted_at`);
@@ -0,0 +1,16 @@
+-- Pacientes passam a ser inativados em vez de removidos. Os que o esquema
+-- antigo tinha removido (soft delete) viram inativos, e o CPF passa a ser
+-- guardado só com os dígitos. Antes deste script o Migrator confere (em Go)
+-- que nenhum CPF é inválido ou ficaria repetido ao tirar a máscara.
+ALTER TABLE `pacients` ADD COLUMN `status` text NOT NULL DEFAULT 'active';
+ALTER TABLE `pacients` ADD COLUMN `inactivated_at` datetime;
+ALTER TABLE `pacients` ADD COLUMN `inactivation_reason` text;
+CREATE INDEX `idx_pacients_status` ON `pacients`(`status`);
+
+UPDATE `pacients`
+SET `status` = 'inactive', `inactivated_at` = `deleted_at`, `deleted_at` = NULL
+WHERE `deleted_at` IS NOT NULL;
+
+UPDATE `pacients`
+SET `cpf` = replace(replace(replace(`cpf`, '.', ''), '-', ''), ' ', '')
+WHERE `cpf` <> replace(replace(replace(`cpf`, '.', ''), '-', ''), ' ', '');
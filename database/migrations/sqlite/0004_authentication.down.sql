@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `login_challenges`;
+DROP TABLE IF EXISTS `recovery_codes`;
+DROP TABLE IF EXISTS `password_reset_tokens`;
+DROP TABLE IF EXISTS `password_histories`;
+DROP TABLE IF EXISTS `login_attempts`;
+DROP TABLE IF EXISTS `sessions`;
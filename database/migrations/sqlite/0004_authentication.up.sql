@@ -0,0 +1,75 @@
+-- Sessões com refresh token, bloqueio de login, política de senha e
+-- segundo fator
+CREATE TABLE `sessions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer NOT NULL,
+    `refresh_token_hash` text NOT NULL,
+    `previous_token_hash` text,
+    `user_agent` text,
+    `ip` text,
+    `expires_at` datetime NOT NULL,
+    `last_used_at` datetime,
+    `revoked_at` datetime
+);
+CREATE INDEX `idx_sessions_previous_token_hash` ON `sessions`(`previous_token_hash`);
+CREATE UNIQUE INDEX `idx_sessions_refresh_token_hash` ON `sessions`(`refresh_token_hash`);
+CREATE INDEX `idx_sessions_user_id` ON `sessions`(`user_id`);
+CREATE INDEX `idx_sessions_deleted_at` ON `sessions`(`deleted_at`);
+
+CREATE TABLE `login_attempts` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `kind` text NOT NULL,
+    `identifier` text NOT NULL,
+    `failures` integer NOT NULL,
+    `last_failure_at` datetime NOT NULL,
+    `locked_until` datetime
+);
+CREATE UNIQUE INDEX `idx_login_attempt_key` ON `login_attempts`(`kind`,`identifier`);
+
+CREATE TABLE `password_histories` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `user_id` integer NOT NULL,
+    `hash` text NOT NULL
+);
+CREATE INDEX `idx_password_histories_user_id` ON `password_histories`(`user_id`);
+
+CREATE TABLE `password_reset_tokens` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `user_id` integer NOT NULL,
+    `issued_by_id` integer NOT NULL,
+    `token_hash` text NOT NULL,
+    `expires_at` datetime NOT NULL,
+    `used_at` datetime
+);
+CREATE UNIQUE INDEX `idx_password_reset_tokens_token_hash` ON `password_reset_tokens`(`token_hash`);
+CREATE INDEX `idx_password_reset_tokens_user_id` ON `password_reset_tokens`(`user_id`);
+
+CREATE TABLE `recovery_codes` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `user_id` integer NOT NULL,
+    `code_hash` text NOT NULL,
+    `used_at` datetime
+);
+CREATE UNIQUE INDEX `idx_recovery_codes_code_hash` ON `recovery_codes`(`code_hash`);
+CREATE INDEX `idx_recovery_codes_user_id` ON `recovery_codes`(`user_id`);
+
+CREATE TABLE `login_challenges` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `user_id` integer NOT NULL,
+    `token_hash` text NOT NULL,
+    `user_agent` text,
+    `ip` text,
+    `expires_at` datetime NOT NULL,
+    `attempts` integer NOT NULL DEFAULT 0,
+    `used_at` datetime
+);
+CREATE UNIQUE INDEX `idx_login_challenges_token_hash` ON `login_challenges`(`token_hash`);
+CREATE INDEX `idx_login_challenges_user_id` ON `login_challenges`(`user_id`);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `doctor_specialties`;
+DROP TABLE IF EXISTS `doctor_profiles`;
+DROP TABLE IF EXISTS `specialties`;
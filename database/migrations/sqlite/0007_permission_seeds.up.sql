@@ -0,0 +1,9 @@
+-- Concessões da matriz padrão já gravadas, para que uma permissão revogada
+-- pelo administrador não seja concedida de novo na próxima inicialização
+CREATE TABLE `permission_seeds` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `role` text NOT NULL,
+    `permission` text NOT NULL
+);
+CREATE UNIQUE INDEX `idx_permission_seed` ON `permission_seeds`(`role`,`permission`);
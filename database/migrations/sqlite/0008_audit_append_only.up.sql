@@ -0,0 +1,10 @@
+-- A trilha de auditoria só recebe inserções, também para quem escreve direto
+-- no banco sem passar pelos hooks do modelo
+CREATE TRIGGER `audit_logs_no_update` BEFORE UPDATE ON `audit_logs`
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+CREATE TRIGGER `audit_logs_no_delete` BEFORE DELETE ON `audit_logs`
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"gorm.io/gorm"
+)
+
+// preflights conferem os dados antes do script de uma migração, pelo nome
+// dela, quando o SQL sozinho não consegue explicar o que está errado
+var preflights = map[string]func(tx *gorm.DB) error{
+	"pacient_status": checkLegacyCPFs,
+}
+
+// LegacyCPF é um paciente do esquema antigo com o CPF como foi gravado
+type LegacyCPF struct {
+	ID  uint
+	CPF string
+}
+
+// LegacyCPFError lista os pacientes que impedem a normalização dos CPFs:
+// os que ficariam com o mesmo CPF e os com dígitos verificadores inválidos.
+// Eles precisam ser corrigidos ou unificados à mão antes de migrar.
+type LegacyCPFError struct {
+	Duplicates map[string][]LegacyCPF
+	Invalid    []LegacyCPF
+}
+
+func (e *LegacyCPFError) Error() string {
+	var b strings.Builder
+	b.WriteString("pacients have CPFs that cannot be normalized; fix or merge them and run the migration again:")
+
+	digits := make([]string, 0, len(e.Duplicates))
+	for d := range e.Duplicates {
+		digits = append(digits, d)
+	}
+	sort.Strings(digits)
+	for _, d := range digits {
+		rows := make([]string, len(e.Duplicates[d]))
+		for i, row := range e.Duplicates[d] {
+			rows[i] = fmt.Sprintf("id %d (%q)", row.ID, row.CPF)
+		}
+		fmt.Fprintf(&b, "\n  - duplicate CPF %s: %s", d, strings.Join(rows, ", "))
+	}
+	for _, row := range e.Invalid {
+		fmt.Fprintf(&b, "\n  - invalid CPF %q: id %d", row.CPF, row.ID)
+	}
+	return b.String()
+}
+
+// checkLegacyCPFs roda antes de os CPFs serem reduzidos aos dígitos. Inclui
+// os pacientes removidos pelo soft delete, que também ficam na restrição unique.
+func checkLegacyCPFs(tx *gorm.DB) error {
+	var rows []LegacyCPF
+	if err := tx.Raw("SELECT id, cpf FROM pacients ORDER BY id").Scan(&rows).Error; err != nil {
+		return fmt.Errorf("unable to read pacient CPFs: %w", err)
+	}
+
+	report := &LegacyCPFError{Duplicates: map[string][]LegacyCPF{}}
+	byDigits := map[string][]LegacyCPF{}
+	for _, row := range rows {
+		if !cpf.IsValid(row.CPF) {
+			report.Invalid = append(report.Invalid, row)
+			continue
+		}
+		digits := cpf.CPF(row.CPF).Digits()
+		byDigits[digits] = append(byDigits[digits], row)
+	}
+	for digits, group := range byDigits {
+		if len(group) > 1 {
+			report.Duplicates[digits] = group
+		}
+	}
+
+	if len(report.Duplicates) > 0 || len(report.Invalid) > 0 {
+		return report
+	}
+	return nil
+}
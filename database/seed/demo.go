@@ -0,0 +1,434 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"gorm.io/gorm"
+)
+
+// DemoOptions controla o tamanho dos dados de demonstração. A mesma Seed gera
+// sempre os mesmos médicos, pacientes e consultas; From é o primeiro dia da
+// agenda (veja DefaultFrom), e o fuso dele é o dos turnos dos médicos.
+type DemoOptions struct {
+	Seed     int64
+	Pacients int
+	Weeks    int
+	From     time.Time
+	Password string
+}
+
+// DemoResult conta o que foi criado nesta execução
+type DemoResult struct {
+	Specialties  int
+	Doctors      int
+	Staff        int
+	Pacients     int
+	Appointments int
+}
+
+var demoSpecialties = []string{
+	"Cardiologia", "Clínica Geral", "Dermatologia", "Ginecologia",
+	"Neurologia", "Ortopedia", "Pediatria", "Psiquiatria",
+}
+
+var demoDoctors = []string{
+	"Dra. Ana Beatriz Monteiro", "Dr. Carlos Eduardo Pinheiro", "Dra. Fernanda Lobato",
+	"Dr. Gustavo Tavares", "Dra. Juliana Barros", "Dr. Marcelo Quaresma",
+	"Dra. Patrícia Nogueira", "Dr. Rafael Cardoso", "Dra. Sílvia Amoras", "Dr. Thiago Brito",
+}
+
+// demoStaff são os papéis de apoio, um usuário de cada, para que a
+// demonstração cubra as permissões de todos eles
+var demoStaff = []struct {
+	name string
+	role enums.Role
+}{
+	{"Enf. Roberto Siqueira", enums.Nurse},
+	{"Farm. Larissa Góes", enums.Pharmacist},
+	{"Marcos Vieira", enums.LabTechnician},
+}
+
+var (
+	maleNames   = []string{"João", "Pedro", "Lucas", "Mateus", "Gabriel", "Rafael", "Felipe", "Bruno", "Diego", "André", "Marcos", "Paulo", "Raimundo", "José", "Antônio", "Francisco"}
+	femaleNames = []string{"Maria", "Ana", "Juliana", "Camila", "Beatriz", "Larissa", "Fernanda", "Letícia", "Amanda", "Bruna", "Raimunda", "Francisca", "Luana", "Patrícia", "Sandra", "Helena"}
+	surnames    = []string{"Silva", "Santos", "Oliveira", "Souza", "Lima", "Pereira", "Costa", "Ferreira", "Rodrigues", "Almeida", "Nascimento", "Carvalho", "Araújo", "Ribeiro", "Barbosa", "Cardoso", "Moraes", "Pinheiro", "Teixeira", "Monteiro"}
+	streets     = []string{"Av. Nazaré", "Av. Almirante Barroso", "Tv. Quintino Bocaiúva", "Rua dos Mundurucus", "Av. Governador José Malcher", "Tv. Padre Eutíquio", "Rua Boaventura da Silva", "Av. Pedro Miranda"}
+	districts   = []string{"Nazaré", "Umarizal", "Batista Campos", "Marco", "Pedreira", "Cremação", "Jurunas", "Guamá"}
+	allergies   = []string{"Dipirona", "Penicilina", "Frutos do mar", "Lactose", "Látex", "Ibuprofeno"}
+	bloodTypes  = []enums.BloodType{
+		enums.APositive, enums.ANegative, enums.BPositive, enums.BNegative,
+		enums.ABPositive, enums.ABNegative, enums.OPositive, enums.ONegative,
+	}
+)
+
+// Turnos dos médicos de demonstração: 8h–12h e 13h–17h, de segunda a sexta,
+// em consultas de 30 minutos
+const (
+	demoSlotMinutes = 30
+	demoSlotsPerDay = 16
+)
+
+type demoDoctor struct {
+	user        models.User
+	crm         string
+	specialties []string
+}
+
+type demoAppointment struct {
+	doctor int
+	date   time.Time
+	status enums.AppointmentStatus
+}
+
+type demoPacient struct {
+	pacient      models.Pacient
+	appointments []demoAppointment
+}
+
+// Demo cria especialidades, médicos com perfil e turnos, um usuário de
+// enfermagem, farmácia e laboratório, pacientes e consultas espalhadas pelas semanas de From. Registros que já existem (pelo
+// CPF ou pelo nome, no caso das especialidades) são mantidos, e as consultas
+// só são criadas junto com pacientes novos, então rodar de novo não duplica.
+func Demo(db *gorm.DB, policy passwords.Policy, options DemoOptions) (*DemoResult, error) {
+	if options.Pacients < 0 || options.Weeks <= 0 {
+		return nil, errors.New("pacients must not be negative and weeks must be positive")
+	}
+	if options.From.IsZero() {
+		return nil, errors.New("the first day of the demo schedule is required")
+	}
+
+	hashed, err := policy.Hash(options.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(options.Seed))
+	doctors := planDoctors(rng)
+	pacients := planPacients(rng, options, len(doctors))
+	staff := planStaff(rng)
+	now := time.Now()
+
+	var result DemoResult
+	err = db.Transaction(func(tx *gorm.DB) error {
+		specialties, err := ensureSpecialties(tx, &result)
+		if err != nil {
+			return err
+		}
+
+		doctorIDs := make([]uint, len(doctors))
+		for i, doctor := range doctors {
+			if doctorIDs[i], err = ensureDoctor(tx, policy, doctor, specialties, hashed, &result); err != nil {
+				return err
+			}
+		}
+
+		for _, user := range staff {
+			_, created, err := ensureUser(tx, policy, user, hashed)
+			if err != nil {
+				return err
+			}
+			if created {
+				result.Staff++
+			}
+		}
+
+		for _, planned := range pacients {
+			created, err := ensurePacient(tx, &planned.pacient)
+			if err != nil {
+				return err
+			}
+			if !created {
+				continue
+			}
+			result.Pacients++
+
+			for _, a := range planned.appointments {
+				if err := createAppointment(tx, planned.pacient.ID, doctorIDs[a.doctor], a, now); err != nil {
+					return err
+				}
+				result.Appointments++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DefaultFrom é a segunda-feira de metade das semanas atrás, para que a agenda
+// tenha tanto histórico quanto consultas futuras. O dia é contado no fuso de
+// now, que deve ser o da clínica.
+func DefaultFrom(now time.Time, weeks int) time.Time {
+	monday := now.AddDate(0, 0, -((int(now.Weekday())+6)%7)-7*(weeks/2))
+	return time.Date(monday.Year(), monday.Month(), monday.Day(), 0, 0, 0, 0, now.Location())
+}
+
+func planDoctors(rng *rand.Rand) []demoDoctor {
+	doctors := make([]demoDoctor, len(demoDoctors))
+	crms := map[string]bool{}
+
+	for i, name := range demoDoctors {
+		crm := fmt.Sprint(10000 + rng.Intn(90000))
+		for crms[crm] {
+			crm = fmt.Sprint(10000 + rng.Intn(90000))
+		}
+		crms[crm] = true
+
+		specialties := []string{demoSpecialties[i%len(demoSpecialties)]}
+		if extra := demoSpecialties[rng.Intn(len(demoSpecialties))]; extra != specialties[0] {
+			specialties = append(specialties, extra)
+		}
+
+		doctors[i] = demoDoctor{
+			user: models.User{
+				Name: name,
+				CPF:  cpf.Generate(rng),
+				Role: enums.Doctor,
+			},
+			crm:         crm,
+			specialties: specialties,
+		}
+	}
+	return doctors
+}
+
+// planStaff vem depois dos pacientes no sorteio para não mudar os dados que
+// uma mesma Seed já gerava
+func planStaff(rng *rand.Rand) []models.User {
+	staff := make([]models.User, len(demoStaff))
+	for i, member := range demoStaff {
+		staff[i] = models.User{Name: member.name, CPF: cpf.Generate(rng), Role: member.role}
+	}
+	return staff
+}
+
+func planPacients(rng *rand.Rand, options DemoOptions, doctors int) []demoPacient {
+	pacients := make([]demoPacient, options.Pacients)
+	busy := map[string]bool{}
+
+	for i := range pacients {
+		sex, first := enums.Male, maleNames[rng.Intn(len(maleNames))]
+		if rng.Intn(2) == 0 {
+			sex, first = enums.Female, femaleNames[rng.Intn(len(femaleNames))]
+		}
+		last := surnames[rng.Intn(len(surnames))]
+		name := fmt.Sprintf("%s %s %s", first, surnames[rng.Intn(len(surnames))], last)
+
+		pacient := models.Pacient{
+			Name:        name,
+			BirthDate:   time.Date(1940+rng.Intn(83), time.Month(1+rng.Intn(12)), 1+rng.Intn(28), 0, 0, 0, 0, time.UTC),
+			CPF:         cpf.Generate(rng),
+			Sex:         sex,
+			PhoneNumber: fmt.Sprintf("(91) 9%04d-%04d", rng.Intn(10000), rng.Intn(10000)),
+			Address:     fmt.Sprintf("%s, %d - %s, Belém - PA", streets[rng.Intn(len(streets))], 1+rng.Intn(3000), districts[rng.Intn(len(districts))]),
+			Status:      enums.PacientActive,
+		}
+		if rng.Intn(10) < 6 {
+			email := fmt.Sprintf("%s.%s%d@example.com", asciiLower(first), asciiLower(last), rng.Intn(100))
+			pacient.Email = &email
+		}
+		if rng.Intn(10) < 8 {
+			bloodType := bloodTypes[rng.Intn(len(bloodTypes))]
+			pacient.BloodType = &bloodType
+		}
+		if rng.Intn(10) < 2 {
+			allergy := allergies[rng.Intn(len(allergies))]
+			pacient.Allergies = &allergy
+		}
+
+		planned := demoPacient{pacient: pacient}
+		for range rng.Intn(4) {
+			doctor := rng.Intn(doctors)
+			day := rng.Intn(options.Weeks * 7)
+			slot := rng.Intn(demoSlotsPerDay)
+			roll := rng.Intn(10)
+
+			date := slotTime(options.From, day, slot)
+			if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+				continue
+			}
+			doctorKey := fmt.Sprintf("d%d@%d", doctor, date.Unix())
+			pacientKey := fmt.Sprintf("p%d@%d", i, date.Unix())
+			if busy[doctorKey] || busy[pacientKey] {
+				continue
+			}
+			busy[doctorKey], busy[pacientKey] = true, true
+
+			planned.appointments = append(planned.appointments, demoAppointment{
+				doctor: doctor,
+				date:   date,
+				status: demoStatus(roll),
+			})
+		}
+		pacients[i] = planned
+	}
+	return pacients
+}
+
+func slotTime(from time.Time, day, slot int) time.Time {
+	hour := 8
+	if slot >= demoSlotsPerDay/2 {
+		hour, slot = 13, slot-demoSlotsPerDay/2
+	}
+	return time.Date(from.Year(), from.Month(), from.Day()+day, hour, slot*demoSlotMinutes, 0, 0, from.Location())
+}
+
+// demoStatus sorteia o desfecho de uma consulta passada; consultas futuras
+// ficam agendadas, confirmadas ou canceladas (ver createAppointment)
+func demoStatus(roll int) enums.AppointmentStatus {
+	switch {
+	case roll < 7:
+		return enums.Completed
+	case roll < 8:
+		return enums.NoShow
+	default:
+		return enums.Cancelled
+	}
+}
+
+func ensureSpecialties(tx *gorm.DB, result *DemoResult) (map[string]models.Specialty, error) {
+	specialties := map[string]models.Specialty{}
+	for _, name := range demoSpecialties {
+		var specialty models.Specialty
+		found := tx.Where("LOWER(name) = LOWER(?)", name).Limit(1).Find(&specialty)
+		err := found.Error
+		if err == nil && found.RowsAffected == 0 {
+			specialty = models.Specialty{Name: name}
+			err = tx.Create(&specialty).Error
+			result.Specialties++
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to seed specialty %s: %v", name, err)
+		}
+		specialties[name] = specialty
+	}
+	return specialties, nil
+}
+
+// ensureUser cria o usuário com a senha de demonstração se o CPF ainda não
+// existe, e devolve o ID dele nos dois casos
+func ensureUser(tx *gorm.DB, policy passwords.Policy, planned models.User, hashed string) (uint, bool, error) {
+	var existing models.User
+	found := tx.Unscoped().Select("id").Where("cpf = ?", planned.CPF).Limit(1).Find(&existing)
+	if found.Error != nil {
+		return 0, false, fmt.Errorf("unable to check cpf: %v", found.Error)
+	}
+	if found.RowsAffected > 0 {
+		return existing.ID, false, nil
+	}
+
+	user := planned
+	user.Password = hashed
+	if err := tx.Create(&user).Error; err != nil {
+		return 0, false, fmt.Errorf("unable to seed user %s: %v", user.Name, err)
+	}
+	if err := policy.Remember(tx, user.ID, hashed); err != nil {
+		return 0, false, err
+	}
+	return user.ID, true, nil
+}
+
+func ensureDoctor(tx *gorm.DB, policy passwords.Policy, doctor demoDoctor, specialties map[string]models.Specialty, hashed string, result *DemoResult) (uint, error) {
+	userID, created, err := ensureUser(tx, policy, doctor.user, hashed)
+	if err != nil || !created {
+		return userID, err
+	}
+
+	profile := models.DoctorProfile{
+		UserID:              userID,
+		CRM:                 doctor.crm,
+		CRMUF:               "PA",
+		ConsultationMinutes: demoSlotMinutes,
+	}
+	for _, name := range doctor.specialties {
+		profile.Specialties = append(profile.Specialties, specialties[name])
+	}
+	if err := tx.Create(&profile).Error; err != nil {
+		return 0, fmt.Errorf("unable to seed profile of %s: %v", doctor.user.Name, err)
+	}
+
+	for weekday := time.Monday; weekday <= time.Friday; weekday++ {
+		shifts := []models.Shift{
+			{UserID: userID, Weekday: weekday, StartTime: "08:00", EndTime: "12:00"},
+			{UserID: userID, Weekday: weekday, StartTime: "13:00", EndTime: "17:00"},
+		}
+		if err := tx.Create(&shifts).Error; err != nil {
+			return 0, fmt.Errorf("unable to seed shifts of %s: %v", doctor.user.Name, err)
+		}
+	}
+
+	result.Doctors++
+	return userID, nil
+}
+
+func ensurePacient(tx *gorm.DB, pacient *models.Pacient) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.Pacient{}).Unscoped().Where("cpf = ?", pacient.CPF).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("unable to check cpf: %v", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if err := tx.Create(pacient).Error; err != nil {
+		return false, fmt.Errorf("unable to seed pacient %s: %v", pacient.Name, err)
+	}
+	return true, nil
+}
+
+func createAppointment(tx *gorm.DB, pacientID, doctorID uint, planned demoAppointment, now time.Time) error {
+	status := planned.status
+	if planned.date.After(now) {
+		// o mesmo sorteio, lido como desfecho de uma consulta futura
+		switch status {
+		case enums.Completed:
+			status = enums.Scheduled
+		case enums.NoShow:
+			status = enums.Confirmed
+		}
+	}
+
+	appointment := models.Appointment{
+		PacientID: pacientID,
+		UserID:    doctorID,
+		Date:      planned.date.UTC(),
+		EndDate:   planned.date.Add(demoSlotMinutes * time.Minute).UTC(),
+		Status:    status,
+	}
+	if status == enums.Cancelled {
+		reason := "Paciente desmarcou"
+		appointment.CancelReason = &reason
+	}
+	if err := tx.Create(&appointment).Error; err != nil {
+		return fmt.Errorf("unable to seed appointment: %v", err)
+	}
+
+	if status == enums.Scheduled {
+		return nil
+	}
+	event := models.AppointmentEvent{
+		AppointmentID: appointment.ID,
+		FromStatus:    enums.Scheduled,
+		ToStatus:      status,
+		Reason:        appointment.CancelReason,
+	}
+	return tx.Create(&event).Error
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c",
+)
+
+func asciiLower(value string) string {
+	return accents.Replace(strings.ToLower(value))
+}
@@ -0,0 +1,69 @@
+// Package seed popula um banco recém-migrado: o primeiro admin e, se pedido,
+// um conjunto de dados de demonstração. Rodar de novo não duplica registros.
+package seed
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"gorm.io/gorm"
+)
+
+var ErrCPFNotAdmin = errors.New("a non-admin user already has this cpf")
+
+type AdminInput struct {
+	Name     string
+	CPF      string
+	Password string
+}
+
+// Admin cria o admin informado, obrigado a trocar a senha no primeiro login.
+// Se o CPF já for de um admin, devolve o existente sem mexer na senha.
+func Admin(db *gorm.DB, policy passwords.Policy, input AdminInput) (*models.User, bool, error) {
+	document, err := cpf.Parse(input.CPF)
+	if err != nil {
+		return nil, false, err
+	}
+	if input.Name == "" {
+		return nil, false, errors.New("admin name is required")
+	}
+
+	var existing models.User
+	found := db.Where("cpf = ?", document).Limit(1).Find(&existing)
+	if found.Error != nil {
+		return nil, false, fmt.Errorf("unable to check cpf: %v", found.Error)
+	}
+	if found.RowsAffected > 0 {
+		if existing.Role != enums.Admin {
+			return nil, false, ErrCPFNotAdmin
+		}
+		return &existing, false, nil
+	}
+
+	hashed, err := policy.Hash(input.Password)
+	if err != nil {
+		return nil, false, err
+	}
+
+	admin := models.User{
+		Name:               input.Name,
+		CPF:                document,
+		Password:           hashed,
+		Role:               enums.Admin,
+		MustChangePassword: true,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&admin).Error; err != nil {
+			return err
+		}
+		return policy.Remember(tx, admin.ID, hashed)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &admin, true, nil
+}
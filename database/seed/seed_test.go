@@ -0,0 +1,171 @@
+package seed
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var (
+	testPolicy   = passwords.NewPolicy(config.Default().Password)
+	testLocation = config.Default().Location
+)
+
+// setupTestDB usa o esquema das migrações, como o banco de verdade
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+	migrator, err := database.NewMigrator(db)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+	return db
+}
+
+func TestAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	input := AdminInput{Name: "Alice", CPF: "529.982.247-25", Password: "Admin-secret1"}
+
+	admin, created, err := Admin(db, testPolicy, input)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, enums.Admin, admin.Role)
+	assert.Equal(t, cpf.CPF("52998224725"), admin.CPF)
+	assert.True(t, admin.MustChangePassword)
+	assert.NoError(t, utils.CheckPassword(admin.Password, "Admin-secret1"))
+
+	// rodar de novo devolve o mesmo admin e não troca a senha
+	again, created, err := Admin(db, testPolicy, AdminInput{Name: "Alice", CPF: "52998224725", Password: "Other-secret2"})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, admin.ID, again.ID)
+	assert.NoError(t, utils.CheckPassword(again.Password, "Admin-secret1"))
+
+	doctor := models.User{Name: "Bob", CPF: "11144477735", Password: "x", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+
+	tests := []struct {
+		name  string
+		input AdminInput
+		want  error
+	}{
+		{name: "invalid cpf", input: AdminInput{Name: "Carol", CPF: "123", Password: "Admin-secret1"}, want: cpf.ErrInvalid},
+		{name: "cpf of another role", input: AdminInput{Name: "Bob", CPF: "11144477735", Password: "Admin-secret1"}, want: ErrCPFNotAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Admin(db, testPolicy, tt.input)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	t.Run("weak password", func(t *testing.T) {
+		_, _, err := Admin(db, testPolicy, AdminInput{Name: "Carol", CPF: "39053344705", Password: "short"})
+		var policy *passwords.PolicyError
+		assert.ErrorAs(t, err, &policy)
+	})
+}
+
+func TestDemo(t *testing.T) {
+	db := setupTestDB(t)
+	from := time.Date(2030, 3, 4, 0, 0, 0, 0, testLocation)
+	options := DemoOptions{Seed: 7, Pacients: 120, Weeks: 4, From: from, Password: "Demo-secret1"}
+
+	result, err := Demo(db, testPolicy, options)
+	assert.NoError(t, err)
+	assert.Equal(t, len(demoSpecialties), result.Specialties)
+	assert.Equal(t, len(demoDoctors), result.Doctors)
+	assert.Equal(t, len(demoStaff), result.Staff)
+	assert.Equal(t, 120, result.Pacients)
+	assert.Greater(t, result.Appointments, 100)
+
+	var pacients []models.Pacient
+	assert.NoError(t, db.Order("id").Find(&pacients).Error)
+	assert.Len(t, pacients, 120)
+	for _, p := range pacients {
+		assert.True(t, cpf.IsValid(string(p.CPF)), p.CPF)
+	}
+
+	var doctors []models.User
+	assert.NoError(t, db.Preload("DoctorProfile.Specialties").Where("role = ?", enums.Doctor).Find(&doctors).Error)
+	assert.Len(t, doctors, len(demoDoctors))
+	for _, d := range doctors {
+		if assert.NotNil(t, d.DoctorProfile, d.Name) {
+			assert.NotEmpty(t, d.DoctorProfile.Specialties, d.Name)
+		}
+	}
+
+	// um usuário de cada papel de apoio, que entra com a senha de demonstração
+	for _, role := range []enums.Role{enums.Nurse, enums.Pharmacist, enums.LabTechnician} {
+		var staff []models.User
+		assert.NoError(t, db.Where("role = ?", role).Find(&staff).Error)
+		if assert.Len(t, staff, 1, role) {
+			assert.True(t, cpf.IsValid(string(staff[0].CPF)), staff[0].CPF)
+			assert.NoError(t, utils.CheckPassword(staff[0].Password, options.Password), role)
+		}
+	}
+
+	// consultas em dias úteis, dentro dos turnos e sem choque de horário
+	var appointments []models.Appointment
+	assert.NoError(t, db.Find(&appointments).Error)
+	assert.Len(t, appointments, result.Appointments)
+	booked := map[string]bool{}
+	for _, a := range appointments {
+		local := a.Date.In(testLocation)
+		assert.NotEqual(t, time.Saturday, local.Weekday())
+		assert.NotEqual(t, time.Sunday, local.Weekday())
+		assert.True(t, local.Hour() >= 8 && local.Hour() < 17 && local.Hour() != 12, local)
+		assert.False(t, local.Before(from) || !local.Before(from.AddDate(0, 0, 28)), local)
+
+		key := fmt.Sprintf("%d@%s", a.UserID, local)
+		assert.False(t, booked[key], "doctor %d double-booked at %s", a.UserID, local)
+		booked[key] = true
+	}
+
+	t.Run("rerun creates nothing", func(t *testing.T) {
+		again, err := Demo(db, testPolicy, options)
+		assert.NoError(t, err)
+		assert.Equal(t, DemoResult{}, *again)
+	})
+
+	t.Run("same seed, same data", func(t *testing.T) {
+		other := setupTestDB(t)
+		_, err := Demo(other, testPolicy, options)
+		assert.NoError(t, err)
+
+		var staff, otherStaff []models.User
+		assert.NoError(t, db.Where("role IN ?", []enums.Role{enums.Nurse, enums.Pharmacist, enums.LabTechnician}).Order("role").Find(&staff).Error)
+		assert.NoError(t, other.Where("role IN ?", []enums.Role{enums.Nurse, enums.Pharmacist, enums.LabTechnician}).Order("role").Find(&otherStaff).Error)
+		if assert.Len(t, otherStaff, len(staff)) {
+			for i := range staff {
+				assert.Equal(t, staff[i].CPF, otherStaff[i].CPF)
+			}
+		}
+
+		var otherPacients []models.Pacient
+		assert.NoError(t, other.Order("id").Find(&otherPacients).Error)
+		assert.Len(t, otherPacients, len(pacients))
+		for i := range pacients {
+			assert.Equal(t, pacients[i].CPF, otherPacients[i].CPF)
+			assert.Equal(t, pacients[i].Name, otherPacients[i].Name)
+		}
+	})
+}
+
+func TestDefaultFrom(t *testing.T) {
+	wednesday := time.Date(2030, 3, 13, 15, 30, 0, 0, testLocation)
+
+	from := DefaultFrom(wednesday, 4)
+	assert.Equal(t, time.Monday, from.Weekday())
+	assert.Equal(t, time.Date(2030, 2, 25, 0, 0, 0, 0, testLocation), from)
+}
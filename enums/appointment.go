@@ -0,0 +1,18 @@
+package enums
+
+type AppointmentStatus string
+
+const (
+	Scheduled  AppointmentStatus = "scheduled"
+	Confirmed  AppointmentStatus = "confirmed"
+	CheckedIn  AppointmentStatus = "checked_in"
+	InProgress AppointmentStatus = "in_progress"
+	Completed  AppointmentStatus = "completed"
+	Cancelled  AppointmentStatus = "cancelled"
+	NoShow     AppointmentStatus = "no_show"
+)
+
+// AppointmentStatuses lista todos os status válidos de uma consulta
+var AppointmentStatuses = []AppointmentStatus{
+	Scheduled, Confirmed, CheckedIn, InProgress, Completed, Cancelled, NoShow,
+}
@@ -0,0 +1,19 @@
+package enums
+
+// AuditAction é o tipo de operação registrada na trilha de auditoria
+type AuditAction string
+
+const (
+	AuditRead   AuditAction = "read"
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditResource é o tipo de registro acessado
+type AuditResource string
+
+const (
+	AuditPacient AuditResource = "pacient"
+	AuditUser    AuditResource = "user"
+)
@@ -0,0 +1,10 @@
+package enums
+
+type BlockReason string
+
+const (
+	Vacation   BlockReason = "vacation"
+	Congress   BlockReason = "congress"
+	SickLeave  BlockReason = "sick_leave"
+	OtherLeave BlockReason = "other"
+)
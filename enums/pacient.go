@@ -19,3 +19,10 @@ const (
 	OPositive  BloodType = "O+"
 	ONegative  BloodType = "O-"
 )
+
+type PacientStatus string
+
+const (
+	PacientActive   PacientStatus = "active"
+	PacientInactive PacientStatus = "inactive"
+)
@@ -0,0 +1,43 @@
+package enums
+
+// Permission é uma ação do sistema que pode ser concedida a um papel, no
+// formato recurso:ação
+type Permission string
+
+const (
+	PermPacientCreate     Permission = "pacient:create"
+	PermPacientRead       Permission = "pacient:read"
+	PermPacientUpdate     Permission = "pacient:update"
+	PermPacientDeactivate Permission = "pacient:deactivate"
+	PermPacientReadAll    Permission = "pacient:read:all"
+	PermPacientEmergency  Permission = "pacient:emergency-access"
+
+	PermAppointmentCreate Permission = "appointment:create"
+	PermAppointmentRead   Permission = "appointment:read"
+	PermAppointmentUpdate Permission = "appointment:update"
+	PermAppointmentStatus Permission = "appointment:status"
+	PermAgendaRead        Permission = "agenda:read"
+
+	PermDoctorRead      Permission = "doctor:read"
+	PermDoctorProfile   Permission = "doctor:profile"
+	PermSpecialtyManage Permission = "specialty:manage"
+	PermScheduleRead    Permission = "schedule:read"
+	PermScheduleWrite   Permission = "schedule:write"
+	PermSlotRead        Permission = "slot:read"
+
+	PermUserCreate     Permission = "user:create"
+	PermUserRead       Permission = "user:read"
+	PermUserUpdate     Permission = "user:update"
+	PermSessionManage  Permission = "session:manage"
+	PermLockoutManage  Permission = "lockout:manage"
+	PermAuditRead      Permission = "audit:read"
+	PermPermissionRead Permission = "permission:read"
+)
+
+// Permissions lista todas as permissões conhecidas
+var Permissions = []Permission{
+	PermPacientCreate, PermPacientRead, PermPacientUpdate, PermPacientDeactivate, PermPacientReadAll, PermPacientEmergency,
+	PermAppointmentCreate, PermAppointmentRead, PermAppointmentUpdate, PermAppointmentStatus, PermAgendaRead,
+	PermDoctorRead, PermDoctorProfile, PermSpecialtyManage, PermScheduleRead, PermScheduleWrite, PermSlotRead,
+	PermUserCreate, PermUserRead, PermUserUpdate, PermSessionManage, PermLockoutManage, PermAuditRead, PermPermissionRead,
+}
@@ -3,7 +3,21 @@ package enums
 type Role string
 
 const (
-	Receptionist Role = "recepcionist"
-	Doctor       Role = "doctor"
-	Admin        Role = "admin"
+	Receptionist  Role = "recepcionist"
+	Doctor        Role = "doctor"
+	Admin         Role = "admin"
+	Nurse         Role = "nurse"
+	Pharmacist    Role = "pharmacist"
+	LabTechnician Role = "lab_technician"
+)
+
+// Roles lista todos os papéis válidos
+var Roles = []Role{Receptionist, Doctor, Admin, Nurse, Pharmacist, LabTechnician}
+
+// AttemptKind diz se o contador de falhas de login é de um CPF ou de um IP
+type AttemptKind string
+
+const (
+	AttemptCPF AttemptKind = "cpf"
+	AttemptIP  AttemptKind = "ip"
 )
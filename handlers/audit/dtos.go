@@ -0,0 +1,13 @@
+package audit
+
+import "time"
+
+type ListAuditQueryDTO struct {
+	UserID    uint      `form:"userId"`
+	PacientID uint      `form:"pacientId"`
+	Emergency bool      `form:"emergency"`
+	From      time.Time `form:"from"`
+	To        time.Time `form:"to"`
+	Page      int       `form:"page" binding:"omitempty,min=1"`
+	Limit     int       `form:"limit" binding:"omitempty,min=1,max=200"`
+}
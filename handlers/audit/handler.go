@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"net/http"
+
+	aus "github.com/andresidrim/cesupa-hospital/services/audit"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service aus.AuditService
+}
+
+func NewHandler(service aus.AuditService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetAuditLogs consulta a trilha de auditoria
+// @Summary      Consulta a trilha de auditoria
+// @Description  Lista os acessos a pacientes e usuários, do mais recente para o mais antigo, filtrando por usuário, paciente, período ou só os acessos de emergência
+// @Tags         Auditoria
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId     query     int     false  "ID de quem fez o acesso"
+// @Param        pacientId  query     int     false  "ID do paciente acessado"
+// @Param        emergency  query     bool    false  "Só acessos de emergência (quebra de vidro)"
+// @Param        from       query     string  false  "Acessos a partir de (RFC3339)"
+// @Param        to         query     string  false  "Acessos antes de (RFC3339)"
+// @Param        page       query     int     false  "Página (padrão 1)"
+// @Param        limit      query     int     false  "Itens por página (padrão 50, máximo 200)"
+// @Success      200        {object}  handlers.AuditListResponse
+// @Failure      400        {object}  handlers.ErrorResponse  "Invalid input"
+// @Failure      500        {object}  handlers.ErrorResponse  "Failed to fetch audit logs"
+// @Router       /audit [get]
+func (h *Handler) GetAuditLogs(c *gin.Context) {
+	var query ListAuditQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: from must be before to"})
+		return
+	}
+
+	filter := aus.AuditFilter{
+		UserID:    query.UserID,
+		PacientID: query.PacientID,
+		Emergency: query.Emergency,
+		From:      query.From,
+		To:        query.To,
+		Page:      query.Page,
+		Limit:     query.Limit,
+	}
+	entries, total, err := h.service.GetAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch audit logs: " + err.Error()})
+		return
+	}
+
+	page, limit := aus.NormalizePage(filter.Page, filter.Limit)
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
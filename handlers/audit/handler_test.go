@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/middlewares"
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	"github.com/andresidrim/cesupa-hospital/models"
+	aus "github.com/andresidrim/cesupa-hospital/services/audit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuditLogsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockErr        error
+		wantFilter     aus.AuditFilter
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid date", query: "?from=ontem", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "inverted period", query: "?from=2025-03-02T00:00:00Z&to=2025-03-01T00:00:00Z", expectedStatus: http.StatusBadRequest, expectedBody: "from must be before to"},
+		{name: "service error", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to fetch audit logs"},
+		{name: "filters", query: "?userId=3&pacientId=7&page=2", wantFilter: aus.AuditFilter{UserID: 3, PacientID: 7, Page: 2}, expectedStatus: http.StatusOK, expectedBody: `"page":2,"total":1`},
+		{name: "emergency only", query: "?emergency=true", wantFilter: aus.AuditFilter{Emergency: true}, expectedStatus: http.StatusOK, expectedBody: `"total":1`},
+		{name: "defaults", wantFilter: aus.AuditFilter{}, expectedStatus: http.StatusOK, expectedBody: `"limit":50`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAuditService{
+				MockGetAll: func(filter aus.AuditFilter) ([]models.AuditLog, int64, error) {
+					if tt.mockErr != nil {
+						return nil, 0, tt.mockErr
+					}
+					assert.Equal(t, tt.wantFilter, filter)
+					return []models.AuditLog{{ID: 1, UserID: 3}}, 1, nil
+				},
+			}
+			r := gin.Default()
+			r.GET("/audit", NewHandler(ms).GetAuditLogs)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var recorded []models.AuditLog
+	ms := &mocks.MockAuditService{
+		MockRecord: func(entry *models.AuditLog) error {
+			recorded = append(recorded, *entry)
+			return nil
+		},
+	}
+
+	names := map[uint64]string{1: "Ana"}
+	auditMw := middlewares.AuditMiddleware(ms, middlewares.AuditTarget{
+		Type: enums.AuditPacient,
+		Load: func(id uint64) (any, error) { return gin.H{"name": names[id]}, nil },
+	})
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(5)) }, auditMw)
+	r.GET("/pacients/:id", func(c *gin.Context) {
+		c.Set(middlewares.AuditEmergencyKey, c.Query("full") == "true")
+		c.Status(http.StatusOK)
+	})
+	r.PUT("/pacients/:id", func(c *gin.Context) {
+		names[1] = "Ana Maria"
+		c.Status(http.StatusOK)
+	})
+	r.POST("/pacients", func(c *gin.Context) {
+		names[2] = "Bia"
+		c.Set(middlewares.AuditResourceIDKey, uint(2))
+		c.Status(http.StatusCreated)
+	})
+	r.DELETE("/pacients/:id", func(c *gin.Context) { c.AbortWithStatus(http.StatusForbidden) })
+
+	send := func(method, path string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("User-Agent", "test-agent")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send(http.MethodGet, "/pacients/1?full=true")
+	send(http.MethodPut, "/pacients/1")
+	send(http.MethodPost, "/pacients")
+	send(http.MethodDelete, "/pacients/1")
+
+	assert.Len(t, recorded, 4)
+
+	read := recorded[0]
+	assert.Equal(t, uint(5), read.UserID)
+	assert.Equal(t, enums.AuditRead, read.Action)
+	assert.Equal(t, "/pacients/:id", read.Route)
+	assert.Equal(t, "/pacients/1?full=true", read.Path)
+	assert.Equal(t, uint(1), *read.ResourceID)
+	assert.Equal(t, "test-agent", read.UserAgent)
+	assert.True(t, read.Emergency)
+	assert.Nil(t, read.Changes)
+
+	update := recorded[1]
+	assert.Equal(t, enums.AuditUpdate, update.Action)
+	assert.False(t, update.Emergency)
+	assert.Equal(t, models.AuditChanges{"name": {From: "Ana", To: "Ana Maria"}}, update.Changes)
+
+	create := recorded[2]
+	assert.Equal(t, enums.AuditCreate, create.Action)
+	assert.Equal(t, uint(2), *create.ResourceID)
+	assert.Equal(t, models.AuditChange{From: nil, To: "Bia"}, create.Changes["name"])
+
+	// acessos negados também ficam na trilha, sem diff
+	denied := recorded[3]
+	assert.Equal(t, enums.AuditDelete, denied.Action)
+	assert.Equal(t, http.StatusForbidden, denied.Status)
+	assert.Nil(t, denied.Changes)
+}
+
+func TestAuditMiddlewareSubjects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var recorded []models.AuditLog
+	ms := &mocks.MockAuditService{
+		MockRecord: func(entry *models.AuditLog) error {
+			recorded = append(recorded, *entry)
+			return nil
+		},
+	}
+
+	auditPacient := middlewares.AuditMiddleware(ms, middlewares.AuditTarget{Type: enums.AuditPacient})
+	statuses := map[uint64]string{42: "scheduled"}
+	auditAppointment := middlewares.AuditMiddleware(ms, middlewares.AuditTarget{
+		Type:           enums.AuditPacient,
+		Load:           func(id uint64) (any, error) { return gin.H{"status": statuses[id]}, nil },
+		ForeignRouteID: true,
+	})
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(5)) })
+	r.GET("/pacients", auditPacient, func(c *gin.Context) {
+		c.Set(middlewares.AuditSubjectsKey, []uint{3, 1, 3})
+		c.Status(http.StatusOK)
+	})
+	r.GET("/appointments", auditAppointment, func(c *gin.Context) {
+		c.Set(middlewares.AuditSubjectsKey, []uint{})
+		c.Status(http.StatusOK)
+	})
+	r.GET("/appointments/:id", auditAppointment, func(c *gin.Context) {
+		c.Set(middlewares.AuditSubjectsKey, []uint{8})
+		c.AbortWithStatus(http.StatusForbidden)
+	})
+	r.POST("/appointments/:id/cancel", auditAppointment, func(c *gin.Context) {
+		c.Set(middlewares.AuditSubjectsKey, []uint{8})
+		statuses[42] = "cancelled"
+		c.Status(http.StatusOK)
+	})
+	r.GET("/appointments/:id/missing", auditAppointment, func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusNotFound)
+	})
+
+	send := func(path string) []models.AuditLog {
+		recorded = nil
+		method := http.MethodGet
+		if strings.HasSuffix(path, "/cancel") {
+			method = http.MethodPost
+		}
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+		return recorded
+	}
+
+	// uma linha por paciente devolvido, sem repetir
+	entries := send("/pacients")
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, uint(1), *entries[0].ResourceID)
+		assert.Equal(t, uint(3), *entries[1].ResourceID)
+		assert.Equal(t, "/pacients", entries[1].Route)
+	}
+
+	// lista vazia ainda fica na trilha
+	entries = send("/appointments")
+	if assert.Len(t, entries, 1) {
+		assert.Nil(t, entries[0].ResourceID)
+	}
+
+	// o :id da consulta não é tomado por paciente
+	entries = send("/appointments/42")
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, uint(8), *entries[0].ResourceID)
+		assert.Equal(t, http.StatusForbidden, entries[0].Status)
+	}
+	// alterar a consulta fica no paciente dela, com o antes e o depois
+	entries = send("/appointments/42/cancel")
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, uint(8), *entries[0].ResourceID)
+		assert.Equal(t, enums.AuditUpdate, entries[0].Action)
+		assert.Equal(t, models.AuditChanges{"status": {From: "scheduled", To: "cancelled"}}, entries[0].Changes)
+	}
+
+	entries = send("/appointments/42/missing")
+	if assert.Len(t, entries, 1) {
+		assert.Nil(t, entries[0].ResourceID)
+	}
+}
@@ -1,15 +1,27 @@
 package auth
 
-import "github.com/andresidrim/cesupa-hospital/enums"
+import (
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/enums"
+)
 
 type RegisterDTO struct {
 	Name     string     `json:"name" binding:"required"`
-	CPF      string     `json:"cpf" binding:"required"`
-	Password string     `json:"password" binding:"required,min=6"`
-	Role     enums.Role `json:"role" binding:"required"`
+	CPF      cpf.CPF    `json:"cpf" binding:"required,cpf" swaggertype:"string" example:"529.982.247-25"`
+	Password string     `json:"password" binding:"required"`
+	Role     enums.Role `json:"role" binding:"required,role" enums:"recepcionist,doctor,admin,nurse,pharmacist,lab_technician"`
+}
+
+type RefreshDTO struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type VerifyTOTPDTO struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"`
 }
 
 type LoginDTO struct {
-	CPF      string `json:"cpf" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	CPF      cpf.CPF `json:"cpf" binding:"required,cpf" swaggertype:"string" example:"529.982.247-25"`
+	Password string  `json:"password" binding:"required"`
 }
@@ -1,11 +1,19 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	passwordsHandler "github.com/andresidrim/cesupa-hospital/handlers/passwords"
 	"github.com/andresidrim/cesupa-hospital/models"
 	as "github.com/andresidrim/cesupa-hospital/services/auth"
+	"github.com/andresidrim/cesupa-hospital/services/lockouts"
+	ss "github.com/andresidrim/cesupa-hospital/services/sessions"
+	"github.com/andresidrim/cesupa-hospital/services/twofactor"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
@@ -18,13 +26,14 @@ func NewHandler(service as.AuthService) *Handler {
 
 // Register godoc
 // @Summary     Cadastra um novo usuário
-// @Description Recebe name, cpf, password e role e cria o usuário
+// @Description Recebe name, cpf, password e role e cria o usuário. A senha segue a política de senha e precisa ser trocada no primeiro acesso
 // @Tags        auth
 // @Accept      json
 // @Produce     json
 // @Param       payload body     RegisterDTO true "Dados para registro"
 // @Success     201     {object} handlers.RegisterResponse
 // @Failure     400     {object} handlers.ErrorResponse
+// @Failure     422     {object} handlers.PasswordPolicyResponse
 // @Failure     500     {object} handlers.ErrorResponse
 // @Router      /register [post]
 func (h *Handler) Register(c *gin.Context) {
@@ -42,6 +51,9 @@ func (h *Handler) Register(c *gin.Context) {
 	}
 
 	if err := h.service.Register(&user); err != nil {
+		if passwordsHandler.RespondPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to register user: " + err.Error()})
 		return
 	}
@@ -58,14 +70,19 @@ func (h *Handler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary     Faz login e retorna JWT
-// @Description Recebe cpf e senha e devolve um token
+// @Description Recebe cpf e senha e devolve um access token de curta duração e o refresh token da sessão.
+// @Description Falhas seguidas bloqueiam o CPF e o IP temporariamente (429 com Retry-After).
+// @Description Quem tem segundo fator recebe um desafio (mfaRequired) que deve ser concluído em /login/2fa.
 // @Tags        auth
 // @Accept      json
 // @Produce     json
 // @Param       payload body     LoginDTO true "Dados para login"
 // @Success     200     {object} handlers.TokenResponse
+// @Success     202     {object} handlers.LoginChallengeResponse
 // @Failure     400     {object} handlers.ErrorResponse
-// @Failure     401     {object} handlers.ErrorResponse
+// @Failure     401     {object} handlers.ErrorResponse "Invalid credentials"
+// @Failure     429     {object} handlers.ErrorResponse "Too many failed attempts"
+// @Failure     500     {object} handlers.ErrorResponse
 // @Router      /login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var payload LoginDTO
@@ -74,11 +91,129 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.Login(payload.CPF, payload.Password)
+	result, err := h.service.Login(payload.CPF, payload.Password, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	if result.Challenge != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"mfaRequired":    true,
+			"challengeToken": result.Challenge.Token,
+			"expiresAt":      result.Challenge.ExpiresAt,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(result.Tokens))
+}
+
+// VerifyTOTP godoc
+// @Summary     Conclui o login com o segundo fator
+// @Description Troca o desafio devolvido por /login e um código do aplicativo autenticador (ou um código de recuperação) pelos tokens da sessão.
+// @Description Códigos errados contam para o bloqueio do CPF e do IP; o desafio vale por poucos minutos e poucas tentativas.
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       payload body     VerifyTOTPDTO true "Desafio e código"
+// @Success     200     {object} handlers.TokenResponse
+// @Failure     400     {object} handlers.ErrorResponse
+// @Failure     401     {object} handlers.ErrorResponse "Invalid challenge or code"
+// @Failure     429     {object} handlers.ErrorResponse "Too many failed attempts"
+// @Failure     500     {object} handlers.ErrorResponse
+// @Router      /login/2fa [post]
+func (h *Handler) VerifyTOTP(c *gin.Context) {
+	var payload VerifyTOTPDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	tokens, err := h.service.VerifyTOTP(payload.ChallengeToken, payload.Code)
 	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// Refresh godoc
+// @Summary     Renova o access token
+// @Description Troca o refresh token por um novo par de tokens; o refresh token usado deixa de valer
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       payload body     RefreshDTO true "Refresh token atual"
+// @Success     200     {object} handlers.TokenResponse
+// @Failure     400     {object} handlers.ErrorResponse
+// @Failure     401     {object} handlers.ErrorResponse "Invalid, reused or expired token, or inactive user"
+// @Failure     500     {object} handlers.ErrorResponse
+// @Router      /token/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var payload RefreshDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	tokens, err := h.service.Refresh(payload.RefreshToken)
+	switch {
+	case errors.Is(err, ss.ErrInvalidToken), errors.Is(err, ss.ErrTokenReused),
+		errors.Is(err, ss.ErrSessionRevoked), errors.Is(err, ss.ErrSessionExpired),
+		errors.Is(err, ss.ErrUserInactive):
 		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
 		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to refresh token: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// Logout godoc
+// @Summary     Encerra a sessão atual
+// @Description Revoga a sessão do token usado; access e refresh tokens dela deixam de valer
+// @Tags        auth
+// @Security    BearerAuth
+// @Success     204
+// @Failure     401 {object} handlers.ErrorResponse
+// @Failure     500 {object} handlers.ErrorResponse
+// @Router      /logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	userID := c.GetUint("userID")
+	sessionID := c.GetUint("sessionID")
+
+	if err := h.service.Logout(userID, sessionID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to logout: " + err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.Status(http.StatusNoContent)
+}
+
+func respondLoginError(c *gin.Context, err error) {
+	var locked *lockouts.LockedError
+	switch {
+	case errors.As(err, &locked):
+		retryAfter := locked.RetryAfter(time.Now())
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "Too many failed attempts: " + err.Error()})
+	case errors.Is(err, as.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid credentials"})
+	case errors.Is(err, as.ErrInvalidChallenge), errors.Is(err, twofactor.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to login: " + err.Error()})
+	}
+}
+
+func tokenResponse(tokens *as.TokenPair) gin.H {
+	return gin.H{
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	}
 }
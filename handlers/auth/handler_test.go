@@ -2,20 +2,37 @@ package auth
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/middlewares"
 	"github.com/andresidrim/cesupa-hospital/mocks"
 	"github.com/andresidrim/cesupa-hospital/models"
+	as "github.com/andresidrim/cesupa-hospital/services/auth"
+	"github.com/andresidrim/cesupa-hospital/services/lockouts"
+	pws "github.com/andresidrim/cesupa-hospital/services/passwords"
+	ss "github.com/andresidrim/cesupa-hospital/services/sessions"
+	tfs "github.com/andresidrim/cesupa-hospital/services/twofactor"
 	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/andresidrim/cesupa-hospital/validators"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/gorm"
 )
 
+func TestMain(m *testing.M) {
+	if err := validators.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
 // setup router for Register
 func setupRegisterRouter(ms *mocks.MockAuthService) *gin.Engine {
 	h := NewHandler(ms)
@@ -48,19 +65,44 @@ func TestRegisterHandler(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "Invalid input",
 		},
+		{
+			name:           "invalid cpf",
+			body:           `{ "name":"Alice", "cpf":"529.982.247-24", "password":"secret", "role":"admin" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "cpf",
+		},
+		{
+			name:           "unknown role",
+			body:           `{ "name":"Alice", "cpf":"529.982.247-25", "password":"secret", "role":"janitor" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "role",
+		},
+		{
+			name:           "clinical staff role",
+			body:           `{ "name":"Alice", "cpf":"529.982.247-25", "password":"secret", "role":"lab_technician" }`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"role":"lab_technician"`,
+		},
+		{
+			name:            "password rejected by policy",
+			body:            `{ "name":"Alice", "cpf":"529.982.247-25", "password":"secret", "role":"admin" }`,
+			mockRegisterErr: &pws.PolicyError{Violations: []string{"is too common"}},
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedBody:    `"violations":["is too common"]`,
+		},
 		{
 			name:            "service error",
-			body:            `{ "name":"Alice", "cpf":"111", "password":"secret", "role":"admin" }`,
+			body:            `{ "name":"Alice", "cpf":"529.982.247-25", "password":"secret", "role":"admin" }`,
 			mockRegisterErr: assert.AnError,
 			expectedStatus:  http.StatusInternalServerError,
 			expectedBody:    "Failed to register user",
 		},
 		{
 			name:            "success",
-			body:            `{ "name":"Alice", "cpf":"111", "password":"secret", "role":"doctor" }`,
+			body:            `{ "name":"Alice", "cpf":"529.982.247-25", "password":"secret", "role":"doctor" }`,
 			mockRegisterErr: nil,
 			expectedStatus:  http.StatusCreated,
-			expectedBody:    `"name":"Alice"`, // JSON includes created user
+			expectedBody:    `"cpf":"529.982.247-25"`, // JSON includes created user with masked cpf
 		},
 	}
 
@@ -68,6 +110,8 @@ func TestRegisterHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ms := &mocks.MockAuthService{
 				MockRegister: func(u *models.User) error {
+					// o serviço recebe o CPF normalizado
+					assert.Equal(t, cpf.CPF("52998224725"), u.CPF)
 					u.ID = 99
 					return tt.mockRegisterErr
 				},
@@ -92,6 +136,7 @@ func TestLoginHandler(t *testing.T) {
 		name           string
 		body           string
 		mockToken      string
+		mockChallenge  bool
 		mockLoginErr   error
 		expectedStatus int
 		expectedBody   string
@@ -102,28 +147,62 @@ func TestLoginHandler(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "Invalid input",
 		},
+		{
+			name:           "invalid cpf",
+			body:           `{ "cpf":"123", "password":"secret" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
 		{
 			name:           "auth failure",
-			body:           `{ "cpf":"123", "password":"wrong" }`,
-			mockLoginErr:   assert.AnError,
+			body:           `{ "cpf":"52998224725", "password":"wrong" }`,
+			mockLoginErr:   as.ErrInvalidCredentials,
 			expectedStatus: http.StatusUnauthorized,
-			expectedBody:   assert.AnError.Error(),
+			expectedBody:   "Invalid credentials",
 		},
 		{
-			name:           "success",
-			body:           `{ "cpf":"123", "password":"secret" }`,
+			name:           "locked out",
+			body:           `{ "cpf":"52998224725", "password":"secret" }`,
+			mockLoginErr:   &lockouts.LockedError{Until: time.Now().Add(time.Minute)},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "Too many failed attempts",
+		},
+		{
+			name:           "service error",
+			body:           `{ "cpf":"52998224725", "password":"secret" }`,
+			mockLoginErr:   assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to login",
+		},
+		{
+			name:           "success with formatted cpf",
+			body:           `{ "cpf":"529.982.247-25", "password":"secret" }`,
 			mockToken:      "tok123",
 			mockLoginErr:   nil,
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"token":"tok123"`,
 		},
+		{
+			name:           "second factor required",
+			body:           `{ "cpf":"52998224725", "password":"secret" }`,
+			mockChallenge:  true,
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `"challengeToken":"chal789"`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ms := &mocks.MockAuthService{
-				MockLogin: func(cpf, pass string) (string, error) {
-					return tt.mockToken, tt.mockLoginErr
+				MockLogin: func(document cpf.CPF, pass, userAgent, ip string) (*as.LoginResult, error) {
+					assert.Equal(t, cpf.CPF("52998224725"), document)
+					if tt.mockLoginErr != nil {
+						return nil, tt.mockLoginErr
+					}
+					if tt.mockChallenge {
+						return &as.LoginResult{Challenge: &as.Challenge{Token: "chal789", ExpiresAt: time.Now().Add(time.Minute)}}, nil
+					}
+					return &as.LoginResult{Tokens: &as.TokenPair{AccessToken: tt.mockToken, RefreshToken: "ref456", ExpiresIn: 900}}, nil
 				},
 			}
 			r := setupLoginRouter(ms)
@@ -135,25 +214,32 @@ func TestLoginHandler(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusTooManyRequests {
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			}
 		})
 	}
 }
 
+// testSigner assina os tokens dos testes com a configuração padrão
+func testSigner(t *testing.T) *utils.JWTSigner {
+	cfg := config.Default().JWT
+	cfg.SecretKey = "0123456789abcdef0123456789abcdef"
+	signer, err := utils.NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	return signer
+}
+
 func TestRegisterHandlerUnauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-
-	mockUserSvc := &mocks.MockUserService{
-		MockGet: func(id uint64) (*models.User, error) {
-			return &models.User{Model: gorm.Model{ID: uint(id)}, Role: enums.Doctor}, nil
-		},
-	}
+	signer := testSigner(t)
 
 	mockAuthSvc := &mocks.MockAuthService{}
 
 	r := gin.Default()
 	protected := r.Group("/")
 	protected.Use(
-		middlewares.JWTAuthMiddleware(mockUserSvc),
+		middlewares.JWTAuthMiddleware(signer, &mocks.MockSessionService{}),
 		middlewares.RoleMiddleware(enums.Admin),
 	)
 	protected.POST("/register", NewHandler(mockAuthSvc).Register)
@@ -163,10 +249,190 @@ func TestRegisterHandlerUnauthorized(t *testing.T) {
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-	token, _ := utils.GenerateJWT(1)
+	// o papel vem do token: médico não registra usuários
+	token, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Doctor, SessionID: 1})
 	w = httptest.NewRecorder()
 	req = httptest.NewRequest("POST", "/register", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
+
+func TestVerifyTOTPHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "missing code",
+			body:           `{ "challengeToken": "chal" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "wrong code",
+			body:           `{ "challengeToken": "chal", "code": "000000" }`,
+			mockErr:        tfs.ErrInvalidCode,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   tfs.ErrInvalidCode.Error(),
+		},
+		{
+			name:           "expired challenge",
+			body:           `{ "challengeToken": "old", "code": "123456" }`,
+			mockErr:        as.ErrInvalidChallenge,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   as.ErrInvalidChallenge.Error(),
+		},
+		{
+			name:           "locked out",
+			body:           `{ "challengeToken": "chal", "code": "123456" }`,
+			mockErr:        &lockouts.LockedError{Until: time.Now().Add(time.Minute)},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "Too many failed attempts",
+		},
+		{
+			name:           "success",
+			body:           `{ "challengeToken": "chal", "code": "123456" }`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"token":"tok"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAuthService{
+				MockVerifyTOTP: func(challengeToken, code string) (*as.TokenPair, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &as.TokenPair{AccessToken: "tok", RefreshToken: "ref", ExpiresIn: 900}, nil
+				},
+			}
+			r := gin.Default()
+			r.POST("/login/2fa", NewHandler(ms).VerifyTOTP)
+
+			req := httptest.NewRequest("POST", "/login/2fa", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestRefreshHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "missing token",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "reused token",
+			body:           `{ "refreshToken": "old" }`,
+			mockErr:        ss.ErrTokenReused,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   ss.ErrTokenReused.Error(),
+		},
+		{
+			name:           "inactive user",
+			body:           `{ "refreshToken": "current" }`,
+			mockErr:        ss.ErrUserInactive,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   ss.ErrUserInactive.Error(),
+		},
+		{
+			name:           "internal error",
+			body:           `{ "refreshToken": "current" }`,
+			mockErr:        errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to refresh token",
+		},
+		{
+			name:           "success",
+			body:           `{ "refreshToken": "current" }`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"refreshToken":"next"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAuthService{
+				MockRefresh: func(refreshToken string) (*as.TokenPair, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &as.TokenPair{AccessToken: "tok", RefreshToken: "next", ExpiresIn: 900}, nil
+				},
+			}
+			r := gin.Default()
+			r.POST("/token/refresh", NewHandler(ms).Refresh)
+
+			req := httptest.NewRequest("POST", "/token/refresh", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := testSigner(t)
+
+	revoked := false
+	mockSessionSvc := &mocks.MockSessionService{
+		MockValidate: func(sessionID, userID uint) error {
+			if revoked {
+				return ss.ErrSessionRevoked
+			}
+			return nil
+		},
+	}
+	mockAuthSvc := &mocks.MockAuthService{
+		MockLogout: func(userID, sessionID uint) error {
+			assert.Equal(t, uint(1), userID)
+			assert.Equal(t, uint(7), sessionID)
+			revoked = true
+			return nil
+		},
+	}
+
+	r := gin.Default()
+	r.POST("/logout", middlewares.JWTAuthMiddleware(signer, mockSessionSvc), NewHandler(mockAuthSvc).Logout)
+
+	token, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Doctor, SessionID: 7})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// o mesmo access token é recusado depois do logout
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid session")
+}
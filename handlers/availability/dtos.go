@@ -0,0 +1,27 @@
+package availability
+
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+)
+
+type ShiftDTO struct {
+	Weekday   *int   `json:"weekday" binding:"required,min=0,max=6"`
+	StartTime string `json:"startTime" binding:"required"`
+	EndTime   string `json:"endTime" binding:"required"`
+}
+
+type BlockDTO struct {
+	StartsAt time.Time         `json:"startsAt" binding:"required"`
+	EndsAt   time.Time         `json:"endsAt" binding:"required"`
+	Reason   enums.BlockReason `json:"reason" binding:"required,oneof=vacation congress sick_leave other"`
+	Notes    *string           `json:"notes"`
+}
+
+type SlotQueryDTO struct {
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	Duration int       `form:"duration" binding:"omitempty,min=5,max=480"`
+	Limit    int       `form:"limit" binding:"omitempty,min=1,max=200"`
+}
@@ -0,0 +1,445 @@
+package availability
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/models"
+	avs "github.com/andresidrim/cesupa-hospital/services/availability"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service avs.AvailabilityService
+}
+
+func NewHandler(service avs.AvailabilityService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetShifts lista os turnos semanais de um médico
+// @Summary      Lista turnos
+// @Description  Retorna os turnos semanais recorrentes do médico
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID do médico"
+// @Success      200  {array}   models.Shift
+// @Failure      400  {object}  ErrorResponse  "Invalid ID"
+// @Failure      500  {object}  ErrorResponse  "Failed to fetch shifts"
+// @Router       /doctors/{id}/shifts [get]
+func (h *Handler) GetShifts(c *gin.Context) {
+	doctorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	shifts, err := h.service.GetShifts(doctorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch shifts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shifts": shifts})
+}
+
+// AddShift cadastra um turno semanal
+// @Summary      Cadastra turno
+// @Description  Cria um turno semanal recorrente (weekday 0 = domingo, horários HH:MM)
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int       true  "ID do médico"
+// @Param        shift  body      ShiftDTO  true  "Dados do turno"
+// @Success      201    {object}  models.Shift
+// @Failure      400    {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404    {object}  ErrorResponse  "Doctor not found"
+// @Failure      409    {object}  ErrorResponse  "Overlapping shift"
+// @Router       /doctors/{id}/shifts [post]
+func (h *Handler) AddShift(c *gin.Context) {
+	doctorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload ShiftDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	shift := models.Shift{
+		UserID:    uint(doctorID),
+		Weekday:   time.Weekday(*payload.Weekday),
+		StartTime: payload.StartTime,
+		EndTime:   payload.EndTime,
+	}
+
+	if err := h.service.CreateShift(&shift); err != nil {
+		c.JSON(statusFor(err), gin.H{"message": "Failed to create shift: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"shift": shift})
+}
+
+// UpdateShift altera um turno semanal
+// @Summary      Atualiza turno
+// @Description  Substitui dia e horários de um turno do médico
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int       true  "ID do médico"
+// @Param        shiftId  path      int       true  "ID do turno"
+// @Param        shift    body      ShiftDTO  true  "Dados do turno"
+// @Success      200      {object}  models.Shift
+// @Failure      400      {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404      {object}  ErrorResponse  "Shift not found"
+// @Failure      409      {object}  ErrorResponse  "Overlapping shift"
+// @Router       /doctors/{id}/shifts/{shiftId} [put]
+func (h *Handler) UpdateShift(c *gin.Context) {
+	doctorID, shiftID, ok := parseIDs(c, "shiftId")
+	if !ok {
+		return
+	}
+
+	var payload ShiftDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	shift := models.Shift{
+		UserID:    uint(doctorID),
+		Weekday:   time.Weekday(*payload.Weekday),
+		StartTime: payload.StartTime,
+		EndTime:   payload.EndTime,
+	}
+
+	if err := h.service.UpdateShift(shiftID, &shift); err != nil {
+		c.JSON(statusFor(err), gin.H{"message": "Failed to update shift: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shift": shift})
+}
+
+// DeleteShift remove um turno semanal
+// @Summary      Remove turno
+// @Description  Exclui um turno do médico
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int  true  "ID do médico"
+// @Param        shiftId  path  int  true  "ID do turno"
+// @Success      204
+// @Failure      400      {object}  ErrorResponse  "Invalid ID"
+// @Failure      404      {object}  ErrorResponse  "Shift not found"
+// @Router       /doctors/{id}/shifts/{shiftId} [delete]
+func (h *Handler) DeleteShift(c *gin.Context) {
+	doctorID, shiftID, ok := parseIDs(c, "shiftId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteShift(doctorID, shiftID); err != nil {
+		c.JSON(statusFor(err), gin.H{"message": "Failed to delete shift: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetBlocks lista os bloqueios de agenda de um médico
+// @Summary      Lista bloqueios
+// @Description  Retorna férias, congressos e licenças do médico, opcionalmente em um intervalo
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int     true   "ID do médico"
+// @Param        from  query     string  false  "Início do intervalo (RFC3339)"
+// @Param        to    query     string  false  "Fim do intervalo (RFC3339)"
+// @Success      200   {array}   models.AvailabilityBlock
+// @Failure      400   {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      500   {object}  ErrorResponse  "Failed to fetch blocks"
+// @Router       /doctors/{id}/blocks [get]
+func (h *Handler) GetBlocks(c *gin.Context) {
+	doctorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	from, err := parseOptionalTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+	to, err := parseOptionalTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	blocks, err := h.service.GetBlocks(doctorID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch blocks: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocks": blocks})
+}
+
+// AddBlock cadastra um bloqueio de agenda
+// @Summary      Cadastra bloqueio
+// @Description  Bloqueia a agenda do médico por férias, congresso, licença médica ou outro motivo
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int       true  "ID do médico"
+// @Param        block  body      BlockDTO  true  "Dados do bloqueio"
+// @Success      201    {object}  models.AvailabilityBlock
+// @Failure      400    {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404    {object}  ErrorResponse  "Doctor not found"
+// @Router       /doctors/{id}/blocks [post]
+func (h *Handler) AddBlock(c *gin.Context) {
+	doctorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload BlockDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	block := models.AvailabilityBlock{
+		UserID:   uint(doctorID),
+		StartsAt: payload.StartsAt,
+		EndsAt:   payload.EndsAt,
+		Reason:   payload.Reason,
+		Notes:    payload.Notes,
+	}
+
+	if err := h.service.CreateBlock(&block); err != nil {
+		c.JSON(statusFor(err), gin.H{"message": "Failed to create block: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"block": block})
+}
+
+// UpdateBlock altera um bloqueio de agenda
+// @Summary      Atualiza bloqueio
+// @Description  Substitui período, motivo e observações de um bloqueio
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int       true  "ID do médico"
+// @Param        blockId  path      int       true  "ID do bloqueio"
+// @Param        block    body      BlockDTO  true  "Dados do bloqueio"
+// @Success      200      {object}  models.AvailabilityBlock
+// @Failure      400      {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404      {object}  ErrorResponse  "Block not found"
+// @Router       /doctors/{id}/blocks/{blockId} [put]
+func (h *Handler) UpdateBlock(c *gin.Context) {
+	doctorID, blockID, ok := parseIDs(c, "blockId")
+	if !ok {
+		return
+	}
+
+	var payload BlockDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	block := models.AvailabilityBlock{
+		UserID:   uint(doctorID),
+		StartsAt: payload.StartsAt,
+		EndsAt:   payload.EndsAt,
+		Reason:   payload.Reason,
+		Notes:    payload.Notes,
+	}
+
+	if err := h.service.UpdateBlock(blockID, &block); err != nil {
+		c.JSON(statusFor(err), gin.H{"message": "Failed to update block: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"block": block})
+}
+
+// DeleteBlock remove um bloqueio de agenda
+// @Summary      Remove bloqueio
+// @Description  Exclui um bloqueio, liberando novamente o período
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int  true  "ID do médico"
+// @Param        blockId  path  int  true  "ID do bloqueio"
+// @Success      204
+// @Failure      400      {object}  ErrorResponse  "Invalid ID"
+// @Failure      404      {object}  ErrorResponse  "Block not found"
+// @Router       /doctors/{id}/blocks/{blockId} [delete]
+func (h *Handler) DeleteBlock(c *gin.Context) {
+	doctorID, blockID, ok := parseIDs(c, "blockId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteBlock(doctorID, blockID); err != nil {
+		c.JSON(statusFor(err), gin.H{"message": "Failed to delete block: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDoctorSlots lista os horários livres de um médico
+// @Summary      Horários livres do médico
+// @Description  Calcula os horários livres a partir dos turnos, descontando bloqueios e consultas marcadas. Sem from/to, busca nos próximos 7 dias.
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int     true   "ID do médico"
+// @Param        from      query     string  false  "Início da busca (RFC3339)"
+// @Param        to        query     string  false  "Fim da busca (RFC3339)"
+// @Param        duration  query     int     false  "Duração da consulta em minutos (padrão: a do perfil de cada médico)"
+// @Param        limit     query     int     false  "Quantidade máxima de horários"
+// @Success      200       {array}   availability.Slot
+// @Failure      400       {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      500       {object}  ErrorResponse  "Failed to search slots"
+// @Router       /doctors/{id}/slots [get]
+func (h *Handler) GetDoctorSlots(c *gin.Context) {
+	doctorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	filter, ok := bindSlotFilter(c, 0)
+	if !ok {
+		return
+	}
+	filter.DoctorIDs = []uint{uint(doctorID)}
+
+	h.respondSlots(c, filter)
+}
+
+// SearchSlots lista os próximos horários livres entre todos os médicos
+// @Summary      Próximos horários livres
+// @Description  Busca os horários livres de todos os médicos, ou só dos que têm a especialidade informada, ordenados do mais próximo ao mais distante (padrão: 3 horários)
+// @Tags         Agenda
+// @Accept       json
+// @Produce      json
+// @Param        specialty  query     string  false  "Nome da especialidade (ex.: Cardiologia)"
+// @Param        from       query     string  false  "Início da busca (RFC3339)"
+// @Param        to         query     string  false  "Fim da busca (RFC3339)"
+// @Param        duration   query     int     false  "Duração da consulta em minutos (padrão: a do perfil de cada médico)"
+// @Param        limit      query     int     false  "Quantidade máxima de horários (padrão 3)"
+// @Success      200        {array}   availability.Slot
+// @Failure      400        {object}  ErrorResponse  "Invalid input"
+// @Failure      500        {object}  ErrorResponse  "Failed to search slots"
+// @Router       /slots [get]
+func (h *Handler) SearchSlots(c *gin.Context) {
+	filter, ok := bindSlotFilter(c, defaultSlotSuggestions)
+	if !ok {
+		return
+	}
+	filter.Specialty = c.Query("specialty")
+
+	h.respondSlots(c, filter)
+}
+
+func (h *Handler) respondSlots(c *gin.Context, filter avs.SlotFilter) {
+	slots, err := h.service.FindSlots(filter)
+	if err != nil {
+		if errors.Is(err, avs.ErrInvalidInterval) || errors.Is(err, avs.ErrRangeTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to search slots: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
+// defaultSlotSuggestions é quantos horários a recepção oferece por padrão ao paciente
+const defaultSlotSuggestions = 3
+
+// defaultSlotWindow é o intervalo pesquisado quando "to" não é informado
+const defaultSlotWindow = 7 * 24 * time.Hour
+
+func bindSlotFilter(c *gin.Context, defaultLimit int) (avs.SlotFilter, bool) {
+	var query SlotQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return avs.SlotFilter{}, false
+	}
+
+	filter := avs.SlotFilter{
+		From:     query.From,
+		To:       query.To,
+		Duration: time.Duration(query.Duration) * time.Minute,
+		Limit:    query.Limit,
+	}
+	if filter.From.IsZero() {
+		filter.From = time.Now()
+	}
+	if filter.To.IsZero() {
+		filter.To = filter.From.Add(defaultSlotWindow)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+
+	return filter, true
+}
+
+func parseIDs(c *gin.Context, childParam string) (uint64, uint64, bool) {
+	doctorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return 0, 0, false
+	}
+
+	childID, err := strconv.ParseUint(c.Param(childParam), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return 0, 0, false
+	}
+
+	return doctorID, childID, true
+}
+
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, avs.ErrOverlappingShift):
+		return http.StatusConflict
+	case errors.Is(err, avs.ErrInvalidTime),
+		errors.Is(err, avs.ErrInvalidInterval),
+		errors.Is(err, avs.ErrInvalidWeekday),
+		errors.Is(err, avs.ErrNotDoctor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
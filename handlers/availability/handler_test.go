@@ -0,0 +1,330 @@
+package availability
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	"github.com/andresidrim/cesupa-hospital/models"
+	avs "github.com/andresidrim/cesupa-hospital/services/availability"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRouter(ms *mocks.MockAvailabilityService) *gin.Engine {
+	h := NewHandler(ms)
+	r := gin.Default()
+	r.GET("/doctors/:id/shifts", h.GetShifts)
+	r.POST("/doctors/:id/shifts", h.AddShift)
+	r.PUT("/doctors/:id/shifts/:shiftId", h.UpdateShift)
+	r.DELETE("/doctors/:id/shifts/:shiftId", h.DeleteShift)
+	r.GET("/doctors/:id/blocks", h.GetBlocks)
+	r.POST("/doctors/:id/blocks", h.AddBlock)
+	r.PUT("/doctors/:id/blocks/:blockId", h.UpdateBlock)
+	r.DELETE("/doctors/:id/blocks/:blockId", h.DeleteBlock)
+	return r
+}
+
+func TestAddShiftHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		paramID        string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "invalid ID",
+			paramID:        "abc",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid ID",
+		},
+		{
+			name:           "missing weekday",
+			paramID:        "1",
+			body:           `{ "startTime": "08:00", "endTime": "12:00" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "weekday out of range",
+			paramID:        "1",
+			body:           `{ "weekday": 9, "startTime": "08:00", "endTime": "12:00" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "doctor not found",
+			paramID:        "1",
+			body:           `{ "weekday": 1, "startTime": "08:00", "endTime": "12:00" }`,
+			mockErr:        gorm.ErrRecordNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Failed to create shift",
+		},
+		{
+			name:           "overlapping shift",
+			paramID:        "1",
+			body:           `{ "weekday": 1, "startTime": "08:00", "endTime": "12:00" }`,
+			mockErr:        avs.ErrOverlappingShift,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "overlaps",
+		},
+		{
+			name:           "invalid time",
+			paramID:        "1",
+			body:           `{ "weekday": 1, "startTime": "8h", "endTime": "12:00" }`,
+			mockErr:        avs.ErrInvalidTime,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "HH:MM",
+		},
+		{
+			name:           "sunday shift",
+			paramID:        "1",
+			body:           `{ "weekday": 0, "startTime": "08:00", "endTime": "12:00" }`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"doctorId":1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAvailabilityService{
+				MockCreateShift: func(shift *models.Shift) error {
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(http.MethodPost, "/doctors/"+tt.paramID+"/shifts", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestDeleteShiftHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		mockErr        error
+		expectedStatus int
+	}{
+		{name: "invalid shift ID", path: "/doctors/1/shifts/abc", expectedStatus: http.StatusBadRequest},
+		{name: "not found", path: "/doctors/1/shifts/2", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound},
+		{name: "success", path: "/doctors/1/shifts/2", expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAvailabilityService{
+				MockDeleteShift: func(doctorID uint64, id uint64) error {
+					assert.Equal(t, uint64(1), doctorID)
+					assert.Equal(t, uint64(2), id)
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(http.MethodDelete, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAddBlockHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "unknown reason",
+			body:           `{ "startsAt": "2030-01-07T00:00:00Z", "endsAt": "2030-01-14T00:00:00Z", "reason": "beach" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "invalid interval",
+			body:           `{ "startsAt": "2030-01-14T00:00:00Z", "endsAt": "2030-01-07T00:00:00Z", "reason": "vacation" }`,
+			mockErr:        avs.ErrInvalidInterval,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "end must be after start",
+		},
+		{
+			name:           "success",
+			body:           `{ "startsAt": "2030-01-07T00:00:00Z", "endsAt": "2030-01-14T00:00:00Z", "reason": "vacation", "notes": "Férias" }`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"reason":"vacation"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAvailabilityService{
+				MockCreateBlock: func(block *models.AvailabilityBlock) error {
+					assert.Equal(t, uint(3), block.UserID)
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(http.MethodPost, "/doctors/3/blocks", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestGetBlocksHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid from", query: "?from=yesterday", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "no interval", query: "", expectedStatus: http.StatusOK, expectedBody: `"blocks":[]`},
+		{name: "with interval", query: "?from=2030-01-01T00:00:00Z&to=2030-02-01T00:00:00Z", expectedStatus: http.StatusOK, expectedBody: `"blocks":[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAvailabilityService{
+				MockGetBlocks: func(doctorID uint64, from, to time.Time) ([]models.AvailabilityBlock, error) {
+					return []models.AvailabilityBlock{}, nil
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(http.MethodGet, "/doctors/1/blocks"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestSlotsHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		mockErr        error
+		check          func(t *testing.T, filter avs.SlotFilter)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "doctor slots with defaults",
+			path: "/doctors/4/slots",
+			check: func(t *testing.T, filter avs.SlotFilter) {
+				assert.Equal(t, []uint{4}, filter.DoctorIDs)
+				// sem duração, cada médico usa a do próprio perfil
+				assert.Zero(t, filter.Duration)
+				assert.Equal(t, 7*24*time.Hour, filter.To.Sub(filter.From))
+				assert.Zero(t, filter.Limit)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"slots":[]`,
+		},
+		{
+			name: "search suggests three slots by default",
+			path: "/slots?from=2030-01-07T00:00:00Z&to=2030-01-08T00:00:00Z&duration=45",
+			check: func(t *testing.T, filter avs.SlotFilter) {
+				assert.Empty(t, filter.DoctorIDs)
+				assert.Equal(t, 45*time.Minute, filter.Duration)
+				assert.Equal(t, 3, filter.Limit)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "search by specialty",
+			path: "/slots?specialty=Cardiologia",
+			check: func(t *testing.T, filter avs.SlotFilter) {
+				assert.Equal(t, "Cardiologia", filter.Specialty)
+				assert.Empty(t, filter.DoctorIDs)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid duration",
+			path:           "/slots?duration=1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "invalid doctor ID",
+			path:           "/doctors/abc/slots",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid ID",
+		},
+		{
+			name:           "range too large",
+			path:           "/slots",
+			mockErr:        avs.ErrRangeTooLarge,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "31 days",
+		},
+		{
+			name:           "service error",
+			path:           "/slots",
+			mockErr:        assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to search slots",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockAvailabilityService{
+				MockFindSlots: func(filter avs.SlotFilter) ([]avs.Slot, error) {
+					if tt.check != nil {
+						tt.check(t, filter)
+					}
+					return []avs.Slot{}, tt.mockErr
+				},
+			}
+			h := NewHandler(ms)
+			r := gin.Default()
+			r.GET("/doctors/:id/slots", h.GetDoctorSlots)
+			r.GET("/slots", h.SearchSlots)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
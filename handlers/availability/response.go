@@ -0,0 +1,6 @@
+package availability
+
+// ErrorResponse representa um erro comum
+type ErrorResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,12 @@
+package doctors
+
+type DoctorProfileDTO struct {
+	CRM                 string `json:"crm" binding:"required,crm" example:"123456"`
+	UF                  string `json:"uf" binding:"required,uf" example:"PA"`
+	SpecialtyIDs        []uint `json:"specialtyIds" binding:"required,min=1,dive,min=1"`
+	ConsultationMinutes int    `json:"consultationMinutes" binding:"omitempty,min=5,max=240" example:"30"`
+}
+
+type SpecialtyDTO struct {
+	Name string `json:"name" binding:"required,min=2,max=100" example:"Cardiologia"`
+}
@@ -0,0 +1,188 @@
+package doctors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andresidrim/cesupa-hospital/crm"
+	ds "github.com/andresidrim/cesupa-hospital/services/doctors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service ds.DoctorService
+}
+
+func NewHandler(service ds.DoctorService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetProfile busca o perfil profissional de um médico
+// @Summary      Perfil do médico
+// @Description  Retorna o CRM, as especialidades e a duração padrão das consultas do médico
+// @Tags         Médicos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "ID do médico"
+// @Success      200  {object}  handlers.DoctorProfileResponse
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "Doctor profile not found"
+// @Router       /doctors/{id}/profile [get]
+func (h *Handler) GetProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	profile, err := h.service.GetProfile(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Doctor profile not found: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch doctor profile: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile, "registration": profile.Registration()})
+}
+
+// SaveProfile cria ou substitui o perfil profissional de um médico
+// @Summary      Salva perfil do médico
+// @Description  Define o CRM (número + UF, único), as especialidades do catálogo e a duração padrão das consultas (padrão 30 minutos), usada ao agendar sem horário de término
+// @Tags         Médicos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int               true  "ID do médico"
+// @Param        profile  body      DoctorProfileDTO  true  "Dados profissionais"
+// @Success      200      {object}  handlers.DoctorProfileResponse
+// @Failure      400      {object}  handlers.ErrorResponse  "Invalid input"
+// @Failure      404      {object}  handlers.ErrorResponse  "User not found"
+// @Failure      409      {object}  handlers.ErrorResponse  "CRM already registered"
+// @Failure      422      {object}  handlers.ErrorResponse  "User is not a doctor or unknown specialty"
+// @Router       /doctors/{id}/profile [put]
+func (h *Handler) SaveProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload DoctorProfileDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	profile, err := h.service.SaveProfile(id, ds.ProfileInput{
+		CRM:                 payload.CRM,
+		UF:                  payload.UF,
+		SpecialtyIDs:        payload.SpecialtyIDs,
+		ConsultationMinutes: payload.ConsultationMinutes,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, crm.ErrInvalid), errors.Is(err, crm.ErrInvalidUF):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "User not found: " + err.Error()})
+		case errors.Is(err, ds.ErrCRMTaken):
+			c.JSON(http.StatusConflict, gin.H{"message": "CRM already registered: " + err.Error()})
+		case errors.Is(err, ds.ErrNotDoctor), errors.Is(err, ds.ErrUnknownSpecialty):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to save doctor profile: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile, "registration": profile.Registration()})
+}
+
+// GetSpecialties lista o catálogo de especialidades
+// @Summary      Lista especialidades
+// @Description  Retorna o catálogo de especialidades médicas em ordem alfabética
+// @Tags         Médicos
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.SpecialtyListResponse
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to fetch specialties"
+// @Router       /specialties [get]
+func (h *Handler) GetSpecialties(c *gin.Context) {
+	specialties, err := h.service.ListSpecialties()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch specialties: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"specialties": specialties})
+}
+
+// AddSpecialty inclui uma especialidade no catálogo
+// @Summary      Cadastra especialidade
+// @Description  Inclui uma especialidade no catálogo; nomes repetidos (sem diferenciar maiúsculas) são recusados
+// @Tags         Médicos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        specialty  body      SpecialtyDTO  true  "Nome da especialidade"
+// @Success      201        {object}  models.Specialty
+// @Failure      400        {object}  handlers.ErrorResponse  "Invalid input"
+// @Failure      409        {object}  handlers.ErrorResponse  "Specialty already exists"
+// @Router       /specialties [post]
+func (h *Handler) AddSpecialty(c *gin.Context) {
+	var payload SpecialtyDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	specialty, err := h.service.CreateSpecialty(payload.Name)
+	if err != nil {
+		if errors.Is(err, ds.ErrSpecialtyExists) {
+			c.JSON(http.StatusConflict, gin.H{"message": "Specialty already exists: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create specialty: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"specialty": specialty})
+}
+
+// DeleteSpecialty remove uma especialidade do catálogo
+// @Summary      Remove especialidade
+// @Description  Remove uma especialidade que nenhum médico tem
+// @Tags         Médicos
+// @Security     BearerAuth
+// @Param        id   path  int  true  "ID da especialidade"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "Specialty not found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Specialty in use"
+// @Router       /specialties/{id} [delete]
+func (h *Handler) DeleteSpecialty(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	if err := h.service.DeleteSpecialty(id); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Specialty not found: " + err.Error()})
+		case errors.Is(err, ds.ErrSpecialtyInUse):
+			c.JSON(http.StatusConflict, gin.H{"message": "Specialty in use: " + err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete specialty: " + err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
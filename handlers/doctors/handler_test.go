@@ -0,0 +1,152 @@
+package doctors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/crm"
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	"github.com/andresidrim/cesupa-hospital/models"
+	ds "github.com/andresidrim/cesupa-hospital/services/doctors"
+	"github.com/andresidrim/cesupa-hospital/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	if err := validators.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func setupRouter(ms *mocks.MockDoctorService) *gin.Engine {
+	h := NewHandler(ms)
+	r := gin.Default()
+	r.GET("/doctors/:id/profile", h.GetProfile)
+	r.PUT("/doctors/:id/profile", h.SaveProfile)
+	r.GET("/specialties", h.GetSpecialties)
+	r.POST("/specialties", h.AddSpecialty)
+	r.DELETE("/specialties/:id", h.DeleteSpecialty)
+	return r
+}
+
+func TestDoctorProfileHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	valid := `{ "crm": "123.456", "uf": "PA", "specialtyIds": [1, 2], "consultationMinutes": 45 }`
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "get invalid ID", method: http.MethodGet, path: "/doctors/abc/profile", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "get without profile", method: http.MethodGet, path: "/doctors/1/profile", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "Doctor profile not found"},
+		{name: "get success", method: http.MethodGet, path: "/doctors/1/profile", expectedStatus: http.StatusOK, expectedBody: `"registration":"CRM/PA 123456"`},
+		{name: "save invalid crm", method: http.MethodPut, path: "/doctors/1/profile", body: `{ "crm": "12A", "uf": "PA", "specialtyIds": [1] }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "save invalid uf", method: http.MethodPut, path: "/doctors/1/profile", body: `{ "crm": "1234", "uf": "XX", "specialtyIds": [1] }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "save without specialties", method: http.MethodPut, path: "/doctors/1/profile", body: `{ "crm": "1234", "uf": "PA", "specialtyIds": [] }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "save duration too short", method: http.MethodPut, path: "/doctors/1/profile", body: `{ "crm": "1234", "uf": "PA", "specialtyIds": [1], "consultationMinutes": 2 }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "save unknown user", method: http.MethodPut, path: "/doctors/1/profile", body: valid, mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "User not found"},
+		{name: "save not a doctor", method: http.MethodPut, path: "/doctors/1/profile", body: valid, mockErr: ds.ErrNotDoctor, expectedStatus: http.StatusUnprocessableEntity, expectedBody: ds.ErrNotDoctor.Error()},
+		{name: "save unknown specialty", method: http.MethodPut, path: "/doctors/1/profile", body: valid, mockErr: ds.ErrUnknownSpecialty, expectedStatus: http.StatusUnprocessableEntity, expectedBody: ds.ErrUnknownSpecialty.Error()},
+		{name: "save crm taken", method: http.MethodPut, path: "/doctors/1/profile", body: valid, mockErr: ds.ErrCRMTaken, expectedStatus: http.StatusConflict, expectedBody: "CRM already registered"},
+		{name: "save rejected by service", method: http.MethodPut, path: "/doctors/1/profile", body: valid, mockErr: crm.ErrInvalid, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "save success", method: http.MethodPut, path: "/doctors/1/profile", body: valid, expectedStatus: http.StatusOK, expectedBody: `"consultationMinutes":45`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockDoctorService{
+				MockGetProfile: func(userID uint64) (*models.DoctorProfile, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &models.DoctorProfile{UserID: uint(userID), CRM: "123456", CRMUF: "PA"}, nil
+				},
+				MockSaveProfile: func(userID uint64, input ds.ProfileInput) (*models.DoctorProfile, error) {
+					assert.Equal(t, uint64(1), userID)
+					assert.Equal(t, []uint{1, 2}, input.SpecialtyIDs)
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &models.DoctorProfile{UserID: uint(userID), CRM: "123456", CRMUF: input.UF, ConsultationMinutes: input.ConsultationMinutes}, nil
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestSpecialtyHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "list", method: http.MethodGet, path: "/specialties", expectedStatus: http.StatusOK, expectedBody: `"name":"Cardiologia"`},
+		{name: "list error", method: http.MethodGet, path: "/specialties", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to fetch specialties"},
+		{name: "add missing name", method: http.MethodPost, path: "/specialties", body: `{}`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "add duplicate", method: http.MethodPost, path: "/specialties", body: `{ "name": "Cardiologia" }`, mockErr: ds.ErrSpecialtyExists, expectedStatus: http.StatusConflict, expectedBody: "Specialty already exists"},
+		{name: "add success", method: http.MethodPost, path: "/specialties", body: `{ "name": "Cardiologia" }`, expectedStatus: http.StatusCreated, expectedBody: `"name":"Cardiologia"`},
+		{name: "delete invalid ID", method: http.MethodDelete, path: "/specialties/abc", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "delete not found", method: http.MethodDelete, path: "/specialties/1", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "Specialty not found"},
+		{name: "delete in use", method: http.MethodDelete, path: "/specialties/1", mockErr: ds.ErrSpecialtyInUse, expectedStatus: http.StatusConflict, expectedBody: "Specialty in use"},
+		{name: "delete success", method: http.MethodDelete, path: "/specialties/1", expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockDoctorService{
+				MockListSpecialties: func() ([]models.Specialty, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return []models.Specialty{{ID: 1, Name: "Cardiologia"}}, nil
+				},
+				MockCreateSpecialty: func(name string) (*models.Specialty, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &models.Specialty{ID: 1, Name: name}, nil
+				},
+				MockDeleteSpecialty: func(id uint64) error {
+					assert.Equal(t, uint64(1), id)
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
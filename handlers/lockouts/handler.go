@@ -0,0 +1,80 @@
+package lockouts
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	ls "github.com/andresidrim/cesupa-hospital/services/lockouts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service ls.LockoutService
+}
+
+func NewHandler(service ls.LockoutService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetLockouts lista os contadores de falhas de login
+// @Summary      Lista bloqueios de login
+// @Description  Retorna CPFs e IPs com falhas de login recentes; locked=true mostra só os bloqueados agora
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        locked  query     bool  false  "Somente bloqueados"
+// @Success      200     {array}   models.LoginAttempt
+// @Failure      400     {object}  handlers.ErrorResponse  "Invalid input"
+// @Failure      500     {object}  handlers.ErrorResponse  "Failed to fetch lockouts"
+// @Router       /lockouts [get]
+func (h *Handler) GetLockouts(c *gin.Context) {
+	lockedOnly := false
+	if value := c.Query("locked"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+			return
+		}
+		lockedOnly = parsed
+	}
+
+	attempts, err := h.service.GetAll(lockedOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch lockouts: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lockouts": attempts})
+}
+
+// ClearLockout libera um CPF ou IP bloqueado
+// @Summary      Remove bloqueio de login
+// @Description  Apaga o contador de falhas, liberando novas tentativas na hora
+// @Tags         auth
+// @Security     BearerAuth
+// @Param        id   path  int  true  "ID do contador"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "Lockout not found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to clear lockout"
+// @Router       /lockouts/{id} [delete]
+func (h *Handler) ClearLockout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	if err := h.service.Clear(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Lockout not found: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to clear lockout: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
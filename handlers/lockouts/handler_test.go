@@ -0,0 +1,93 @@
+package lockouts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRouter(ms *mocks.MockLockoutService) *gin.Engine {
+	h := NewHandler(ms)
+	r := gin.Default()
+	r.GET("/lockouts", h.GetLockouts)
+	r.DELETE("/lockouts/:id", h.ClearLockout)
+	return r
+}
+
+func TestGetLockoutsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockErr        error
+		wantLocked     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid locked flag", query: "?locked=maybe", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "service error", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to fetch lockouts"},
+		{name: "all", expectedStatus: http.StatusOK, expectedBody: `"identifier":"52998224725"`},
+		{name: "locked only", query: "?locked=true", wantLocked: true, expectedStatus: http.StatusOK, expectedBody: `"kind":"cpf"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockLockoutService{
+				MockGetAll: func(lockedOnly bool) ([]models.LoginAttempt, error) {
+					assert.Equal(t, tt.wantLocked, lockedOnly)
+					return []models.LoginAttempt{{Kind: enums.AttemptCPF, Identifier: "52998224725", Failures: 5}}, tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/lockouts"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestClearLockoutHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		paramID        string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid ID", paramID: "abc", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "not found", paramID: "1", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "Lockout not found"},
+		{name: "service error", paramID: "1", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to clear lockout"},
+		{name: "success", paramID: "1", expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockLockoutService{
+				MockClear: func(id uint64) error {
+					assert.Equal(t, uint64(1), id)
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("DELETE", "/lockouts/"+tt.paramID, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
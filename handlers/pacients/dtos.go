@@ -3,13 +3,14 @@ package pacients
 import (
 	"time"
 
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/enums"
 )
 
 type AddPacientDTO struct {
 	Name        string           `json:"name" binding:"required"`
 	BirthDate   time.Time        `json:"birthDate" binding:"required"`
-	CPF         string           `json:"cpf" binding:"required"`
+	CPF         cpf.CPF          `json:"cpf" binding:"required,cpf" swaggertype:"string" example:"529.982.247-25"`
 	Sex         enums.Sex        `json:"sex" binding:"required"`
 	PhoneNumber string           `json:"phoneNumber" binding:"required"`
 	Address     string           `json:"address" binding:"required"`
@@ -21,7 +22,7 @@ type AddPacientDTO struct {
 type UpdatePacientDTO struct {
 	Name        string           `json:"name" binding:"required"`
 	BirthDate   time.Time        `json:"birthDate" binding:"required"`
-	CPF         string           `json:"cpf" binding:"required"`
+	CPF         cpf.CPF          `json:"cpf" binding:"required,cpf" swaggertype:"string" example:"529.982.247-25"`
 	Sex         enums.Sex        `json:"sex" binding:"required"`
 	PhoneNumber string           `json:"phoneNumber" binding:"required"`
 	Address     string           `json:"address" binding:"required"`
@@ -30,7 +31,65 @@ type UpdatePacientDTO struct {
 	Allergies   *string          `json:"allergies"`
 }
 
+type ListPacientsQueryDTO struct {
+	Name            string          `form:"name"`
+	CPF             cpf.CPF         `form:"cpf" binding:"omitempty,cpf"`
+	Phone           string          `form:"phone"`
+	Sex             enums.Sex       `form:"sex"`
+	BloodType       enums.BloodType `form:"bloodType" binding:"omitempty,oneof=A+ A- B+ B- AB+ AB- O+ O-"`
+	Age             *int            `form:"age" binding:"omitempty,min=0"`
+	MinAge          *int            `form:"minAge" binding:"omitempty,min=0"`
+	MaxAge          *int            `form:"maxAge" binding:"omitempty,min=0"`
+	DoctorID        uint            `form:"doctorId"`
+	IncludeInactive bool            `form:"includeInactive"`
+	Page            int             `form:"page" binding:"omitempty,min=1"`
+	Limit           int             `form:"limit" binding:"omitempty,min=1,max=100"`
+	Sort            string          `form:"sort"`
+}
+
+type InactivatePacientDTO struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type EmergencyAccessDTO struct {
+	Justification string `json:"justification" binding:"required,min=20,max=1000"`
+}
+
+type ListEmergencyAccessesQueryDTO struct {
+	Active bool `form:"active"`
+}
+
 type ScheduleAppointmentDTO struct {
 	DoctorID uint      `json:"doctorId" binding:"required"`
 	Date     time.Time `json:"date" binding:"required"`
 }
+
+type UpdateAppointmentStatusDTO struct {
+	Status enums.AppointmentStatus `json:"status" binding:"required,oneof=scheduled confirmed checked_in in_progress completed no_show"`
+}
+
+type CancelAppointmentDTO struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type RescheduleAppointmentDTO struct {
+	Date time.Time `json:"date" binding:"required"`
+}
+
+type ListAppointmentsQueryDTO struct {
+	DoctorID  uint      `form:"doctorId"`
+	PacientID uint      `form:"pacientId"`
+	From      time.Time `form:"from"`
+	To        time.Time `form:"to"`
+	Status    string    `form:"status"`
+	Page      int       `form:"page" binding:"omitempty,min=1"`
+	Limit     int       `form:"limit" binding:"omitempty,min=1,max=100"`
+	Sort      string    `form:"sort"`
+}
+
+type AgendaQueryDTO struct {
+	Date   string `form:"date"`
+	Status string `form:"status"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
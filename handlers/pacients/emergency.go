@@ -0,0 +1,80 @@
+package pacients
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andresidrim/cesupa-hospital/middlewares"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GrantEmergencyAccess libera um paciente para o médico em uma emergência
+// @Summary      Acesso de emergência (quebra de vidro)
+// @Description  Libera por tempo limitado (EMERGENCY_ACCESS_TTL) o acesso do usuário logado a um paciente com quem ele não tem consulta. A justificativa é gravada, o acesso aparece destacado na auditoria e os admins são avisados.
+// @Tags         Pacientes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int                 true  "ID do paciente"
+// @Param        body  body      EmergencyAccessDTO  true  "Justificativa"
+// @Success      201   {object}  EmergencyAccessResponse
+// @Failure      400   {object}  ErrorResponse  "Invalid input"
+// @Failure      404   {object}  ErrorResponse  "Pacient not found"
+// @Failure      500   {object}  ErrorResponse  "Failed to grant emergency access"
+// @Router       /pacients/{id}/emergency-access [post]
+func (h *Handler) GrantEmergencyAccess(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload EmergencyAccessDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	c.Set(middlewares.AuditEmergencyKey, true)
+
+	access, err := h.service.GrantEmergencyAccess(actorID(c), id, payload.Justification)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Pacient not found: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to grant emergency access: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"access": access})
+}
+
+// ListEmergencyAccesses lista as quebras de vidro para revisão dos admins
+// @Summary      Lista acessos de emergência
+// @Description  Retorna os acessos de emergência concedidos, do mais recente para o mais antigo, com a justificativa de cada um
+// @Tags         Pacientes
+// @Produce      json
+// @Security     BearerAuth
+// @Param        active  query     bool  false  "Só os que ainda valem"
+// @Success      200     {object}  EmergencyAccessListResponse
+// @Failure      400     {object}  ErrorResponse  "Invalid input"
+// @Failure      500     {object}  ErrorResponse  "Failed to list emergency accesses"
+// @Router       /emergency-accesses [get]
+func (h *Handler) ListEmergencyAccesses(c *gin.Context) {
+	var query ListEmergencyAccessesQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	accesses, err := h.service.ListEmergencyAccesses(query.Active)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to list emergency accesses: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accesses": accesses})
+}
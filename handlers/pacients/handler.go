@@ -1,21 +1,37 @@
 package pacients
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/middlewares"
 	"github.com/andresidrim/cesupa-hospital/models"
+	avs "github.com/andresidrim/cesupa-hospital/services/availability"
 	ps "github.com/andresidrim/cesupa-hospital/services/pacients"
+	pms "github.com/andresidrim/cesupa-hospital/services/permissions"
+	us "github.com/andresidrim/cesupa-hospital/services/users"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
-	service ps.PacientService
+	service           ps.PacientService
+	userService       us.UserService
+	permissionService pms.PermissionService
+
+	// location é o fuso da clínica, em que as datas da agenda são lidas
+	location *time.Location
 }
 
-func NewHandler(service ps.PacientService) *Handler {
-	return &Handler{service: service}
+func NewHandler(service ps.PacientService, userService us.UserService, permissionService pms.PermissionService, location *time.Location) *Handler {
+	return &Handler{service: service, userService: userService, permissionService: permissionService, location: location}
 }
 
 // AddPacient cria um novo paciente
@@ -27,6 +43,7 @@ func NewHandler(service ps.PacientService) *Handler {
 // @Param        paciente  body      AddPacientDTO  true  "Dados do paciente"
 // @Success      201       {object}  models.Pacient
 // @Failure      400       {object}  ErrorResponse      "Invalid input"
+// @Failure      409       {object}  ErrorResponse      "Pacient already registered"
 // @Failure      500       {object}  ErrorResponse      "Failed to create pacient"
 // @Router       /pacients [post]
 func (h *Handler) AddPacient(c *gin.Context) {
@@ -43,22 +60,29 @@ func (h *Handler) AddPacient(c *gin.Context) {
 	}
 
 	if err := h.service.Create(&pacient); err != nil {
+		var duplicateErr *ps.DuplicateCPFError
+		if errors.As(err, &duplicateErr) {
+			c.JSON(http.StatusConflict, gin.H{"message": "Pacient already registered: " + err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create pacient" + err.Error()})
 		return
 	}
 
+	c.Set(middlewares.AuditResourceIDKey, pacient.ID)
 	c.JSON(http.StatusCreated, gin.H{"pacient": pacient})
 }
 
 // GetPacient busca um paciente pelo ID
 // @Summary      Busca paciente
-// @Description  Retorna os dados de um paciente pelo seu ID
+// @Description  Retorna os dados de um paciente pelo seu ID. Sem a permissão pacient:read:all, só pacientes com consulta com o usuário ou com acesso de emergência válido.
 // @Tags         Pacientes
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "ID do paciente"
 // @Success      200  {object}  models.Pacient
 // @Failure      400  {object}  ErrorResponse        "Invalid ID"
+// @Failure      403  {object}  ErrorResponse        "No access to this pacient"
 // @Failure      404  {object}  ErrorResponse        "Pacient not found"
 // @Router       /pacients/{id} [get]
 func (h *Handler) GetPacient(c *gin.Context) {
@@ -68,6 +92,10 @@ func (h *Handler) GetPacient(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizePacient(c, id) {
+		return
+	}
+
 	pacient, err := h.service.Get(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Pacient not found: " + err.Error()})
@@ -77,28 +105,82 @@ func (h *Handler) GetPacient(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"pacient": pacient})
 }
 
-// GetAllPacients lista pacientes com filtros opcionais
+// GetAllPacients lista pacientes com filtros e paginação
 // @Summary      Lista pacientes
-// @Description  Retorna todos os pacientes, podendo filtrar por nome e/ou idade
+// @Description  Retorna os pacientes ativos, paginados, podendo filtrar por nome, CPF, telefone, sexo, tipo sanguíneo, idade e médico, e incluir os inativos. Sem a permissão pacient:read:all, só os pacientes com consulta com o usuário ou com acesso de emergência válido.
 // @Tags         Pacientes
 // @Accept       json
 // @Produce      json
-// @Param        name  query     string  false  "Filtra pelo nome (substring)"
-// @Param        age   query     int     false  "Filtra pela idade exata"
-// @Success      200   {array}   models.Pacient
-// @Failure      404   {object}  ErrorResponse        "No pacient was found"
+// @Param        name             query     string  false  "Filtra pelo nome (substring)"
+// @Param        cpf              query     string  false  "Filtra pelo CPF (com ou sem máscara)"
+// @Param        phone            query     string  false  "Filtra pelo telefone (substring)"
+// @Param        sex              query     string  false  "Filtra pelo sexo"
+// @Param        bloodType        query     string  false  "Filtra pelo tipo sanguíneo"
+// @Param        age              query     int     false  "Filtra pela idade exata"
+// @Param        minAge           query     int     false  "Idade mínima"
+// @Param        maxAge           query     int     false  "Idade máxima"
+// @Param        doctorId         query     int     false  "Pacientes com consulta com o médico"
+// @Param        includeInactive  query     bool    false  "Inclui pacientes inativos"
+// @Param        page             query     int     false  "Página (padrão 1)"
+// @Param        limit            query     int     false  "Itens por página (padrão 20, máximo 100)"
+// @Param        sort             query     string  false  "name, birthDate ou createdAt; prefixe com - para ordem decrescente"
+// @Success      200              {object}  PacientListResponse
+// @Failure      400              {object}  ErrorResponse        "Invalid input"
+// @Failure      404              {object}  ErrorResponse        "No pacient was found"
 // @Router       /pacients [get]
 func (h *Handler) GetAllPacients(c *gin.Context) {
-	name := c.Query("name")
-	ageStr := c.Query("age")
+	var query ListPacientsQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+	if query.MinAge != nil && query.MaxAge != nil && *query.MaxAge < *query.MinAge {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: maxAge must not be lower than minAge"})
+		return
+	}
 
-	pacients, err := h.service.GetAll(name, ageStr)
+	filter := ps.PacientFilter{
+		Name:            query.Name,
+		CPF:             query.CPF,
+		Phone:           query.Phone,
+		Sex:             query.Sex,
+		BloodType:       query.BloodType,
+		Age:             query.Age,
+		MinAge:          query.MinAge,
+		MaxAge:          query.MaxAge,
+		DoctorID:        query.DoctorID,
+		IncludeInactive: query.IncludeInactive,
+		Page:            query.Page,
+		Limit:           query.Limit,
+		Sort:            query.Sort,
+	}
+	if h.restricted(c) {
+		filter.VisibleTo = actorID(c)
+	}
+
+	pacients, total, err := h.service.GetAll(filter)
 	if err != nil {
+		if errors.Is(err, ps.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"message": "No pacient was found: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"pacients": pacients})
+	ids := make([]uint, len(pacients))
+	for i, pacient := range pacients {
+		ids[i] = pacient.ID
+	}
+	setAuditSubjects(c, ids...)
+
+	page, limit := pageOrDefault(filter.Page, filter.Limit)
+	c.JSON(http.StatusOK, gin.H{
+		"pacients": pacients,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
 }
 
 // UpdatePacient altera dados de um paciente
@@ -112,6 +194,7 @@ func (h *Handler) GetAllPacients(c *gin.Context) {
 // @Success      200       {object} models.Pacient
 // @Failure      400       {object} ErrorResponse        "Invalid ID or Input"
 // @Failure      404       {object} ErrorResponse        "Pacient not found"
+// @Failure      409       {object} ErrorResponse        "CPF already registered"
 // @Failure      500       {object} ErrorResponse        "Failed to update pacient"
 // @Router       /pacients/{id} [put]
 func (h *Handler) UpdatePacient(c *gin.Context) {
@@ -139,6 +222,11 @@ func (h *Handler) UpdatePacient(c *gin.Context) {
 	}
 
 	if err := h.service.Update(id, &updatedPacient); err != nil {
+		var duplicateErr *ps.DuplicateCPFError
+		if errors.As(err, &duplicateErr) {
+			c.JSON(http.StatusConflict, gin.H{"message": "Pacient already registered: " + err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update pacient: " + err.Error()})
 		return
 	}
@@ -146,17 +234,19 @@ func (h *Handler) UpdatePacient(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"pacient": updatedPacient})
 }
 
-// DeletePacient remove logicamente um paciente
-// @Summary      Exclui paciente
-// @Description  Inativa (ou exclui) um paciente pelo ID
+// DeletePacient inativa um paciente
+// @Summary      Inativa paciente
+// @Description  Marca o paciente como inativo, registrando motivo e data. O cadastro e o CPF continuam reservados e podem ser reativados.
 // @Tags         Pacientes
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "ID do paciente"
-// @Success      200  {object}  models.Pacient
-// @Failure      400  {object}  ErrorResponse        "Invalid ID"
-// @Failure      404  {object}  ErrorResponse        "Pacient not found"
-// @Failure      500  {object}  ErrorResponse        "Failed to delete pacient"
+// @Param        id      path      int                    true   "ID do paciente"
+// @Param        motivo  body      InactivatePacientDTO   false  "Motivo da inativação"
+// @Success      200     {object}  models.Pacient
+// @Failure      400     {object}  ErrorResponse        "Invalid ID or Input"
+// @Failure      404     {object}  ErrorResponse        "Pacient not found"
+// @Failure      409     {object}  ErrorResponse        "Pacient already inactive"
+// @Failure      500     {object}  ErrorResponse        "Failed to inactivate pacient"
 // @Router       /pacients/{id} [delete]
 func (h *Handler) DeletePacient(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -165,31 +255,77 @@ func (h *Handler) DeletePacient(c *gin.Context) {
 		return
 	}
 
-	deletedPacient, err := h.service.Get(id)
+	// o corpo é opcional: DELETE sem payload continua funcionando
+	var payload InactivatePacientDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	pacient, err := h.service.Inactivate(id, payload.Reason)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Pacient not found: " + err.Error()})
+		respondPacientStatusError(c, err, "Failed to inactivate pacient: ")
 		return
 	}
 
-	if err := h.service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete pacient: " + err.Error()})
+	c.JSON(http.StatusOK, gin.H{"pacient": pacient})
+}
+
+// ReactivatePacient reativa um paciente inativo
+// @Summary      Reativa paciente
+// @Description  Volta um paciente inativo para ativo, permitindo novos agendamentos
+// @Tags         Pacientes
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID do paciente"
+// @Success      200  {object}  models.Pacient
+// @Failure      400  {object}  ErrorResponse        "Invalid ID"
+// @Failure      404  {object}  ErrorResponse        "Pacient not found"
+// @Failure      409  {object}  ErrorResponse        "Pacient already active"
+// @Failure      500  {object}  ErrorResponse        "Failed to reactivate pacient"
+// @Router       /pacients/{id}/reactivate [post]
+func (h *Handler) ReactivatePacient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"pacient": deletedPacient})
+	pacient, err := h.service.Reactivate(id)
+	if err != nil {
+		respondPacientStatusError(c, err, "Failed to reactivate pacient: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pacient": pacient})
+}
+
+func respondPacientStatusError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Pacient not found: " + err.Error()})
+	case errors.Is(err, ps.ErrPacientInactive), errors.Is(err, ps.ErrPacientActive):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback + err.Error()})
+	}
 }
 
 // ScheduleAppointment agenda uma consulta para um paciente
 // @Summary      Agenda consulta
-// @Description  Cria uma nova consulta para o paciente informado
+// @Description  Cria uma nova consulta para o paciente com o médico informado
 // @Tags         Pacientes
 // @Accept       json
 // @Produce      json
 // @Param        id          path      int                  true  "ID do paciente"
 // @Param        appointment  body     ScheduleAppointmentDTO true  "Dados da consulta"
-// @Success      201         {object}  models.Appointment
+// @Success      201         {object}  AppointmentResponse
 // @Failure      400         {object}  ErrorResponse              "Invalid ID or Input"
 // @Failure      404         {object}  ErrorResponse              "Pacient not found"
+// @Failure      409         {object}  ConflictResponse           "Appointment conflict"
+// @Failure      422         {object}  ErrorResponse              "Invalid doctor, inactive pacient, past date or doctor unavailable"
 // @Failure      500         {object}  ErrorResponse              "Failed to create appointment"
 // @Router       /pacients/{id}/appointments [post]
 func (h *Handler) ScheduleAppointment(c *gin.Context) {
@@ -210,18 +346,436 @@ func (h *Handler) ScheduleAppointment(c *gin.Context) {
 		return
 	}
 
-	var appointment models.Appointment
-	if err := copier.Copy(&appointment, &payload); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to copy data: " + err.Error()})
+	doctor, err := h.userService.Get(uint64(payload.DoctorID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Invalid doctor: " + err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch doctor: " + err.Error()})
+		return
+	}
+	if doctor.Role != enums.Doctor {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Invalid doctor: user is not a doctor"})
+		return
+	}
+	if !doctor.IsActive() {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Invalid doctor: doctor is inactive"})
 		return
 	}
 
-	appointment.PacientID = uint(id)
+	appointment := models.Appointment{
+		PacientID: uint(id),
+		UserID:    doctor.ID,
+		Date:      payload.Date,
+	}
 
 	if err := h.service.ScheduleAppointment(&appointment); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create appointment: " + err.Error()})
+		respondAppointmentError(c, err, "Failed to create appointment: ")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"appointment": NewAppointmentResponse(appointment)})
+}
+
+// UpdateAppointmentStatus avança o status de uma consulta
+// @Summary      Atualiza status da consulta
+// @Description  Muda o status seguindo o fluxo scheduled → confirmed → checked_in → in_progress → completed (ou no_show). Para cancelar use /appointments/{id}/cancel. Sem a permissão pacient:read:all, consultas de pacientes sem acesso respondem 404.
+// @Tags         Consultas
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                         true  "ID da consulta"
+// @Param        status  body      UpdateAppointmentStatusDTO  true  "Novo status"
+// @Success      200     {object}  models.Appointment
+// @Failure      400     {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404     {object}  ErrorResponse  "Appointment not found"
+// @Failure      422     {object}  ErrorResponse  "Invalid status transition"
+// @Router       /appointments/{id}/status [patch]
+func (h *Handler) UpdateAppointmentStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload UpdateAppointmentStatusDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if !h.authorizeAppointment(c, id) {
+		return
+	}
+
+	appointment, err := h.service.UpdateAppointmentStatus(id, payload.Status, actorID(c))
+	if err != nil {
+		respondAppointmentError(c, err, "Failed to update appointment: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointment": appointment})
+}
+
+// CancelAppointment cancela uma consulta
+// @Summary      Cancela consulta
+// @Description  Cancela a consulta registrando o motivo no histórico e libera o horário. Sem a permissão pacient:read:all, consultas de pacientes sem acesso respondem 404.
+// @Tags         Consultas
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                   true  "ID da consulta"
+// @Param        cancel  body      CancelAppointmentDTO  true  "Motivo do cancelamento"
+// @Success      200     {object}  models.Appointment
+// @Failure      400     {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404     {object}  ErrorResponse  "Appointment not found"
+// @Failure      422     {object}  ErrorResponse  "Invalid status transition"
+// @Router       /appointments/{id}/cancel [post]
+func (h *Handler) CancelAppointment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload CancelAppointmentDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if !h.authorizeAppointment(c, id) {
+		return
+	}
+
+	appointment, err := h.service.CancelAppointment(id, payload.Reason, actorID(c))
+	if err != nil {
+		respondAppointmentError(c, err, "Failed to cancel appointment: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointment": appointment})
+}
+
+// RescheduleAppointment remarca uma consulta
+// @Summary      Reagenda consulta
+// @Description  Move a consulta para outro horário mantendo a duração; o horário anterior fica no histórico. Sem a permissão pacient:read:all, consultas de pacientes sem acesso respondem 404.
+// @Tags         Consultas
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                       true  "ID da consulta"
+// @Param        reschedule  body      RescheduleAppointmentDTO  true  "Novo horário"
+// @Success      200         {object}  models.Appointment
+// @Failure      400         {object}  ErrorResponse     "Invalid ID or Input"
+// @Failure      404         {object}  ErrorResponse     "Appointment not found"
+// @Failure      409         {object}  ConflictResponse  "Appointment conflict"
+// @Failure      422         {object}  ErrorResponse     "Doctor unavailable or invalid status"
+// @Router       /appointments/{id}/reschedule [post]
+func (h *Handler) RescheduleAppointment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload RescheduleAppointmentDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if !h.authorizeAppointment(c, id) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"appointment": appointment})
+	appointment, err := h.service.RescheduleAppointment(id, payload.Date, actorID(c))
+	if err != nil {
+		respondAppointmentError(c, err, "Failed to reschedule appointment: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointment": appointment})
+}
+
+// GetAppointment busca uma consulta pelo ID
+// @Summary      Busca consulta
+// @Description  Retorna a consulta com paciente, médico e histórico de mudanças. Sem a permissão pacient:read:all, só se o usuário tiver acesso ao paciente.
+// @Tags         Consultas
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID da consulta"
+// @Success      200  {object}  models.Appointment
+// @Failure      400  {object}  ErrorResponse  "Invalid ID"
+// @Failure      403  {object}  ErrorResponse  "No access to this pacient"
+// @Failure      404  {object}  ErrorResponse  "Appointment not found"
+// @Router       /appointments/{id} [get]
+func (h *Handler) GetAppointment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	appointment, err := h.service.GetAppointment(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Appointment not found: " + err.Error()})
+		return
+	}
+	if !h.authorizePacient(c, uint64(appointment.PacientID)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointment": appointment})
+}
+
+// ListAppointments lista consultas com filtros e paginação
+// @Summary      Lista consultas
+// @Description  Retorna as consultas filtradas por médico, paciente, período e status, paginadas. Sem a permissão pacient:read:all, só as consultas de pacientes com consulta com o usuário ou com acesso de emergência válido.
+// @Tags         Consultas
+// @Accept       json
+// @Produce      json
+// @Param        doctorId   query     int     false  "ID do médico"
+// @Param        pacientId  query     int     false  "ID do paciente"
+// @Param        from       query     string  false  "Consultas a partir de (RFC3339)"
+// @Param        to         query     string  false  "Consultas antes de (RFC3339)"
+// @Param        status     query     string  false  "Status separados por vírgula"
+// @Param        page       query     int     false  "Página (padrão 1)"
+// @Param        limit      query     int     false  "Itens por página (padrão 20, máximo 100)"
+// @Param        sort       query     string  false  "date, createdAt ou status; prefixe com - para ordem decrescente"
+// @Success      200        {object}  AppointmentListResponse
+// @Failure      400        {object}  ErrorResponse  "Invalid input"
+// @Failure      403        {object}  ErrorResponse  "No access to this pacient"
+// @Failure      500        {object}  ErrorResponse  "Failed to list appointments"
+// @Router       /appointments [get]
+func (h *Handler) ListAppointments(c *gin.Context) {
+	var query ListAppointmentsQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	statuses, err := parseStatuses(query.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	filter := ps.AppointmentFilter{
+		DoctorID:  query.DoctorID,
+		PacientID: query.PacientID,
+		From:      query.From,
+		To:        query.To,
+		Statuses:  statuses,
+		Page:      query.Page,
+		Limit:     query.Limit,
+		Sort:      query.Sort,
+	}
+	if h.restricted(c) {
+		if query.PacientID != 0 && !h.authorizePacient(c, uint64(query.PacientID)) {
+			return
+		}
+		filter.VisibleTo = actorID(c)
+	}
+
+	h.respondAppointmentList(c, filter)
+}
+
+// GetMyAgenda lista as consultas do médico autenticado em um dia
+// @Summary      Minha agenda
+// @Description  Retorna as consultas do médico logado no dia informado (padrão: hoje), em ordem de horário
+// @Tags         Consultas
+// @Accept       json
+// @Produce      json
+// @Param        date    query     string  false  "Dia no formato AAAA-MM-DD"
+// @Param        status  query     string  false  "Status separados por vírgula"
+// @Param        page    query     int     false  "Página (padrão 1)"
+// @Param        limit   query     int     false  "Itens por página (padrão 20, máximo 100)"
+// @Success      200     {object}  AppointmentListResponse
+// @Failure      400     {object}  ErrorResponse  "Invalid input"
+// @Failure      401     {object}  ErrorResponse  "Missing user"
+// @Router       /me/agenda [get]
+func (h *Handler) GetMyAgenda(c *gin.Context) {
+	doctorID := actorID(c)
+	if doctorID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Missing authenticated user"})
+		return
+	}
+
+	var query AgendaQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	day := time.Now().In(h.location)
+	if query.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", query.Date, h.location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+			return
+		}
+		day = parsed
+	}
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, h.location)
+
+	statuses, err := parseStatuses(query.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	h.respondAppointmentList(c, ps.AppointmentFilter{
+		DoctorID: doctorID,
+		From:     from,
+		To:       from.AddDate(0, 0, 1),
+		Statuses: statuses,
+		Page:     query.Page,
+		Limit:    query.Limit,
+		Sort:     "date",
+	})
+}
+
+func (h *Handler) respondAppointmentList(c *gin.Context, filter ps.AppointmentFilter) {
+	appointments, total, err := h.service.ListAppointments(filter)
+	if err != nil {
+		if errors.Is(err, ps.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to list appointments: " + err.Error()})
+		return
+	}
+
+	ids := make([]uint, len(appointments))
+	for i, appointment := range appointments {
+		ids[i] = appointment.PacientID
+	}
+	setAuditSubjects(c, ids...)
+
+	page, limit := pageOrDefault(filter.Page, filter.Limit)
+	c.JSON(http.StatusOK, gin.H{
+		"appointments": appointments,
+		"total":        total,
+		"page":         page,
+		"limit":        limit,
+	})
+}
+
+// pageOrDefault devolve a página e o limite efetivos para a resposta
+func pageOrDefault(page, limit int) (int, int) {
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = ps.DefaultPageSize
+	}
+	return page, limit
+}
+
+// parseStatuses converte "scheduled,confirmed" em status validados
+func parseStatuses(raw string) ([]enums.AppointmentStatus, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var statuses []enums.AppointmentStatus
+	for _, value := range strings.Split(raw, ",") {
+		status := enums.AppointmentStatus(strings.TrimSpace(value))
+		if !slices.Contains(enums.AppointmentStatuses, status) {
+			return nil, fmt.Errorf("unknown status %q", status)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// respondAppointmentError traduz os erros de agendamento do service para HTTP
+func respondAppointmentError(c *gin.Context, err error, fallback string) {
+	var conflictErr *ps.AppointmentConflictError
+	var transitionErr *ps.InvalidTransitionError
+
+	switch {
+	case errors.As(err, &conflictErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"message":     "Appointment conflict: " + err.Error(),
+			"appointment": NewAppointmentResponse(conflictErr.Appointment),
+		})
+	case errors.Is(err, ps.ErrPacientInactive):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Pacient inactive: " + err.Error()})
+	case errors.Is(err, ps.ErrAppointmentInPast):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Invalid date: " + err.Error()})
+	case avs.IsUnavailable(err):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Doctor unavailable: " + err.Error()})
+	case errors.As(err, &transitionErr), errors.Is(err, ps.ErrCancelRequiresReason):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Invalid status transition: " + err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Appointment not found: " + err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback + err.Error()})
+	}
+}
+
+// restricted indica se o usuário só pode ver os próprios pacientes
+func (h *Handler) restricted(c *gin.Context) bool {
+	role, _ := c.Get("role")
+	userRole, _ := role.(enums.Role)
+	return !h.permissionService.Allowed(userRole, enums.PermPacientReadAll)
+}
+
+// authorizePacient confere se quem só vê os próprios pacientes pode ver este,
+// respondendo 403 quando não pode. O paciente entra na auditoria mesmo se o
+// acesso for negado, e os acessos de emergência a marcam.
+func (h *Handler) authorizePacient(c *gin.Context, pacientID uint64) bool {
+	setAuditSubjects(c, uint(pacientID))
+	return h.checkPacientAccess(c, pacientID, http.StatusForbidden, "No access to this pacient: ")
+}
+
+// authorizeAppointment carrega a consulta antes de alterá-la e confere o acesso
+// ao paciente dela. Para quem não vê o paciente, a consulta não existe (404).
+func (h *Handler) authorizeAppointment(c *gin.Context, id uint64) bool {
+	appointment, err := h.service.GetAppointment(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Appointment not found: " + err.Error()})
+		return false
+	}
+
+	setAuditSubjects(c, appointment.PacientID)
+	return h.checkPacientAccess(c, uint64(appointment.PacientID), http.StatusNotFound, "Appointment not found: ")
+}
+
+// checkPacientAccess responde deniedStatus se o usuário, restrito aos próprios
+// pacientes, não tiver consulta nem acesso de emergência com o paciente
+func (h *Handler) checkPacientAccess(c *gin.Context, pacientID uint64, deniedStatus int, deniedMessage string) bool {
+	if !h.restricted(c) {
+		return true
+	}
+
+	emergency, err := h.service.PacientAccess(actorID(c), pacientID)
+	if err != nil {
+		if errors.Is(err, ps.ErrNoPacientAccess) {
+			c.JSON(deniedStatus, gin.H{"message": deniedMessage + err.Error()})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check access: " + err.Error()})
+		return false
+	}
+	if emergency {
+		c.Set(middlewares.AuditEmergencyKey, true)
+	}
+	return true
+}
+
+// setAuditSubjects informa à auditoria os pacientes cujos dados a resposta
+// traz, quando eles não são o :id da rota
+func setAuditSubjects(c *gin.Context, pacientIDs ...uint) {
+	c.Set(middlewares.AuditSubjectsKey, pacientIDs)
+}
+
+// actorID devolve o usuário autenticado definido pelo JWTAuthMiddleware
+func actorID(c *gin.Context) uint {
+	if v, ok := c.Get("userID"); ok {
+		if id, ok := v.(uint); ok {
+			return id
+		}
+	}
+	return 0
 }
@@ -4,19 +4,35 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/andresidrim/cesupa-hospital/config"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/middlewares"
 	"github.com/andresidrim/cesupa-hospital/mocks"
 	"github.com/andresidrim/cesupa-hospital/models"
+	avs "github.com/andresidrim/cesupa-hospital/services/availability"
+	ps "github.com/andresidrim/cesupa-hospital/services/pacients"
 	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/andresidrim/cesupa-hospital/validators"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
 
+// testLocation é o fuso padrão da clínica
+var testLocation = config.Default().Location
+
+func TestMain(m *testing.M) {
+	if err := validators.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
 func setupTestRouter(h *Handler) *gin.Engine {
 	router := gin.Default()
 	router.GET("/pacients", h.GetAllPacients)
@@ -38,7 +54,7 @@ func TestAddPacient(t *testing.T) {
 			body: `{
 				"name": "John Doe",
 				"birthDate": "2000-01-01T00:00:00Z",
-				"cpf": "12345678900",
+				"cpf": "52998224725",
 				"sex": "male",
 				"phoneNumber": "+123456789",
 				"address": "123 Street"
@@ -63,12 +79,25 @@ func TestAddPacient(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "Invalid input",
 		},
+		{
+			name: "invalid cpf check digit",
+			body: `{
+				"name": "John Doe",
+				"birthDate": "2000-01-01T00:00:00Z",
+				"cpf": "529.982.247-20",
+				"sex": "male",
+				"phoneNumber": "+123456789",
+				"address": "123 Street"
+			}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "'cpf' tag",
+		},
 		{
 			name: "service error",
 			body: `{
 				"name": "John Doe",
 				"birthDate": "2000-01-01T00:00:00Z",
-				"cpf": "12345678900",
+				"cpf": "52998224725",
 				"sex": "male",
 				"phoneNumber": "+123456789",
 				"address": "123 Street"
@@ -88,7 +117,7 @@ func TestAddPacient(t *testing.T) {
 				},
 			}
 
-			handler := NewHandler(mockService)
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
 			router := gin.Default()
 			router.POST("/pacients", handler.AddPacient)
 
@@ -149,7 +178,7 @@ func TestGetPacient(t *testing.T) {
 				},
 			}
 
-			handler := NewHandler(mockService)
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
 			router := gin.Default()
 			router.GET("/pacients/:id", handler.GetPacient)
 
@@ -167,9 +196,12 @@ func TestGetPacient(t *testing.T) {
 func TestGetAllPacients(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	age, minAge, maxAge := 30, 18, 65
+
 	tests := []struct {
 		name       string
 		query      string
+		wantFilter *ps.PacientFilter
 		mockResult []models.Pacient
 		mockError  error
 		wantStatus int
@@ -178,6 +210,7 @@ func TestGetAllPacients(t *testing.T) {
 		{
 			name:       "found pacient",
 			query:      "?name=John&age=30",
+			wantFilter: &ps.PacientFilter{Name: "John", Age: &age},
 			mockResult: []models.Pacient{{Name: "John Doe"}},
 			wantStatus: http.StatusOK,
 			wantBody:   "John Doe",
@@ -187,7 +220,53 @@ func TestGetAllPacients(t *testing.T) {
 			query:      "?name=Unknown",
 			mockResult: []models.Pacient{},
 			wantStatus: http.StatusOK,
-			wantBody:   "[]",
+			wantBody:   `"pacients":[]`,
+		},
+		{
+			name:       "include inactive",
+			query:      "?includeInactive=true",
+			wantFilter: &ps.PacientFilter{IncludeInactive: true},
+			mockResult: []models.Pacient{{Name: "John Doe", Status: enums.PacientInactive}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"status":"inactive"`,
+		},
+		{
+			name:  "all filters",
+			query: "?cpf=529.982.247-25&phone=9199&sex=female&bloodType=O%2B&minAge=18&maxAge=65&doctorId=4&page=2&limit=10&sort=-birthDate",
+			wantFilter: &ps.PacientFilter{
+				CPF:       "529.982.247-25",
+				Phone:     "9199",
+				Sex:       "female",
+				BloodType: enums.OPositive,
+				MinAge:    &minAge,
+				MaxAge:    &maxAge,
+				DoctorID:  4,
+				Page:      2,
+				Limit:     10,
+				Sort:      "-birthDate",
+			},
+			mockResult: []models.Pacient{},
+			wantStatus: http.StatusOK,
+			wantBody:   `"page":2`,
+		},
+		{
+			name:       "invalid cpf",
+			query:      "?cpf=123",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Invalid input",
+		},
+		{
+			name:       "invalid age range",
+			query:      "?minAge=40&maxAge=20",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "maxAge",
+		},
+		{
+			name:       "invalid sort",
+			query:      "?sort=cpf",
+			mockError:  ps.ErrInvalidSort,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid sort",
 		},
 		{
 			name:       "internal error",
@@ -201,13 +280,15 @@ func TestGetAllPacients(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &mocks.MockPacientService{
-				MockGetAll: func(name, ageStr string) ([]models.Pacient, error) {
-					t.Logf("MockGetAll called with name: %s, age: %s", name, ageStr)
-					return tt.mockResult, tt.mockError
+				MockGetAll: func(filter ps.PacientFilter) ([]models.Pacient, int64, error) {
+					if tt.wantFilter != nil {
+						assert.Equal(t, *tt.wantFilter, filter)
+					}
+					return tt.mockResult, int64(len(tt.mockResult)), tt.mockError
 				},
 			}
 
-			handler := NewHandler(mockService)
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
 			router := setupTestRouter(handler)
 
 			req, _ := http.NewRequest(http.MethodGet, "/pacients"+tt.query, nil)
@@ -245,7 +326,7 @@ func TestUpdatePacient(t *testing.T) {
 		{
 			name:           "pacient not found",
 			paramID:        "1",
-			body:           `{ "name": "John", "birthDate": "2000-01-01T00:00:00Z", "cpf":"123", "sex":"male", "phoneNumber":"123", "address":"street" }`,
+			body:           `{ "name": "John", "birthDate": "2000-01-01T00:00:00Z", "cpf":"529.982.247-25", "sex":"male", "phoneNumber":"123", "address":"street" }`,
 			mockGetErr:     assert.AnError,
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   "Pacient not found",
@@ -260,7 +341,7 @@ func TestUpdatePacient(t *testing.T) {
 		{
 			name:           "update error",
 			paramID:        "1",
-			body:           `{ "name": "John", "birthDate": "2000-01-01T00:00:00Z", "cpf":"123", "sex":"male", "phoneNumber":"123", "address":"street" }`,
+			body:           `{ "name": "John", "birthDate": "2000-01-01T00:00:00Z", "cpf":"529.982.247-25", "sex":"male", "phoneNumber":"123", "address":"street" }`,
 			mockGetErr:     nil,
 			mockUpdateErr:  assert.AnError,
 			expectedStatus: http.StatusInternalServerError,
@@ -269,7 +350,7 @@ func TestUpdatePacient(t *testing.T) {
 		{
 			name:           "successful update",
 			paramID:        "1",
-			body:           `{ "name": "John", "birthDate": "2000-01-01T00:00:00Z", "cpf":"123", "sex":"male", "phoneNumber":"123", "address":"street" }`,
+			body:           `{ "name": "John", "birthDate": "2000-01-01T00:00:00Z", "cpf":"529.982.247-25", "sex":"male", "phoneNumber":"123", "address":"street" }`,
 			mockGetErr:     nil,
 			mockUpdateErr:  nil,
 			expectedStatus: http.StatusOK,
@@ -288,7 +369,7 @@ func TestUpdatePacient(t *testing.T) {
 				},
 			}
 
-			handler := NewHandler(mockService)
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
 			router := gin.Default()
 			router.PUT("/pacients/:id", handler.UpdatePacient)
 
@@ -312,12 +393,13 @@ func TestDeletePacient(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
-		name           string
-		paramID        string
-		mockGetErr     error
-		mockDeleteErr  error
-		expectedStatus int
-		expectedBody   string
+		name            string
+		paramID         string
+		body            string
+		mockInactiveErr error
+		expectedReason  string
+		expectedStatus  int
+		expectedBody    string
 	}{
 		{
 			name:           "invalid ID",
@@ -326,61 +408,112 @@ func TestDeletePacient(t *testing.T) {
 			expectedBody:   "Invalid ID",
 		},
 		{
-			name:           "pacient not found",
+			name:            "pacient not found",
+			paramID:         "1",
+			mockInactiveErr: gorm.ErrRecordNotFound,
+			expectedStatus:  http.StatusNotFound,
+			expectedBody:    "Pacient not found",
+		},
+		{
+			name:            "already inactive",
+			paramID:         "1",
+			mockInactiveErr: ps.ErrPacientInactive,
+			expectedStatus:  http.StatusConflict,
+			expectedBody:    "pacient is inactive",
+		},
+		{
+			name:            "inactivate failure",
+			paramID:         "1",
+			mockInactiveErr: assert.AnError,
+			expectedStatus:  http.StatusInternalServerError,
+			expectedBody:    "Failed to inactivate pacient",
+		},
+		{
+			name:           "invalid body",
 			paramID:        "1",
-			mockGetErr:     gorm.ErrRecordNotFound,
-			expectedStatus: http.StatusNotFound,
-			expectedBody:   "Pacient not found",
+			body:           `{ "reason": 10 }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
 		},
 		{
-			name:           "delete failure",
+			name:           "successful inactivation without reason",
 			paramID:        "1",
-			mockGetErr:     nil,
-			mockDeleteErr:  assert.AnError,
-			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "Failed to delete pacient",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"status":"inactive"`,
 		},
 		{
-			name:           "successful delete",
+			name:           "successful inactivation with reason",
 			paramID:        "1",
-			mockGetErr:     nil,
-			mockDeleteErr:  nil,
+			body:           `{ "reason": "Óbito" }`,
+			expectedReason: "Óbito",
 			expectedStatus: http.StatusOK,
-			expectedBody:   "pacient",
+			expectedBody:   `"status":"inactive"`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &mocks.MockPacientService{
-				MockGet: func(id uint64) (*models.Pacient, error) {
-					if tt.mockGetErr != nil {
-						return nil, tt.mockGetErr
+				MockInactivate: func(id uint64, reason string) (*models.Pacient, error) {
+					assert.Equal(t, tt.expectedReason, reason)
+					if tt.mockInactiveErr != nil {
+						return nil, tt.mockInactiveErr
 					}
-					return &models.Pacient{
-						Model: gorm.Model{
-							ID: uint(id),
-						},
-						Name: "John Doe",
-					}, nil
-				},
-				MockDelete: func(id uint64) error {
-					return tt.mockDeleteErr
+					return &models.Pacient{Model: gorm.Model{ID: uint(id)}, Name: "John Doe", Status: enums.PacientInactive}, nil
 				},
 			}
 
-			handler := NewHandler(mockService)
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
 			router := gin.Default()
 			router.DELETE("/pacients/:id", handler.DeletePacient)
 
-			req, _ := http.NewRequest(http.MethodDelete, "/pacients/"+tt.paramID, nil)
+			req, _ := http.NewRequest(http.MethodDelete, "/pacients/"+tt.paramID, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
 			resp := httptest.NewRecorder()
 
 			router.ServeHTTP(resp, req)
 
-			t.Logf("Response: %s", resp.Body.String())
-			t.Log("END OF SUBTEST")
-			t.Log(strings.Repeat("-", 50))
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestReactivatePacient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		paramID        string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid ID", paramID: "abc", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "not found", paramID: "1", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "Pacient not found"},
+		{name: "already active", paramID: "1", mockErr: ps.ErrPacientActive, expectedStatus: http.StatusConflict, expectedBody: "already active"},
+		{name: "success", paramID: "1", expectedStatus: http.StatusOK, expectedBody: `"status":"active"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPacientService{
+				MockReactivate: func(id uint64) (*models.Pacient, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &models.Pacient{Model: gorm.Model{ID: uint(id)}, Status: enums.PacientActive}, nil
+				},
+			}
+
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
+			router := gin.Default()
+			router.POST("/pacients/:id/reactivate", handler.ReactivatePacient)
+
+			req, _ := http.NewRequest(http.MethodPost, "/pacients/"+tt.paramID+"/reactivate", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
 
 			assert.Equal(t, tt.expectedStatus, resp.Code)
 			assert.Contains(t, resp.Body.String(), tt.expectedBody)
@@ -396,6 +529,7 @@ func TestScheduleAppointment(t *testing.T) {
 		paramID        string
 		body           string
 		mockGetErr     error
+		doctor         *models.User
 		mockCreateErr  error
 		expectedStatus int
 		expectedBody   string
@@ -422,6 +556,37 @@ func TestScheduleAppointment(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   "Invalid input",
 		},
+		{
+			name:           "doctor not found",
+			paramID:        "1",
+			body:           `{ "doctorId": 9, "date": "2024-01-01T10:00:00Z" }`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "Invalid doctor",
+		},
+		{
+			name:           "user is not a doctor",
+			paramID:        "1",
+			body:           `{ "doctorId": 2, "date": "2024-01-01T10:00:00Z" }`,
+			doctor:         &models.User{Model: gorm.Model{ID: 2}, Role: enums.Receptionist},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "user is not a doctor",
+		},
+		{
+			name:           "inactive doctor",
+			paramID:        "1",
+			body:           `{ "doctorId": 3, "date": "2024-01-01T10:00:00Z" }`,
+			doctor:         &models.User{Model: gorm.Model{ID: 3}, Role: enums.Doctor, DeactivatedAt: &time.Time{}},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "doctor is inactive",
+		},
+		{
+			name:           "date in the past",
+			paramID:        "1",
+			body:           `{ "doctorId": 1, "date": "2024-01-01T10:00:00Z" }`,
+			mockCreateErr:  ps.ErrAppointmentInPast,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "must be in the future",
+		},
 		{
 			name:           "DB error",
 			paramID:        "1",
@@ -431,6 +596,25 @@ func TestScheduleAppointment(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   "Failed to create appointment",
 		},
+		{
+			name:    "conflict",
+			paramID: "1",
+			body:    `{ "doctorId": 1, "date": "2024-01-01T10:00:00Z" }`,
+			mockCreateErr: &ps.AppointmentConflictError{
+				Appointment: models.Appointment{Model: gorm.Model{ID: 7}},
+				DoctorBusy:  true,
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "doctor already has appointment 7",
+		},
+		{
+			name:           "doctor unavailable",
+			paramID:        "1",
+			body:           `{ "doctorId": 1, "date": "2024-01-01T10:00:00Z" }`,
+			mockCreateErr:  avs.ErrOutsideShift,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "Doctor unavailable",
+		},
 		{
 			name:           "success",
 			paramID:        "1",
@@ -438,22 +622,37 @@ func TestScheduleAppointment(t *testing.T) {
 			mockGetErr:     nil,
 			mockCreateErr:  nil,
 			expectedStatus: http.StatusCreated,
-			expectedBody:   "appointment",
+			expectedBody:   `"doctorId":1`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			doctor := tt.doctor
+			if doctor == nil {
+				doctor = &models.User{Model: gorm.Model{ID: 1}, Role: enums.Doctor}
+			}
 			mockService := &mocks.MockPacientService{
 				MockGet: func(id uint64) (*models.Pacient, error) {
 					return &models.Pacient{Model: gorm.Model{ID: uint(id)}, Name: "Test Pacient"}, tt.mockGetErr
 				},
 				MockScheduleAppointment: func(appt *models.Appointment) error {
+					assert.Equal(t, doctor.ID, appt.UserID)
+					appt.ID = 1
 					return tt.mockCreateErr
 				},
 			}
 
-			handler := NewHandler(mockService)
+			mockUserService := &mocks.MockUserService{
+				MockGet: func(id uint64) (*models.User, error) {
+					if uint(id) != doctor.ID {
+						return nil, gorm.ErrRecordNotFound
+					}
+					return doctor, nil
+				},
+			}
+
+			handler := NewHandler(mockService, mockUserService, &mocks.MockPermissionService{}, testLocation)
 			router := gin.Default()
 			router.POST("/pacients/:id/appointments", handler.ScheduleAppointment)
 
@@ -469,8 +668,18 @@ func TestScheduleAppointment(t *testing.T) {
 	}
 }
 
+// testSigner assina os tokens dos testes com a configuração padrão
+func testSigner(t *testing.T) *utils.JWTSigner {
+	cfg := config.Default().JWT
+	cfg.SecretKey = "0123456789abcdef0123456789abcdef"
+	signer, err := utils.NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	return signer
+}
+
 func TestGetAllPacientsAuth(t *testing.T) {
 	gin.SetMode(gin.TestMode)
+	signer := testSigner(t)
 
 	mockUserSvc := &mocks.MockUserService{
 		// Sempre retorna um user com role "admin" ou qualquer outro não-permitido
@@ -480,16 +689,16 @@ func TestGetAllPacientsAuth(t *testing.T) {
 	}
 
 	mockPacientSvc := &mocks.MockPacientService{
-		MockGetAll: func(name, ageStr string) ([]models.Pacient, error) {
-			return []models.Pacient{{Name: "ShouldNotAppear"}}, nil
+		MockGetAll: func(filter ps.PacientFilter) ([]models.Pacient, int64, error) {
+			return []models.Pacient{{Name: "ShouldNotAppear"}}, 1, nil
 		},
 	}
 
-	handler := NewHandler(mockPacientSvc)
+	handler := NewHandler(mockPacientSvc, mockUserSvc, &mocks.MockPermissionService{}, testLocation)
 	r := gin.Default()
 	protected := r.Group("/")
 	protected.Use(
-		middlewares.JWTAuthMiddleware(mockUserSvc),
+		middlewares.JWTAuthMiddleware(signer, &mocks.MockSessionService{}),
 		middlewares.RoleMiddleware(enums.Receptionist, enums.Doctor),
 	)
 	protected.GET("/pacients", handler.GetAllPacients)
@@ -499,10 +708,629 @@ func TestGetAllPacientsAuth(t *testing.T) {
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-	token, _ := utils.GenerateJWT(1)
+	token, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Admin, SessionID: 1})
 	w = httptest.NewRecorder()
 	req = httptest.NewRequest("GET", "/pacients", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
+
+func TestAppointmentLifecycleHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "status invalid ID",
+			method:         http.MethodPatch,
+			path:           "/appointments/abc/status",
+			body:           `{ "status": "confirmed" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid ID",
+		},
+		{
+			name:           "status unknown value",
+			method:         http.MethodPatch,
+			path:           "/appointments/1/status",
+			body:           `{ "status": "done" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "status cancelled must use cancel endpoint",
+			method:         http.MethodPatch,
+			path:           "/appointments/1/status",
+			body:           `{ "status": "cancelled" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "status invalid transition",
+			method:         http.MethodPatch,
+			path:           "/appointments/1/status",
+			body:           `{ "status": "completed" }`,
+			mockErr:        &ps.InvalidTransitionError{From: enums.Scheduled, To: enums.Completed},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "cannot go from scheduled to completed",
+		},
+		{
+			name:           "status not found",
+			method:         http.MethodPatch,
+			path:           "/appointments/1/status",
+			body:           `{ "status": "confirmed" }`,
+			mockErr:        gorm.ErrRecordNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Appointment not found",
+		},
+		{
+			name:           "status success",
+			method:         http.MethodPatch,
+			path:           "/appointments/1/status",
+			body:           `{ "status": "confirmed" }`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"status":"confirmed"`,
+		},
+		{
+			name:           "cancel without reason",
+			method:         http.MethodPost,
+			path:           "/appointments/1/cancel",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "cancel success",
+			method:         http.MethodPost,
+			path:           "/appointments/1/cancel",
+			body:           `{ "reason": "Paciente viajou" }`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"cancelReason":"Paciente viajou"`,
+		},
+		{
+			name:   "reschedule conflict",
+			method: http.MethodPost,
+			path:   "/appointments/1/reschedule",
+			body:   `{ "date": "2030-01-07T10:00:00Z" }`,
+			mockErr: &ps.AppointmentConflictError{
+				Appointment: models.Appointment{Model: gorm.Model{ID: 9}},
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "pacient already has appointment 9",
+		},
+		{
+			name:           "reschedule doctor unavailable",
+			method:         http.MethodPost,
+			path:           "/appointments/1/reschedule",
+			body:           `{ "date": "2030-01-07T03:00:00Z" }`,
+			mockErr:        avs.ErrOutsideShift,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "Doctor unavailable",
+		},
+		{
+			name:           "reschedule success",
+			method:         http.MethodPost,
+			path:           "/appointments/1/reschedule",
+			body:           `{ "date": "2030-01-07T10:00:00Z" }`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"date":"2030-01-07T10:00:00Z"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPacientService{}
+			if tt.mockErr != nil {
+				mockService.MockUpdateStatus = func(id uint64, status enums.AppointmentStatus, actorID uint) (*models.Appointment, error) {
+					return nil, tt.mockErr
+				}
+				mockService.MockCancelAppointment = func(id uint64, reason string, actorID uint) (*models.Appointment, error) {
+					return nil, tt.mockErr
+				}
+				mockService.MockReschedule = func(id uint64, date time.Time, actorID uint) (*models.Appointment, error) {
+					return nil, tt.mockErr
+				}
+			}
+
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
+			router := gin.Default()
+			router.PATCH("/appointments/:id/status", handler.UpdateAppointmentStatus)
+			router.POST("/appointments/:id/cancel", handler.CancelAppointment)
+			router.POST("/appointments/:id/reschedule", handler.RescheduleAppointment)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestListAppointmentsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockErr        error
+		check          func(t *testing.T, filter ps.AppointmentFilter)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "filters are forwarded",
+			query: "?doctorId=2&pacientId=3&from=2030-01-01T00:00:00Z&status=scheduled,confirmed&page=2&limit=10&sort=-date",
+			check: func(t *testing.T, filter ps.AppointmentFilter) {
+				assert.Equal(t, uint(2), filter.DoctorID)
+				assert.Equal(t, uint(3), filter.PacientID)
+				assert.Equal(t, 2030, filter.From.Year())
+				assert.Equal(t, []enums.AppointmentStatus{enums.Scheduled, enums.Confirmed}, filter.Statuses)
+				assert.Equal(t, 2, filter.Page)
+				assert.Equal(t, 10, filter.Limit)
+				assert.Equal(t, "-date", filter.Sort)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"page":2`,
+		},
+		{
+			name:           "default pagination",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"limit":20`,
+		},
+		{
+			name:           "unknown status",
+			query:          "?status=done",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unknown status",
+		},
+		{
+			name:           "limit too large",
+			query:          "?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "invalid sort",
+			query:          "?sort=name",
+			mockErr:        ps.ErrInvalidSort,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid sort field",
+		},
+		{
+			name:           "service error",
+			mockErr:        assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to list appointments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPacientService{
+				MockListAppointments: func(filter ps.AppointmentFilter) ([]models.Appointment, int64, error) {
+					if tt.check != nil {
+						tt.check(t, filter)
+					}
+					return []models.Appointment{}, 0, tt.mockErr
+				},
+			}
+
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
+			router := gin.Default()
+			router.GET("/appointments", handler.ListAppointments)
+
+			req, _ := http.NewRequest(http.MethodGet, "/appointments"+tt.query, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestGetAppointmentHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &mocks.MockPacientService{
+		MockGetAppointment: func(id uint64) (*models.Appointment, error) {
+			if id != 5 {
+				return nil, gorm.ErrRecordNotFound
+			}
+			return &models.Appointment{Model: gorm.Model{ID: 5}, Status: enums.Confirmed}, nil
+		},
+	}
+
+	handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
+	router := gin.Default()
+	router.GET("/appointments/:id", handler.GetAppointment)
+
+	for path, want := range map[string]int{
+		"/appointments/abc": http.StatusBadRequest,
+		"/appointments/1":   http.StatusNotFound,
+		"/appointments/5":   http.StatusOK,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, want, resp.Code, path)
+	}
+}
+
+// TestAuditSubjects confere que as respostas informam à auditoria os pacientes
+// cujos dados trazem
+func TestAuditSubjects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &mocks.MockPacientService{
+		MockGetAll: func(filter ps.PacientFilter) ([]models.Pacient, int64, error) {
+			return []models.Pacient{{Model: gorm.Model{ID: 2}}, {Model: gorm.Model{ID: 4}}}, 2, nil
+		},
+		MockGetAppointment: func(id uint64) (*models.Appointment, error) {
+			return &models.Appointment{Model: gorm.Model{ID: uint(id)}, PacientID: 9}, nil
+		},
+		MockListAppointments: func(filter ps.AppointmentFilter) ([]models.Appointment, int64, error) {
+			return []models.Appointment{{PacientID: 6}, {PacientID: 7}}, 2, nil
+		},
+	}
+
+	handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
+	var subjects any
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		c.Next()
+		subjects, _ = c.Get(middlewares.AuditSubjectsKey)
+	})
+	router.GET("/pacients", handler.GetAllPacients)
+	router.GET("/appointments", handler.ListAppointments)
+	router.GET("/appointments/:id", handler.GetAppointment)
+	router.GET("/me/agenda", handler.GetMyAgenda)
+
+	for path, want := range map[string][]uint{
+		"/pacients":       {2, 4},
+		"/appointments":   {6, 7},
+		"/appointments/5": {9},
+		"/me/agenda":      {6, 7},
+	} {
+		subjects = nil
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code, path)
+		assert.Equal(t, want, subjects, path)
+	}
+}
+
+func TestGetMyAgendaHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         any
+		query          string
+		check          func(t *testing.T, filter ps.AppointmentFilter)
+		expectedStatus int
+	}{
+		{
+			name:           "missing user",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "given day",
+			userID: uint(7),
+			query:  "?date=2030-01-07",
+			check: func(t *testing.T, filter ps.AppointmentFilter) {
+				assert.Equal(t, uint(7), filter.DoctorID)
+				assert.True(t, time.Date(2030, 1, 7, 0, 0, 0, 0, testLocation).Equal(filter.From))
+				assert.Equal(t, 24*time.Hour, filter.To.Sub(filter.From))
+				assert.Equal(t, "date", filter.Sort)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "defaults to today",
+			userID: uint(7),
+			check: func(t *testing.T, filter ps.AppointmentFilter) {
+				now := time.Now()
+				assert.False(t, now.Before(filter.From))
+				assert.True(t, now.Before(filter.To))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid date",
+			userID:         uint(7),
+			query:          "?date=07/01/2030",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPacientService{
+				MockListAppointments: func(filter ps.AppointmentFilter) ([]models.Appointment, int64, error) {
+					if tt.check != nil {
+						tt.check(t, filter)
+					}
+					return []models.Appointment{}, 0, nil
+				},
+			}
+
+			handler := NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation)
+			router := gin.Default()
+			router.GET("/me/agenda", func(c *gin.Context) {
+				if tt.userID != nil {
+					c.Set("userID", tt.userID)
+				}
+				c.Next()
+			}, handler.GetMyAgenda)
+
+			req, _ := http.NewRequest(http.MethodGet, "/me/agenda"+tt.query, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestDoctorPacientScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	doctorOnly := &mocks.MockPermissionService{
+		MockAllowed: func(role enums.Role, permission enums.Permission) bool {
+			return permission != enums.PermPacientReadAll
+		},
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		accessErr     error
+		emergency     bool
+		wantStatus    int
+		wantEmergency bool
+	}{
+		{name: "own pacient", path: "/pacients/42", wantStatus: http.StatusOK},
+		{name: "emergency access", path: "/pacients/42", emergency: true, wantStatus: http.StatusOK, wantEmergency: true},
+		{name: "no access", path: "/pacients/42", accessErr: ps.ErrNoPacientAccess, wantStatus: http.StatusForbidden},
+		{name: "access check fails", path: "/pacients/42", accessErr: assert.AnError, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPacientService{
+				MockGet: func(id uint64) (*models.Pacient, error) {
+					return &models.Pacient{Model: gorm.Model{ID: uint(id)}}, nil
+				},
+				MockPacientAccess: func(doctorID uint, pacientID uint64) (bool, error) {
+					assert.Equal(t, uint(7), doctorID)
+					assert.Equal(t, uint64(42), pacientID)
+					return tt.emergency, tt.accessErr
+				},
+			}
+
+			var flagged bool
+			handler := NewHandler(mockService, &mocks.MockUserService{}, doctorOnly, testLocation)
+			router := gin.Default()
+			router.GET("/pacients/:id", func(c *gin.Context) {
+				c.Set("userID", uint(7))
+				c.Set("role", enums.Doctor)
+				c.Next()
+				flagged = c.GetBool(middlewares.AuditEmergencyKey)
+			}, handler.GetPacient)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code)
+			assert.Equal(t, tt.wantEmergency, flagged)
+		})
+	}
+
+	t.Run("appointment of another doctor's pacient", func(t *testing.T) {
+		mockService := &mocks.MockPacientService{
+			MockGetAppointment: func(id uint64) (*models.Appointment, error) {
+				return &models.Appointment{Model: gorm.Model{ID: uint(id)}, PacientID: 42, Pacient: models.Pacient{Name: "John Doe"}}, nil
+			},
+			MockPacientAccess: func(doctorID uint, pacientID uint64) (bool, error) {
+				assert.Equal(t, uint64(42), pacientID)
+				return false, ps.ErrNoPacientAccess
+			},
+		}
+
+		router := gin.Default()
+		router.GET("/appointments/:id", func(c *gin.Context) {
+			c.Set("userID", uint(7))
+			c.Set("role", enums.Doctor)
+		}, NewHandler(mockService, &mocks.MockUserService{}, doctorOnly, testLocation).GetAppointment)
+
+		req, _ := http.NewRequest(http.MethodGet, "/appointments/5", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "John Doe")
+	})
+
+	t.Run("changes to appointments of another doctor's pacient", func(t *testing.T) {
+		changed := false
+		mockService := &mocks.MockPacientService{
+			MockGetAppointment: func(id uint64) (*models.Appointment, error) {
+				if id != 5 {
+					return nil, gorm.ErrRecordNotFound
+				}
+				return &models.Appointment{Model: gorm.Model{ID: 5}, PacientID: 42}, nil
+			},
+			MockPacientAccess: func(doctorID uint, pacientID uint64) (bool, error) {
+				assert.Equal(t, uint64(42), pacientID)
+				return false, ps.ErrNoPacientAccess
+			},
+			MockUpdateStatus: func(id uint64, status enums.AppointmentStatus, actorID uint) (*models.Appointment, error) {
+				changed = true
+				return &models.Appointment{}, nil
+			},
+			MockCancelAppointment: func(id uint64, reason string, actorID uint) (*models.Appointment, error) {
+				changed = true
+				return &models.Appointment{}, nil
+			},
+			MockReschedule: func(id uint64, date time.Time, actorID uint) (*models.Appointment, error) {
+				changed = true
+				return &models.Appointment{}, nil
+			},
+		}
+
+		handler := NewHandler(mockService, &mocks.MockUserService{}, doctorOnly, testLocation)
+		router := gin.Default()
+		router.Use(func(c *gin.Context) {
+			c.Set("userID", uint(7))
+			c.Set("role", enums.Doctor)
+		})
+		router.PATCH("/appointments/:id/status", handler.UpdateAppointmentStatus)
+		router.POST("/appointments/:id/cancel", handler.CancelAppointment)
+		router.POST("/appointments/:id/reschedule", handler.RescheduleAppointment)
+
+		requests := []struct{ method, path, body string }{
+			{http.MethodPatch, "/appointments/5/status", `{ "status": "confirmed" }`},
+			{http.MethodPost, "/appointments/5/cancel", `{ "reason": "Paciente viajou" }`},
+			{http.MethodPost, "/appointments/5/reschedule", `{ "date": "2030-01-07T10:00:00Z" }`},
+			{http.MethodPost, "/appointments/6/cancel", `{ "reason": "Paciente viajou" }`},
+		}
+		for _, r := range requests {
+			req, _ := http.NewRequest(r.method, r.path, strings.NewReader(r.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusNotFound, resp.Code, r.path)
+			assert.Contains(t, resp.Body.String(), "Appointment not found", r.path)
+		}
+		assert.False(t, changed)
+	})
+
+	t.Run("appointments are restricted to visible pacients", func(t *testing.T) {
+		var got *ps.AppointmentFilter
+		mockService := &mocks.MockPacientService{
+			MockListAppointments: func(filter ps.AppointmentFilter) ([]models.Appointment, int64, error) {
+				got = &filter
+				return []models.Appointment{}, 0, nil
+			},
+			MockPacientAccess: func(doctorID uint, pacientID uint64) (bool, error) {
+				if pacientID == 42 {
+					return false, nil
+				}
+				return false, ps.ErrNoPacientAccess
+			},
+		}
+
+		router := gin.Default()
+		router.GET("/appointments", func(c *gin.Context) {
+			c.Set("userID", uint(7))
+			c.Set("role", enums.Doctor)
+		}, NewHandler(mockService, &mocks.MockUserService{}, doctorOnly, testLocation).ListAppointments)
+
+		for query, want := range map[string]int{
+			"":              http.StatusOK,
+			"?pacientId=42": http.StatusOK,
+			"?pacientId=43": http.StatusForbidden,
+		} {
+			got = nil
+			req, _ := http.NewRequest(http.MethodGet, "/appointments"+query, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, want, resp.Code, query)
+			if want == http.StatusOK && assert.NotNil(t, got, query) {
+				assert.Equal(t, uint(7), got.VisibleTo, query)
+			} else {
+				assert.Nil(t, got, query)
+			}
+		}
+	})
+
+	t.Run("list is restricted to visible pacients", func(t *testing.T) {
+		var got ps.PacientFilter
+		mockService := &mocks.MockPacientService{
+			MockGetAll: func(filter ps.PacientFilter) ([]models.Pacient, int64, error) {
+				got = filter
+				return []models.Pacient{}, 0, nil
+			},
+		}
+
+		router := gin.Default()
+		router.GET("/pacients", func(c *gin.Context) {
+			c.Set("userID", uint(7))
+			c.Set("role", enums.Doctor)
+		}, NewHandler(mockService, &mocks.MockUserService{}, doctorOnly, testLocation).GetAllPacients)
+
+		req, _ := http.NewRequest(http.MethodGet, "/pacients", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, uint(7), got.VisibleTo)
+	})
+}
+
+func TestGrantEmergencyAccessHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	justification := `{ "justification": "Paciente inconsciente no pronto-socorro" }`
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "invalid ID", path: "/pacients/abc/emergency-access", body: justification, wantStatus: http.StatusBadRequest, wantBody: "Invalid ID"},
+		{name: "missing justification", path: "/pacients/42/emergency-access", body: `{}`, wantStatus: http.StatusBadRequest, wantBody: "Invalid input"},
+		{name: "justification too short", path: "/pacients/42/emergency-access", body: `{ "justification": "urgente" }`, wantStatus: http.StatusBadRequest, wantBody: "Invalid input"},
+		{name: "pacient not found", path: "/pacients/42/emergency-access", body: justification, mockErr: gorm.ErrRecordNotFound, wantStatus: http.StatusNotFound, wantBody: "Pacient not found"},
+		{name: "service error", path: "/pacients/42/emergency-access", body: justification, mockErr: assert.AnError, wantStatus: http.StatusInternalServerError, wantBody: "Failed to grant emergency access"},
+		{name: "granted", path: "/pacients/42/emergency-access", body: justification, wantStatus: http.StatusCreated, wantBody: `"pacientId":42`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPacientService{
+				MockGrantEmergency: func(doctorID uint, pacientID uint64, justification string) (*models.EmergencyAccess, error) {
+					assert.Equal(t, uint(7), doctorID)
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &models.EmergencyAccess{UserID: doctorID, PacientID: uint(pacientID), Justification: justification}, nil
+				},
+			}
+
+			router := gin.Default()
+			router.POST("/pacients/:id/emergency-access", func(c *gin.Context) {
+				c.Set("userID", uint(7))
+			}, NewHandler(mockService, &mocks.MockUserService{}, &mocks.MockPermissionService{}, testLocation).GrantEmergencyAccess)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.wantBody)
+		})
+	}
+}
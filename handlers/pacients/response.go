@@ -1,13 +1,19 @@
 package pacients
 
-import "time"
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+)
 
 // PacientResponse é o payload retornado em /pacients e /pacients/{id}
 type PacientResponse struct {
 	ID          uint      `json:"id"`
 	Name        string    `json:"name"`
 	BirthDate   time.Time `json:"birthDate"`
-	CPF         string    `json:"cpf"`
+	CPF         cpf.CPF   `json:"cpf" swaggertype:"string" example:"529.982.247-25"`
 	Sex         string    `json:"sex"`
 	PhoneNumber string    `json:"phoneNumber"`
 	Address     string    `json:"address"`
@@ -18,10 +24,55 @@ type PacientResponse struct {
 
 // AppointmentResponse é o payload retornado em POST /pacients/{id}/appointments
 type AppointmentResponse struct {
-	ID        uint      `json:"id"`
-	PacientID uint      `json:"pacientId"`
-	UserID    uint      `json:"doctorId"`
-	Date      time.Time `json:"date"`
+	ID        uint                    `json:"id"`
+	PacientID uint                    `json:"pacientId"`
+	UserID    uint                    `json:"doctorId"`
+	Date      time.Time               `json:"date"`
+	EndDate   time.Time               `json:"endDate"`
+	Status    enums.AppointmentStatus `json:"status"`
+}
+
+func NewAppointmentResponse(appointment models.Appointment) AppointmentResponse {
+	return AppointmentResponse{
+		ID:        appointment.ID,
+		PacientID: appointment.PacientID,
+		UserID:    appointment.UserID,
+		Date:      appointment.Date,
+		EndDate:   appointment.EndDate,
+		Status:    appointment.Status,
+	}
+}
+
+// ConflictResponse é retornado quando o horário já está ocupado
+type ConflictResponse struct {
+	Message     string              `json:"message"`
+	Appointment AppointmentResponse `json:"appointment"`
+}
+
+// AppointmentListResponse é o payload paginado de GET /appointments e /me/agenda
+type AppointmentListResponse struct {
+	Appointments []models.Appointment `json:"appointments"`
+	Total        int64                `json:"total"`
+	Page         int                  `json:"page"`
+	Limit        int                  `json:"limit"`
+}
+
+// PacientListResponse é o payload paginado de GET /pacients
+type PacientListResponse struct {
+	Pacients []models.Pacient `json:"pacients"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	Limit    int              `json:"limit"`
+}
+
+// EmergencyAccessResponse é o payload de POST /pacients/{id}/emergency-access
+type EmergencyAccessResponse struct {
+	Access models.EmergencyAccess `json:"access"`
+}
+
+// EmergencyAccessListResponse é o payload de GET /emergency-accesses
+type EmergencyAccessListResponse struct {
+	Accesses []models.EmergencyAccess `json:"accesses"`
 }
 
 // ErrorResponse representa um erro comum
@@ -0,0 +1,140 @@
+package passwords
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	pws "github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service pws.PasswordService
+}
+
+func NewHandler(service pws.PasswordService) *Handler {
+	return &Handler{service: service}
+}
+
+// ChangeMyPassword troca a senha do usuário logado
+// @Summary      Troca a própria senha
+// @Description  Exige a senha atual. A nova segue a política de senha e não pode repetir as últimas; todas as sessões são encerradas
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body  ChangePasswordDTO  true  "Senha atual e nova"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid input"
+// @Failure      403  {object}  handlers.ErrorResponse  "Current password is incorrect"
+// @Failure      422  {object}  handlers.PasswordPolicyResponse  "Password rejected by policy"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to change password"
+// @Router       /me/password [post]
+func (h *Handler) ChangeMyPassword(c *gin.Context) {
+	var payload ChangePasswordDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	err := h.service.Change(c.GetUint("userID"), payload.CurrentPassword, payload.NewPassword)
+	if err != nil {
+		respondPasswordError(c, err, "Failed to change password: ")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// IssueReset emite um token de redefinição de senha
+// @Summary      Emite token de redefinição de senha
+// @Description  Gera um token de uso único para o usuário definir uma nova senha em /password/reset. Tokens anteriores deixam de valer
+// @Tags         Usuários
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "ID do usuário"
+// @Success      201  {object}  handlers.PasswordResetResponse
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "User not found"
+// @Failure      409  {object}  handlers.ErrorResponse  "User is inactive"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to issue reset token"
+// @Router       /users/{id}/password-reset [post]
+func (h *Handler) IssueReset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	reset, token, err := h.service.IssueReset(id, c.GetUint("userID"))
+	if err != nil {
+		respondPasswordError(c, err, "Failed to issue reset token: ")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"token": token, "expiresAt": reset.ExpiresAt})
+}
+
+// ResetPassword define a senha a partir do token emitido pelo admin
+// @Summary      Redefine a senha
+// @Description  Usa o token de uso único emitido por um admin para definir uma nova senha
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  ResetPasswordDTO  true  "Token e nova senha"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid input or token"
+// @Failure      422  {object}  handlers.PasswordPolicyResponse  "Password rejected by policy"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to reset password"
+// @Router       /password/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var payload ResetPasswordDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.service.Reset(payload.Token, payload.NewPassword); err != nil {
+		respondPasswordError(c, err, "Failed to reset password: ")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RespondPolicyError responde 422 para senhas recusadas pela política ou
+// repetidas e informa se tratou o erro. É usada também pelos handlers de
+// cadastro e edição de usuários.
+func RespondPolicyError(c *gin.Context, err error) bool {
+	var policy *pws.PolicyError
+	switch {
+	case errors.As(err, &policy):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error(), "violations": policy.Violations})
+	case errors.Is(err, pws.ErrPasswordReused):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+func respondPasswordError(c *gin.Context, err error, fallback string) {
+	if RespondPolicyError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, pws.ErrWrongPassword):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, pws.ErrInvalidResetToken):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, pws.ErrUserInactive):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found: " + err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback + err.Error()})
+	}
+}
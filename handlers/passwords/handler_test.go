@@ -0,0 +1,126 @@
+package passwords
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/middlewares"
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	"github.com/andresidrim/cesupa-hospital/models"
+	pws "github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRouter(ms *mocks.MockPasswordService) *gin.Engine {
+	h := NewHandler(ms)
+	r := gin.Default()
+	r.POST("/password/reset", h.ResetPassword)
+	r.POST("/users/:id/password-reset", func(c *gin.Context) { c.Set("userID", uint(9)) }, h.IssueReset)
+	r.POST("/me/password", func(c *gin.Context) { c.Set("userID", uint(3)) }, h.ChangeMyPassword)
+	return r
+}
+
+func TestPasswordHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "change missing fields", path: "/me/password", body: `{ "newPassword": "x" }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "change wrong current", path: "/me/password", body: `{ "currentPassword": "a", "newPassword": "b" }`, mockErr: pws.ErrWrongPassword, expectedStatus: http.StatusForbidden, expectedBody: pws.ErrWrongPassword.Error()},
+		{name: "change rejected by policy", path: "/me/password", body: `{ "currentPassword": "a", "newPassword": "b" }`, mockErr: &pws.PolicyError{Violations: []string{"is too common"}}, expectedStatus: http.StatusUnprocessableEntity, expectedBody: `"violations":["is too common"]`},
+		{name: "change reused", path: "/me/password", body: `{ "currentPassword": "a", "newPassword": "b" }`, mockErr: pws.ErrPasswordReused, expectedStatus: http.StatusUnprocessableEntity, expectedBody: pws.ErrPasswordReused.Error()},
+		{name: "change success", path: "/me/password", body: `{ "currentPassword": "a", "newPassword": "b" }`, expectedStatus: http.StatusNoContent},
+		{name: "issue invalid ID", path: "/users/abc/password-reset", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "issue not found", path: "/users/1/password-reset", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "User not found"},
+		{name: "issue inactive", path: "/users/1/password-reset", mockErr: pws.ErrUserInactive, expectedStatus: http.StatusConflict, expectedBody: pws.ErrUserInactive.Error()},
+		{name: "issue success", path: "/users/1/password-reset", expectedStatus: http.StatusCreated, expectedBody: `"token":"reset-token"`},
+		{name: "reset missing token", path: "/password/reset", body: `{ "newPassword": "b" }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "reset invalid token", path: "/password/reset", body: `{ "token": "t", "newPassword": "b" }`, mockErr: pws.ErrInvalidResetToken, expectedStatus: http.StatusBadRequest, expectedBody: pws.ErrInvalidResetToken.Error()},
+		{name: "reset service error", path: "/password/reset", body: `{ "token": "t", "newPassword": "b" }`, mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to reset password"},
+		{name: "reset success", path: "/password/reset", body: `{ "token": "t", "newPassword": "b" }`, expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockPasswordService{
+				MockChange: func(userID uint, current, next string) error {
+					assert.Equal(t, uint(3), userID)
+					return tt.mockErr
+				},
+				MockIssueReset: func(userID uint64, issuedByID uint) (*models.PasswordResetToken, string, error) {
+					assert.Equal(t, uint64(1), userID)
+					assert.Equal(t, uint(9), issuedByID)
+					if tt.mockErr != nil {
+						return nil, "", tt.mockErr
+					}
+					return &models.PasswordResetToken{ExpiresAt: time.Now().Add(time.Hour)}, "reset-token", nil
+				},
+				MockReset: func(token, next string) error {
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+// testSigner assina os tokens dos testes com a configuração padrão
+func testSigner(t *testing.T) *utils.JWTSigner {
+	cfg := config.Default().JWT
+	cfg.SecretKey = "0123456789abcdef0123456789abcdef"
+	signer, err := utils.NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	return signer
+}
+
+func TestMustChangePasswordIsEnforced(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := testSigner(t)
+
+	r := gin.Default()
+	protected := r.Group("/")
+	protected.Use(
+		middlewares.JWTAuthMiddleware(signer, &mocks.MockSessionService{}),
+		middlewares.PasswordChangeMiddleware("/me/password"),
+	)
+	protected.POST("/me/password", NewHandler(&mocks.MockPasswordService{}).ChangeMyPassword)
+	protected.GET("/pacients", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	pending, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Doctor, SessionID: 1, MustChangePassword: true})
+	assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "/pacients", pending, ""))
+	assert.Equal(t, http.StatusNoContent, send(http.MethodPost, "/me/password", pending, `{ "currentPassword": "a", "newPassword": "b" }`))
+
+	changed, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Doctor, SessionID: 2})
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/pacients", changed, ""))
+}
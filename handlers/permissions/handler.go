@@ -0,0 +1,51 @@
+package permissions
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	ps "github.com/andresidrim/cesupa-hospital/services/permissions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service ps.PermissionService
+}
+
+func NewHandler(service ps.PermissionService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetUserPermissions mostra as permissões efetivas de um usuário
+// @Summary      Permissões do usuário
+// @Description  Retorna o papel do usuário e as permissões que ele tem pela matriz de permissões; usuários desativados não têm nenhuma
+// @Tags         Usuários
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "ID do usuário"
+// @Success      200  {object}  ps.Effective
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "User not found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to fetch permissions"
+// @Router       /users/{id}/permissions [get]
+func (h *Handler) GetUserPermissions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	effective, err := h.service.Effective(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "User not found: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch permissions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"permissions": effective})
+}
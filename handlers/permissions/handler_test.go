@@ -0,0 +1,94 @@
+package permissions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/middlewares"
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	ps "github.com/andresidrim/cesupa-hospital/services/permissions"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestGetUserPermissionsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid ID", path: "/users/abc/permissions", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "not found", path: "/users/1/permissions", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "User not found"},
+		{name: "service error", path: "/users/1/permissions", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to fetch permissions"},
+		{name: "success", path: "/users/1/permissions", expectedStatus: http.StatusOK, expectedBody: `"permissions":["pacient:read"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockPermissionService{
+				MockEffective: func(userID uint64) (*ps.Effective, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &ps.Effective{UserID: uint(userID), Role: enums.Doctor, Active: true, Permissions: []enums.Permission{enums.PermPacientRead}}, nil
+				},
+			}
+			r := gin.Default()
+			r.GET("/users/:id/permissions", NewHandler(ms).GetUserPermissions)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ms := &mocks.MockPermissionService{
+		MockAllowed: func(role enums.Role, permission enums.Permission) bool {
+			assert.Equal(t, enums.PermPacientRead, permission)
+			return role == enums.Doctor
+		},
+	}
+
+	tests := []struct {
+		name           string
+		role           any
+		expectedStatus int
+	}{
+		{name: "missing role", expectedStatus: http.StatusForbidden},
+		{name: "invalid role type", role: "doctor", expectedStatus: http.StatusInternalServerError},
+		{name: "without permission", role: enums.Receptionist, expectedStatus: http.StatusForbidden},
+		{name: "with permission", role: enums.Doctor, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.Default()
+			r.GET("/pacients",
+				func(c *gin.Context) {
+					if tt.role != nil {
+						c.Set("role", tt.role)
+					}
+				},
+				middlewares.RequirePermission(ms, enums.PermPacientRead),
+				func(c *gin.Context) { c.Status(http.StatusOK) },
+			)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pacients", nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
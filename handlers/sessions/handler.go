@@ -0,0 +1,108 @@
+package sessions
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	ss "github.com/andresidrim/cesupa-hospital/services/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service ss.SessionService
+}
+
+func NewHandler(service ss.SessionService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetUserSessions lista as sessões ativas de um usuário
+// @Summary      Lista sessões
+// @Description  Retorna os logins ainda válidos do usuário, do uso mais recente para o mais antigo
+// @Tags         Usuários
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "ID do usuário"
+// @Success      200  {array}   models.Session
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to fetch sessions"
+// @Router       /users/{id}/sessions [get]
+func (h *Handler) GetUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	sessions, err := h.service.GetByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch sessions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession encerra uma sessão do usuário
+// @Summary      Revoga sessão
+// @Description  Revoga a sessão; o access token dela passa a ser recusado e o refresh token deixa de valer
+// @Tags         Usuários
+// @Security     BearerAuth
+// @Param        id         path  int  true  "ID do usuário"
+// @Param        sessionId  path  int  true  "ID da sessão"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "Session not found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to revoke session"
+// @Router       /users/{id}/sessions/{sessionId} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	if err := h.service.Revoke(userID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Session not found: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to revoke session: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAllSessions encerra todas as sessões do usuário
+// @Summary      Revoga todas as sessões
+// @Description  Desloga o usuário de todos os dispositivos
+// @Tags         Usuários
+// @Security     BearerAuth
+// @Param        id   path  int  true  "ID do usuário"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to revoke sessions"
+// @Router       /users/{id}/sessions [delete]
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	if err := h.service.RevokeAll(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to revoke sessions: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRouter(ms *mocks.MockSessionService) *gin.Engine {
+	h := NewHandler(ms)
+	r := gin.Default()
+	r.GET("/users/:id/sessions", h.GetUserSessions)
+	r.DELETE("/users/:id/sessions", h.RevokeAllSessions)
+	r.DELETE("/users/:id/sessions/:sessionId", h.RevokeSession)
+	return r
+}
+
+func TestGetUserSessionsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		paramID        string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid ID", paramID: "abc", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "service error", paramID: "1", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to fetch sessions"},
+		{name: "success", paramID: "1", expectedStatus: http.StatusOK, expectedBody: `"userAgent":"curl"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockSessionService{
+				MockGetByUser: func(userID uint64) ([]models.Session, error) {
+					return []models.Session{{UserID: uint(userID), UserAgent: "curl"}}, tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/users/"+tt.paramID+"/sessions", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestRevokeSessionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid session ID", path: "/users/1/sessions/abc", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "not found", path: "/users/1/sessions/2", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "Session not found"},
+		{name: "service error", path: "/users/1/sessions/2", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to revoke session"},
+		{name: "success", path: "/users/1/sessions/2", expectedStatus: http.StatusNoContent},
+		{name: "revoke all", path: "/users/1/sessions", expectedStatus: http.StatusNoContent},
+		{name: "revoke all error", path: "/users/1/sessions", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to revoke sessions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockSessionService{
+				MockRevoke: func(userID, id uint64) error {
+					assert.Equal(t, uint64(1), userID)
+					assert.Equal(t, uint64(2), id)
+					return tt.mockErr
+				},
+				MockRevokeAll: func(userID uint64) error {
+					assert.Equal(t, uint64(1), userID)
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("DELETE", tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package setup
+
+import "github.com/andresidrim/cesupa-hospital/cpf"
+
+type SetupAdminDTO struct {
+	Name     string  `json:"name" binding:"required"`
+	CPF      cpf.CPF `json:"cpf" binding:"required,cpf" swaggertype:"string" example:"529.982.247-25"`
+	Password string  `json:"password" binding:"required"`
+}
@@ -0,0 +1,95 @@
+package setup
+
+import (
+	"errors"
+	"net/http"
+
+	passwordsHandler "github.com/andresidrim/cesupa-hospital/handlers/passwords"
+	"github.com/andresidrim/cesupa-hospital/middlewares"
+	"github.com/andresidrim/cesupa-hospital/models"
+	ss "github.com/andresidrim/cesupa-hospital/services/setup"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenHeader é o cabeçalho que traz o token de setup mostrado no log da API
+const TokenHeader = "X-Setup-Token"
+
+type Handler struct {
+	service ss.SetupService
+}
+
+func NewHandler(service ss.SetupService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetStatus informa se o primeiro admin ainda pode ser criado
+// @Summary      Situação do setup inicial
+// @Description  available é true enquanto não houver nenhum usuário e a API tiver gerado um token de setup
+// @Tags         setup
+// @Produce      json
+// @Success      200  {object}  handlers.SetupStatusResponse
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to check setup"
+// @Router       /setup [get]
+func (h *Handler) GetStatus(c *gin.Context) {
+	available, err := h.service.Available()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check setup: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"available": available})
+}
+
+// CreateAdmin cria o primeiro admin
+// @Summary      Cria o primeiro admin
+// @Description  Só funciona enquanto não houver usuários. Exige no cabeçalho X-Setup-Token o token mostrado no log ao iniciar a API; depois do primeiro admin o setup é encerrado
+// @Tags         setup
+// @Accept       json
+// @Produce      json
+// @Param        X-Setup-Token  header  string         true  "Token de setup"
+// @Param        payload        body    SetupAdminDTO  true  "Dados do admin"
+// @Success      201  {object}  handlers.RegisterResponse
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid input"
+// @Failure      401  {object}  handlers.ErrorResponse  "Invalid setup token"
+// @Failure      404  {object}  handlers.ErrorResponse  "Setup is not available"
+// @Failure      422  {object}  handlers.PasswordPolicyResponse  "Password rejected by policy"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to create admin"
+// @Router       /setup/admin [post]
+func (h *Handler) CreateAdmin(c *gin.Context) {
+	var payload SetupAdminDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	admin := models.User{
+		Name:     payload.Name,
+		CPF:      payload.CPF,
+		Password: payload.Password,
+	}
+
+	if err := h.service.CreateAdmin(c.GetHeader(TokenHeader), &admin); err != nil {
+		if passwordsHandler.RespondPolicyError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, ss.ErrSetupClosed):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case errors.Is(err, ss.ErrInvalidSetupToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create admin: " + err.Error()})
+		}
+		return
+	}
+
+	c.Set(middlewares.AuditResourceIDKey, admin.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"user": gin.H{
+			"id":   admin.ID,
+			"name": admin.Name,
+			"cpf":  admin.CPF,
+			"role": admin.Role,
+		},
+	})
+}
@@ -0,0 +1,91 @@
+package setup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	"github.com/andresidrim/cesupa-hospital/models"
+	pws "github.com/andresidrim/cesupa-hospital/services/passwords"
+	ss "github.com/andresidrim/cesupa-hospital/services/setup"
+	"github.com/andresidrim/cesupa-hospital/validators"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	if err := validators.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func setupRouter(ms *mocks.MockSetupService) *gin.Engine {
+	h := NewHandler(ms)
+	r := gin.Default()
+	r.GET("/setup", h.GetStatus)
+	r.POST("/setup/admin", h.CreateAdmin)
+	return r
+}
+
+func TestSetupHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	valid := `{ "name": "Alice", "cpf": "529.982.247-25", "password": "Admin-secret1" }`
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		available      bool
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "status open", method: http.MethodGet, path: "/setup", available: true, expectedStatus: http.StatusOK, expectedBody: `"available":true`},
+		{name: "status closed", method: http.MethodGet, path: "/setup", expectedStatus: http.StatusOK, expectedBody: `"available":false`},
+		{name: "status error", method: http.MethodGet, path: "/setup", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to check setup"},
+		{name: "invalid cpf", method: http.MethodPost, path: "/setup/admin", body: `{ "name": "Alice", "cpf": "123", "password": "x" }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "missing password", method: http.MethodPost, path: "/setup/admin", body: `{ "name": "Alice", "cpf": "529.982.247-25" }`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "wrong token", method: http.MethodPost, path: "/setup/admin", body: valid, mockErr: ss.ErrInvalidSetupToken, expectedStatus: http.StatusUnauthorized, expectedBody: ss.ErrInvalidSetupToken.Error()},
+		{name: "already done", method: http.MethodPost, path: "/setup/admin", body: valid, mockErr: ss.ErrSetupClosed, expectedStatus: http.StatusNotFound, expectedBody: ss.ErrSetupClosed.Error()},
+		{name: "weak password", method: http.MethodPost, path: "/setup/admin", body: valid, mockErr: &pws.PolicyError{Violations: []string{"is too short"}}, expectedStatus: http.StatusUnprocessableEntity, expectedBody: `"violations":["is too short"]`},
+		{name: "service error", method: http.MethodPost, path: "/setup/admin", body: valid, mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to create admin"},
+		{name: "success", method: http.MethodPost, path: "/setup/admin", body: valid, expectedStatus: http.StatusCreated, expectedBody: `"role":"admin"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockSetupService{
+				MockAvailable: func() (bool, error) {
+					return tt.available, tt.mockErr
+				},
+				MockCreateAdmin: func(token string, admin *models.User) error {
+					assert.Equal(t, "setup-token", token)
+					assert.Equal(t, "52998224725", admin.CPF.String())
+					if tt.mockErr != nil {
+						return tt.mockErr
+					}
+					admin.ID = 1
+					admin.Role = enums.Admin
+					return nil
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(TokenHeader, "setup-token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package handlers
 
-import "github.com/andresidrim/cesupa-hospital/enums"
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+)
 
 // ErrorResponse é usado para todas as falhas
 type ErrorResponse struct {
@@ -11,11 +17,68 @@ type ErrorResponse struct {
 type RegisterResponse struct {
 	ID   uint       `json:"id"`
 	Name string     `json:"name"`
-	CPF  string     `json:"cpf"`
+	CPF  cpf.CPF    `json:"cpf" swaggertype:"string" example:"529.982.247-25"`
 	Role enums.Role `json:"role"`
 }
 
-// TokenResponse é o payload de sucesso de /login
+// TokenResponse é o payload de sucesso de /login e /token/refresh
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+// LoginChallengeResponse é devolvido por /login quando o usuário tem
+// segundo fator; o desafio é concluído em /login/2fa
+type LoginChallengeResponse struct {
+	MFARequired    bool      `json:"mfaRequired"`
+	ChallengeToken string    `json:"challengeToken"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// TOTPSetupResponse é o payload de sucesso de /me/2fa/setup
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodesResponse traz os códigos de recuperação, mostrados uma única vez
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// AuditListResponse é o payload de sucesso de /audit
+type AuditListResponse struct {
+	Entries []models.AuditLog `json:"entries"`
+	Total   int64             `json:"total"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
+}
+
+// PasswordPolicyResponse é devolvido quando a senha não cumpre a política
+type PasswordPolicyResponse struct {
+	Message    string   `json:"message"`
+	Violations []string `json:"violations"`
+}
+
+// PasswordResetResponse é o payload de sucesso de /users/{id}/password-reset
+type PasswordResetResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// DoctorProfileResponse é o payload de sucesso de /doctors/{id}/profile
+type DoctorProfileResponse struct {
+	Profile      models.DoctorProfile `json:"profile"`
+	Registration string               `json:"registration" example:"CRM/PA 123456"`
+}
+
+// SpecialtyListResponse é o payload de sucesso de GET /specialties
+type SpecialtyListResponse struct {
+	Specialties []models.Specialty `json:"specialties"`
+}
+
+// SetupStatusResponse é o payload de sucesso de GET /setup
+type SetupStatusResponse struct {
+	Available bool `json:"available"`
 }
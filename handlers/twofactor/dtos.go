@@ -0,0 +1,5 @@
+package twofactor
+
+type CodeDTO struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
@@ -0,0 +1,169 @@
+package twofactor
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	tfs "github.com/andresidrim/cesupa-hospital/services/twofactor"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service tfs.TwoFactorService
+}
+
+func NewHandler(service tfs.TwoFactorService) *Handler {
+	return &Handler{service: service}
+}
+
+// SetupTOTP inicia o cadastro do segundo fator do usuário logado
+// @Summary      Inicia o cadastro do TOTP
+// @Description  Gera o segredo e a URI otpauth:// para o QR code do aplicativo autenticador. O segundo fator só passa a valer depois de confirmado em /me/2fa/enable
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.TOTPSetupResponse
+// @Failure      409  {object}  handlers.ErrorResponse  "Already enabled"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to start two-factor setup"
+// @Router       /me/2fa/setup [post]
+func (h *Handler) SetupTOTP(c *gin.Context) {
+	enrollment, err := h.service.Setup(c.GetUint("userID"))
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to start two-factor setup: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": enrollment.Secret, "uri": enrollment.URI})
+}
+
+// EnableTOTP confirma o cadastro do segundo fator
+// @Summary      Ativa o TOTP
+// @Description  Confirma o cadastro com um código do aplicativo e devolve os códigos de recuperação, que não são mostrados de novo. Todas as sessões são encerradas
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      CodeDTO  true  "Código do aplicativo"
+// @Success      200      {object}  handlers.RecoveryCodesResponse
+// @Failure      400      {object}  handlers.ErrorResponse  "Invalid input or code"
+// @Failure      409      {object}  handlers.ErrorResponse  "Already enabled or setup not started"
+// @Failure      500      {object}  handlers.ErrorResponse  "Failed to enable two-factor"
+// @Router       /me/2fa/enable [post]
+func (h *Handler) EnableTOTP(c *gin.Context) {
+	var payload CodeDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	codes, err := h.service.Enable(c.GetUint("userID"), payload.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to enable two-factor: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// DisableTOTP desliga o segundo fator do usuário logado
+// @Summary      Desativa o TOTP
+// @Description  Exige um código do aplicativo ou de recuperação. Papéis com segundo fator obrigatório não podem desativar
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body  CodeDTO  true  "Código do aplicativo ou de recuperação"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid input or code"
+// @Failure      403  {object}  handlers.ErrorResponse  "Mandatory for this role"
+// @Failure      409  {object}  handlers.ErrorResponse  "Not enabled"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to disable two-factor"
+// @Router       /me/2fa/disable [post]
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	var payload CodeDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.service.Disable(c.GetUint("userID"), payload.Code); err != nil {
+		respondTwoFactorError(c, err, "Failed to disable two-factor: ")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes troca os códigos de recuperação
+// @Summary      Gera novos códigos de recuperação
+// @Description  Exige um código do aplicativo ou de recuperação; os códigos anteriores deixam de valer
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload  body      CodeDTO  true  "Código do aplicativo ou de recuperação"
+// @Success      200      {object}  handlers.RecoveryCodesResponse
+// @Failure      400      {object}  handlers.ErrorResponse  "Invalid input or code"
+// @Failure      409      {object}  handlers.ErrorResponse  "Not enabled"
+// @Failure      500      {object}  handlers.ErrorResponse  "Failed to regenerate recovery codes"
+// @Router       /me/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var payload CodeDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.GetUint("userID"), payload.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to regenerate recovery codes: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// ResetTOTP remove o segundo fator de um usuário
+// @Summary      Remove o TOTP de um usuário
+// @Description  Para quem perdeu o aplicativo e os códigos de recuperação: remove o segundo fator e encerra as sessões. Se o papel exigir TOTP, o usuário cadastra um novo no próximo acesso
+// @Tags         Usuários
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  int  true  "ID do usuário"
+// @Success      204
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "User not found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Not enabled"
+// @Failure      500  {object}  handlers.ErrorResponse  "Failed to reset two-factor"
+// @Router       /users/{id}/2fa [delete]
+func (h *Handler) ResetTOTP(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	if err := h.service.Reset(id); err != nil {
+		respondTwoFactorError(c, err, "Failed to reset two-factor: ")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondTwoFactorError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, tfs.ErrInvalidCode):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, tfs.ErrRequiredByRole):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, tfs.ErrAlreadyEnabled), errors.Is(err, tfs.ErrNotEnabled), errors.Is(err, tfs.ErrSetupRequired):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found: " + err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback + err.Error()})
+	}
+}
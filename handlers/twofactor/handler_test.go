@@ -0,0 +1,135 @@
+package twofactor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/middlewares"
+	"github.com/andresidrim/cesupa-hospital/mocks"
+	tfs "github.com/andresidrim/cesupa-hospital/services/twofactor"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRouter(ms *mocks.MockTwoFactorService) *gin.Engine {
+	h := NewHandler(ms)
+	r := gin.Default()
+	me := r.Group("/me/2fa", func(c *gin.Context) { c.Set("userID", uint(3)) })
+	me.POST("/setup", h.SetupTOTP)
+	me.POST("/enable", h.EnableTOTP)
+	me.POST("/disable", h.DisableTOTP)
+	me.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	r.DELETE("/users/:id/2fa", h.ResetTOTP)
+	return r
+}
+
+func TestTwoFactorHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "setup already enabled", method: http.MethodPost, path: "/me/2fa/setup", mockErr: tfs.ErrAlreadyEnabled, expectedStatus: http.StatusConflict, expectedBody: tfs.ErrAlreadyEnabled.Error()},
+		{name: "setup success", method: http.MethodPost, path: "/me/2fa/setup", expectedStatus: http.StatusOK, expectedBody: `"uri":"otpauth://totp/x"`},
+		{name: "enable missing code", method: http.MethodPost, path: "/me/2fa/enable", body: `{}`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid input"},
+		{name: "enable wrong code", method: http.MethodPost, path: "/me/2fa/enable", body: `{ "code": "000000" }`, mockErr: tfs.ErrInvalidCode, expectedStatus: http.StatusBadRequest, expectedBody: tfs.ErrInvalidCode.Error()},
+		{name: "enable without setup", method: http.MethodPost, path: "/me/2fa/enable", body: `{ "code": "123456" }`, mockErr: tfs.ErrSetupRequired, expectedStatus: http.StatusConflict, expectedBody: tfs.ErrSetupRequired.Error()},
+		{name: "enable success", method: http.MethodPost, path: "/me/2fa/enable", body: `{ "code": "123456" }`, expectedStatus: http.StatusOK, expectedBody: `"recoveryCodes":["abcde-fghjk"]`},
+		{name: "disable mandatory", method: http.MethodPost, path: "/me/2fa/disable", body: `{ "code": "123456" }`, mockErr: tfs.ErrRequiredByRole, expectedStatus: http.StatusForbidden, expectedBody: tfs.ErrRequiredByRole.Error()},
+		{name: "disable success", method: http.MethodPost, path: "/me/2fa/disable", body: `{ "code": "123456" }`, expectedStatus: http.StatusNoContent},
+		{name: "regenerate not enabled", method: http.MethodPost, path: "/me/2fa/recovery-codes", body: `{ "code": "123456" }`, mockErr: tfs.ErrNotEnabled, expectedStatus: http.StatusConflict, expectedBody: tfs.ErrNotEnabled.Error()},
+		{name: "regenerate success", method: http.MethodPost, path: "/me/2fa/recovery-codes", body: `{ "code": "123456" }`, expectedStatus: http.StatusOK, expectedBody: `"recoveryCodes"`},
+		{name: "reset invalid ID", method: http.MethodDelete, path: "/users/abc/2fa", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "reset not found", method: http.MethodDelete, path: "/users/1/2fa", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "User not found"},
+		{name: "reset service error", method: http.MethodDelete, path: "/users/1/2fa", mockErr: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedBody: "Failed to reset two-factor"},
+		{name: "reset success", method: http.MethodDelete, path: "/users/1/2fa", expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := func(userID uint, code string) ([]string, error) {
+				assert.Equal(t, uint(3), userID)
+				if tt.mockErr != nil {
+					return nil, tt.mockErr
+				}
+				return []string{"abcde-fghjk"}, nil
+			}
+			ms := &mocks.MockTwoFactorService{
+				MockSetup: func(userID uint) (*tfs.Enrollment, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &tfs.Enrollment{Secret: "SECRET", URI: "otpauth://totp/x"}, nil
+				},
+				MockEnable:                  codes,
+				MockRegenerateRecoveryCodes: codes,
+				MockDisable: func(userID uint, code string) error {
+					return tt.mockErr
+				},
+				MockReset: func(userID uint64) error {
+					assert.Equal(t, uint64(1), userID)
+					return tt.mockErr
+				},
+			}
+			r := setupRouter(ms)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+// testSigner assina os tokens dos testes com a configuração padrão
+func testSigner(t *testing.T) *utils.JWTSigner {
+	cfg := config.Default().JWT
+	cfg.SecretKey = "0123456789abcdef0123456789abcdef"
+	signer, err := utils.NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	return signer
+}
+
+func TestTOTPEnrollmentIsEnforced(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := testSigner(t)
+
+	r := gin.Default()
+	protected := r.Group("/")
+	protected.Use(
+		middlewares.JWTAuthMiddleware(signer, &mocks.MockSessionService{}),
+		middlewares.TOTPEnrollmentMiddleware("/me/2fa/setup"),
+	)
+	protected.POST("/me/2fa/setup", NewHandler(&mocks.MockTwoFactorService{}).SetupTOTP)
+	protected.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	pending, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Admin, SessionID: 1, MustEnrollTOTP: true})
+	assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "/users", pending))
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/me/2fa/setup", pending))
+
+	enrolled, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Admin, SessionID: 2})
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/users", enrolled))
+}
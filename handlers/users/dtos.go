@@ -0,0 +1,22 @@
+package users
+
+import (
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/enums"
+)
+
+type UpdateUserDTO struct {
+	Name     string     `json:"name" binding:"required"`
+	CPF      cpf.CPF    `json:"cpf" binding:"required,cpf" swaggertype:"string" example:"529.982.247-25"`
+	Role     enums.Role `json:"role" binding:"required,role" enums:"recepcionist,doctor,admin,nurse,pharmacist,lab_technician"`
+	Password *string    `json:"password"`
+	Active   *bool      `json:"active"`
+}
+
+type PatchUserDTO struct {
+	Name     *string     `json:"name" binding:"omitempty,min=1"`
+	CPF      *cpf.CPF    `json:"cpf" binding:"omitempty,cpf" swaggertype:"string" example:"529.982.247-25"`
+	Role     *enums.Role `json:"role" binding:"omitempty,role" enums:"recepcionist,doctor,admin,nurse,pharmacist,lab_technician"`
+	Password *string     `json:"password"`
+	Active   *bool       `json:"active"`
+}
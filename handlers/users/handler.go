@@ -1,13 +1,18 @@
 package users
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/andresidrim/cesupa-hospital/enums"
+	passwordsHandler "github.com/andresidrim/cesupa-hospital/handlers/passwords"
 	us "github.com/andresidrim/cesupa-hospital/services/users"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
@@ -47,20 +52,26 @@ func (h *Handler) GetUser(c *gin.Context) {
 
 // GetAllUsers lista usuários, opcionalmente filtrados por papel(s)
 // @Summary      Lista usuários
-// @Description  Retorna todos os usuários, podendo filtrar por um ou mais papéis
+// @Description  Retorna todos os usuários, podendo filtrar por um ou mais papéis (recepcionist, doctor, admin, nurse, pharmacist, lab_technician)
 // @Tags         Usuários
 // @Accept       json
 // @Produce      json
 // @Param        roles  query     []string  false  "Filtro de papéis separados por vírgula"
 // @Success      200    {array}   models.User
+// @Failure      400    {object}  ErrorResponse      "Invalid input"
 // @Failure      404    {object}  ErrorResponse      "No users were found"
 // @Router       /users [get]
 func (h *Handler) GetAllUsers(c *gin.Context) {
 	rawRoles := c.Query("roles")
 	var roles []enums.Role
 	if rawRoles != "" {
-		for _, role := range strings.Split(rawRoles, ",") {
-			roles = append(roles, enums.Role(strings.TrimSpace(role)))
+		for _, value := range strings.Split(rawRoles, ",") {
+			role := enums.Role(strings.TrimSpace(value))
+			if !slices.Contains(enums.Roles, role) {
+				c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid input: unknown role %q", role)})
+				return
+			}
+			roles = append(roles, role)
 		}
 	}
 
@@ -75,19 +86,149 @@ func (h *Handler) GetAllUsers(c *gin.Context) {
 
 // GetDoctors retorna apenas os usuários com papel de médico
 // @Summary      Lista médicos
-// @Description  Retorna todos os usuários cujo papel é 'doctor'
+// @Description  Retorna os usuários ativos cujo papel é 'doctor', com CRM e especialidades, podendo filtrar por especialidade
 // @Tags         Usuários
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.User
-// @Failure      404  {object}  ErrorResponse      "No doctors found"
+// @Param        specialty  query     string  false  "Nome da especialidade (ex.: Cardiologia)"
+// @Success      200        {array}   models.User
+// @Failure      404        {object}  ErrorResponse      "No doctors found"
 // @Router       /doctors [get]
 func (h *Handler) GetDoctors(c *gin.Context) {
-	// força o filtro de papel "doctor"
-	users, err := h.service.GetAll([]enums.Role{enums.Doctor})
+	doctors, err := h.service.GetDoctors(c.Query("specialty"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No doctors found: " + err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"doctors": users})
+	c.JSON(http.StatusOK, gin.H{"doctors": doctors})
+}
+
+// UpdateUser substitui os dados de um usuário
+// @Summary      Atualiza usuário
+// @Description  Substitui nome, CPF e papel do usuário; senha e situação (ativo) são opcionais. Senha definida aqui precisa ser trocada pelo usuário no próximo acesso
+// @Tags         Usuários
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int            true  "ID do usuário"
+// @Param        usuario  body      UpdateUserDTO  true  "Dados do usuário"
+// @Success      200      {object}  models.User
+// @Failure      400      {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404      {object}  ErrorResponse  "User not found"
+// @Failure      409      {object}  ErrorResponse  "Last admin or duplicate CPF"
+// @Failure      422      {object}  handlers.PasswordPolicyResponse  "Password rejected by policy"
+// @Failure      500      {object}  ErrorResponse  "Failed to update user"
+// @Router       /users/{id} [put]
+func (h *Handler) UpdateUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload UpdateUserDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	user, err := h.service.Update(id, us.UserUpdate{
+		Name:     &payload.Name,
+		CPF:      &payload.CPF,
+		Role:     &payload.Role,
+		Password: payload.Password,
+		Active:   payload.Active,
+	})
+	if err != nil {
+		respondUserError(c, err, "Failed to update user: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// PatchUser altera parte dos dados de um usuário
+// @Summary      Altera usuário
+// @Description  Altera apenas os campos enviados: nome, CPF, papel, senha ou situação (ativo). Senha definida aqui precisa ser trocada pelo usuário no próximo acesso
+// @Tags         Usuários
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int           true  "ID do usuário"
+// @Param        usuario  body      PatchUserDTO  true  "Campos alterados"
+// @Success      200      {object}  models.User
+// @Failure      400      {object}  ErrorResponse  "Invalid ID or Input"
+// @Failure      404      {object}  ErrorResponse  "User not found"
+// @Failure      409      {object}  ErrorResponse  "Last admin or duplicate CPF"
+// @Failure      422      {object}  handlers.PasswordPolicyResponse  "Password rejected by policy"
+// @Failure      500      {object}  ErrorResponse  "Failed to update user"
+// @Router       /users/{id} [patch]
+func (h *Handler) PatchUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	var payload PatchUserDTO
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input: " + err.Error()})
+		return
+	}
+
+	user, err := h.service.Update(id, us.UserUpdate{
+		Name:     payload.Name,
+		CPF:      payload.CPF,
+		Role:     payload.Role,
+		Password: payload.Password,
+		Active:   payload.Active,
+	})
+	if err != nil {
+		respondUserError(c, err, "Failed to update user: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// DeleteUser desativa um usuário
+// @Summary      Desativa usuário
+// @Description  Desliga o usuário sem apagá-lo: ele deixa de fazer login e de receber consultas, mas o histórico é mantido
+// @Tags         Usuários
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID do usuário"
+// @Success      200  {object}  models.User
+// @Failure      400  {object}  ErrorResponse  "Invalid ID"
+// @Failure      404  {object}  ErrorResponse  "User not found"
+// @Failure      409  {object}  ErrorResponse  "Last admin or already inactive"
+// @Failure      500  {object}  ErrorResponse  "Failed to deactivate user"
+// @Router       /users/{id} [delete]
+func (h *Handler) DeleteUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID: " + err.Error()})
+		return
+	}
+
+	user, err := h.service.Deactivate(id)
+	if err != nil {
+		respondUserError(c, err, "Failed to deactivate user: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func respondUserError(c *gin.Context, err error, fallback string) {
+	if passwordsHandler.RespondPolicyError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found: " + err.Error()})
+	case errors.Is(err, us.ErrLastAdmin), errors.Is(err, us.ErrDuplicateCPF), errors.Is(err, us.ErrInactive):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback + err.Error()})
+	}
 }
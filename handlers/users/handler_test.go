@@ -4,18 +4,34 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/middlewares"
 	"github.com/andresidrim/cesupa-hospital/mocks"
 	"github.com/andresidrim/cesupa-hospital/models"
+	pws "github.com/andresidrim/cesupa-hospital/services/passwords"
+	ss "github.com/andresidrim/cesupa-hospital/services/sessions"
+	us "github.com/andresidrim/cesupa-hospital/services/users"
 	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/andresidrim/cesupa-hospital/validators"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
 
+func TestMain(m *testing.M) {
+	if err := validators.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
 func setupGetUserRouter(ms *mocks.MockUserService) *gin.Engine {
 	h := NewHandler(ms)
 	r := gin.Default()
@@ -118,6 +134,23 @@ func TestGetAllUsersHandler(t *testing.T) {
 			expectedLen:    1,
 			expectedBody:   `"name":"B"`,
 		},
+		{
+			name:  "with clinical staff roles",
+			query: "?roles=nurse,%20pharmacist,lab_technician",
+			mockGetAll: func(roles []enums.Role) ([]models.User, error) {
+				assert.Equal(t, []enums.Role{enums.Nurse, enums.Pharmacist, enums.LabTechnician}, roles)
+				return []models.User{{Name: "C"}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+			expectedBody:   `"name":"C"`,
+		},
+		{
+			name:           "unknown role",
+			query:          "?roles=doctor,janitor",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `unknown role \"janitor\"`,
+		},
 		{
 			name:           "service error",
 			query:          "",
@@ -165,15 +198,14 @@ func TestGetDoctorsHandler(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		mockGetAll     func(roles []enums.Role) ([]models.User, error)
+		query          string
+		mockGetDoctors func(specialty string) ([]models.User, error)
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name: "service error",
-			mockGetAll: func(roles []enums.Role) ([]models.User, error) {
-				// o handler deverá chamar GetAll com apenas enums.Doctor
-				assert.Equal(t, []enums.Role{enums.Doctor}, roles)
+			mockGetDoctors: func(specialty string) ([]models.User, error) {
 				return nil, assert.AnError
 			},
 			expectedStatus: http.StatusNotFound,
@@ -181,8 +213,8 @@ func TestGetDoctorsHandler(t *testing.T) {
 		},
 		{
 			name: "empty list",
-			mockGetAll: func(roles []enums.Role) ([]models.User, error) {
-				assert.Equal(t, []enums.Role{enums.Doctor}, roles)
+			mockGetDoctors: func(specialty string) ([]models.User, error) {
+				assert.Empty(t, specialty)
 				return []models.User{}, nil
 			},
 			expectedStatus: http.StatusOK,
@@ -190,8 +222,7 @@ func TestGetDoctorsHandler(t *testing.T) {
 		},
 		{
 			name: "success",
-			mockGetAll: func(roles []enums.Role) ([]models.User, error) {
-				assert.Equal(t, []enums.Role{enums.Doctor}, roles)
+			mockGetDoctors: func(specialty string) ([]models.User, error) {
 				return []models.User{
 					{Model: gorm.Model{ID: 5}, Name: "Dr. Who"},
 				}, nil
@@ -199,14 +230,31 @@ func TestGetDoctorsHandler(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"name":"Dr. Who"`,
 		},
+		{
+			name:  "by specialty",
+			query: "?specialty=Cardiologia",
+			mockGetDoctors: func(specialty string) ([]models.User, error) {
+				assert.Equal(t, "Cardiologia", specialty)
+				return []models.User{{
+					Model: gorm.Model{ID: 6},
+					Name:  "Dr. House",
+					DoctorProfile: &models.DoctorProfile{
+						CRM: "123456", CRMUF: "PA",
+						Specialties: []models.Specialty{{ID: 1, Name: "Cardiologia"}},
+					},
+				}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"crmUf":"PA"`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ms := &mocks.MockUserService{MockGetAll: tt.mockGetAll}
+			ms := &mocks.MockUserService{MockGetDoctors: tt.mockGetDoctors}
 			router := setupGetDoctorsRouter(ms)
 
-			req := httptest.NewRequest("GET", "/doctors", nil)
+			req := httptest.NewRequest("GET", "/doctors"+tt.query, nil)
 			w := httptest.NewRecorder()
 			router.ServeHTTP(w, req)
 
@@ -216,18 +264,21 @@ func TestGetDoctorsHandler(t *testing.T) {
 	}
 }
 
+// testSigner assina os tokens dos testes com a configuração padrão
+func testSigner(t *testing.T) *utils.JWTSigner {
+	cfg := config.Default().JWT
+	cfg.SecretKey = "0123456789abcdef0123456789abcdef"
+	signer, err := utils.NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	return signer
+}
+
 func TestGetDoctorsAuth(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-
-	mockUserSvc := &mocks.MockUserService{
-		// Retorna um user com papel "receptionist", mas rota só para rec+admin
-		MockGet: func(id uint64) (*models.User, error) {
-			return &models.User{Model: gorm.Model{ID: uint(id)}, Role: enums.Doctor}, nil
-		},
-	}
+	signer := testSigner(t)
 
 	mockUserListSvc := &mocks.MockUserService{
-		MockGetAll: func(roles []enums.Role) ([]models.User, error) {
+		MockGetDoctors: func(specialty string) ([]models.User, error) {
 			return []models.User{{Name: "ShouldNotShow"}}, nil
 		},
 	}
@@ -236,7 +287,7 @@ func TestGetDoctorsAuth(t *testing.T) {
 	r := gin.Default()
 	protected := r.Group("/")
 	protected.Use(
-		middlewares.JWTAuthMiddleware(mockUserSvc),
+		middlewares.JWTAuthMiddleware(signer, &mocks.MockSessionService{}),
 		// rota /doctors só para receptionist ou admin
 		middlewares.RoleMiddleware(enums.Receptionist, enums.Admin),
 	)
@@ -247,10 +298,244 @@ func TestGetDoctorsAuth(t *testing.T) {
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-	token, _ := utils.GenerateJWT(1)
+	// token de médico, mas rota só para rec+admin
+	token, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Doctor, SessionID: 1})
 	w = httptest.NewRecorder()
 	req = httptest.NewRequest("GET", "/doctors", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
+
+func TestUpdateUserHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockErr        error
+		check          func(t *testing.T, changes us.UserUpdate)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "invalid ID",
+			method:         http.MethodPut,
+			path:           "/users/abc",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid ID",
+		},
+		{
+			name:           "put requires every field",
+			method:         http.MethodPut,
+			path:           "/users/1",
+			body:           `{ "name": "Bob" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:           "put with unknown role",
+			method:         http.MethodPut,
+			path:           "/users/1",
+			body:           `{ "name": "Bob", "cpf": "529.982.247-25", "role": "janitor" }`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid input",
+		},
+		{
+			name:   "put success",
+			method: http.MethodPut,
+			path:   "/users/1",
+			body:   `{ "name": "Bob", "cpf": "529.982.247-25", "role": "doctor" }`,
+			check: func(t *testing.T, changes us.UserUpdate) {
+				assert.Equal(t, "Bob", *changes.Name)
+				assert.Equal(t, cpf.CPF("52998224725"), *changes.CPF)
+				assert.Equal(t, enums.Doctor, *changes.Role)
+				assert.Nil(t, changes.Password)
+				assert.Nil(t, changes.Active)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"user"`,
+		},
+		{
+			name:   "patch only role",
+			method: http.MethodPatch,
+			path:   "/users/1",
+			body:   `{ "role": "recepcionist" }`,
+			check: func(t *testing.T, changes us.UserUpdate) {
+				assert.Nil(t, changes.Name)
+				assert.Nil(t, changes.CPF)
+				assert.Equal(t, enums.Receptionist, *changes.Role)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "patch password reset",
+			method: http.MethodPatch,
+			path:   "/users/1",
+			body:   `{ "password": "new-secret" }`,
+			check: func(t *testing.T, changes us.UserUpdate) {
+				assert.Equal(t, "new-secret", *changes.Password)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			// a política de senha é aplicada pelo serviço, não no binding
+			name:           "patch password rejected by policy",
+			method:         http.MethodPatch,
+			path:           "/users/1",
+			body:           `{ "password": "123" }`,
+			mockErr:        &pws.PolicyError{Violations: []string{"must have at least 8 characters"}},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"violations":["must have at least 8 characters"]`,
+		},
+		{
+			name:           "patch reused password",
+			method:         http.MethodPatch,
+			path:           "/users/1",
+			body:           `{ "password": "Old-Password1" }`,
+			mockErr:        pws.ErrPasswordReused,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   pws.ErrPasswordReused.Error(),
+		},
+		{
+			name:           "patch demoting last admin",
+			method:         http.MethodPatch,
+			path:           "/users/1",
+			body:           `{ "role": "doctor" }`,
+			mockErr:        us.ErrLastAdmin,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "last active admin",
+		},
+		{
+			name:           "patch duplicate cpf",
+			method:         http.MethodPatch,
+			path:           "/users/1",
+			body:           `{ "cpf": "52998224725" }`,
+			mockErr:        us.ErrDuplicateCPF,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "cpf already belongs",
+		},
+		{
+			name:           "patch not found",
+			method:         http.MethodPatch,
+			path:           "/users/1",
+			body:           `{ "active": false }`,
+			mockErr:        gorm.ErrRecordNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "User not found",
+		},
+		{
+			name:           "patch failure",
+			method:         http.MethodPatch,
+			path:           "/users/1",
+			body:           `{ "active": true }`,
+			mockErr:        assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "Failed to update user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockUserService{
+				MockUpdate: func(id uint64, changes us.UserUpdate) (*models.User, error) {
+					assert.Equal(t, uint64(1), id)
+					if tt.check != nil {
+						tt.check(t, changes)
+					}
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					return &models.User{Model: gorm.Model{ID: 1}}, nil
+				},
+			}
+			h := NewHandler(ms)
+			r := gin.Default()
+			r.PUT("/users/:id", h.UpdateUser)
+			r.PATCH("/users/:id", h.PatchUser)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestDeleteUserHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		paramID        string
+		mockErr        error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "invalid ID", paramID: "abc", expectedStatus: http.StatusBadRequest, expectedBody: "Invalid ID"},
+		{name: "not found", paramID: "1", mockErr: gorm.ErrRecordNotFound, expectedStatus: http.StatusNotFound, expectedBody: "User not found"},
+		{name: "last admin", paramID: "1", mockErr: us.ErrLastAdmin, expectedStatus: http.StatusConflict, expectedBody: "last active admin"},
+		{name: "already inactive", paramID: "1", mockErr: us.ErrInactive, expectedStatus: http.StatusConflict, expectedBody: "already inactive"},
+		{name: "success", paramID: "1", expectedStatus: http.StatusOK, expectedBody: `"deactivatedAt"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &mocks.MockUserService{
+				MockDeactivate: func(id uint64) (*models.User, error) {
+					if tt.mockErr != nil {
+						return nil, tt.mockErr
+					}
+					now := time.Now()
+					return &models.User{Model: gorm.Model{ID: uint(id)}, DeactivatedAt: &now}, nil
+				},
+			}
+			h := NewHandler(ms)
+			r := gin.Default()
+			r.DELETE("/users/:id", h.DeleteUser)
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/"+tt.paramID, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestInactiveUserIsRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := testSigner(t)
+
+	ms := &mocks.MockUserService{
+		MockGetAll: func(roles []enums.Role) ([]models.User, error) {
+			return []models.User{}, nil
+		},
+	}
+	// a sessão é validada junto com o usuário dono dela
+	sessions := &mocks.MockSessionService{
+		MockValidate: func(sessionID, userID uint) error {
+			return ss.ErrUserInactive
+		},
+	}
+
+	r := gin.Default()
+	r.Use(middlewares.JWTAuthMiddleware(signer, sessions))
+	r.GET("/users", NewHandler(ms).GetAllUsers)
+
+	token, _ := signer.Generate(utils.TokenSubject{UserID: 1, Role: enums.Admin, SessionID: 1})
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "User inactive")
+}
@@ -21,21 +21,43 @@
 package main
 
 import (
+	"log"
+
+	"github.com/andresidrim/cesupa-hospital/config"
 	"github.com/andresidrim/cesupa-hospital/database"
-	"github.com/andresidrim/cesupa-hospital/env"
 	"github.com/gin-contrib/cors"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	auditHandler "github.com/andresidrim/cesupa-hospital/handlers/audit"
 	authHandlers "github.com/andresidrim/cesupa-hospital/handlers/auth"
+	availabilityHandler "github.com/andresidrim/cesupa-hospital/handlers/availability"
+	doctorsHandler "github.com/andresidrim/cesupa-hospital/handlers/doctors"
+	lockoutsHandler "github.com/andresidrim/cesupa-hospital/handlers/lockouts"
 	pacientsHandler "github.com/andresidrim/cesupa-hospital/handlers/pacients"
+	passwordsHandler "github.com/andresidrim/cesupa-hospital/handlers/passwords"
+	permissionsHandler "github.com/andresidrim/cesupa-hospital/handlers/permissions"
+	sessionsHandler "github.com/andresidrim/cesupa-hospital/handlers/sessions"
+	setupHandler "github.com/andresidrim/cesupa-hospital/handlers/setup"
+	twoFactorHandler "github.com/andresidrim/cesupa-hospital/handlers/twofactor"
 	usersHandler "github.com/andresidrim/cesupa-hospital/handlers/users"
 
+	auditService "github.com/andresidrim/cesupa-hospital/services/audit"
 	authServices "github.com/andresidrim/cesupa-hospital/services/auth"
+	availabilityService "github.com/andresidrim/cesupa-hospital/services/availability"
+	doctorsService "github.com/andresidrim/cesupa-hospital/services/doctors"
+	lockoutsService "github.com/andresidrim/cesupa-hospital/services/lockouts"
 	pacientsService "github.com/andresidrim/cesupa-hospital/services/pacients"
+	passwordsService "github.com/andresidrim/cesupa-hospital/services/passwords"
+	permissionsService "github.com/andresidrim/cesupa-hospital/services/permissions"
+	sessionsService "github.com/andresidrim/cesupa-hospital/services/sessions"
+	setupService "github.com/andresidrim/cesupa-hospital/services/setup"
+	twoFactorService "github.com/andresidrim/cesupa-hospital/services/twofactor"
 	usersService "github.com/andresidrim/cesupa-hospital/services/users"
 
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/middlewares"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/andresidrim/cesupa-hospital/validators"
 	"github.com/gin-gonic/gin"
 
 	swaggerFiles "github.com/swaggo/files"
@@ -44,24 +66,99 @@ import (
 )
 
 func main() {
+	// Configuração (padrões, CONFIG_FILE e variáveis de ambiente): qualquer
+	// valor inválido impede a API de subir
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Log.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// Validações customizadas de binding (ex.: cpf)
+	if err := validators.Register(); err != nil {
+		log.Fatalf("failed to register validators: %v", err)
+	}
+
+	// Chaves de assinatura dos JWTs (HS256 ou RS256 com kid)
+	jwtSigner, err := utils.NewJWTSigner(cfg.JWT)
+	if err != nil {
+		log.Fatalf("failed to load JWT keys: %v", err)
+	}
+
 	// Conexão ao banco
-	db := database.Connect()
+	db := database.Connect(cfg.Database, cfg.Log.Level)
+
+	// Matriz de permissões por papel, carregada do banco
+	permissionSvc := permissionsService.NewService(db)
+	if err := permissionSvc.Load(); err != nil {
+		log.Fatalf("failed to load permissions: %v", err)
+	}
 
 	// Services
-	pacientSvc := pacientsService.NewService(db)
-	userSvc := usersService.NewService(db)
-	authSvc := authServices.NewService(db)
+	pacientSvc := pacientsService.NewService(db, cfg.Location, cfg.Emergency)
+	userSvc := usersService.NewService(db, cfg.Password)
+	sessionSvc := sessionsService.NewService(db, cfg.Session)
+	lockoutSvc := lockoutsService.NewService(db, cfg.Login)
+	authSvc := authServices.NewService(db, sessionSvc, lockoutSvc, jwtSigner, passwordsService.NewPolicy(cfg.Password), cfg.TOTP)
+	availabilitySvc := availabilityService.NewService(db, cfg.Location)
+	passwordSvc := passwordsService.NewService(db, cfg.Password)
+	twoFactorSvc := twoFactorService.NewService(db, cfg.TOTP)
+	auditSvc := auditService.NewService(db)
+	doctorSvc := doctorsService.NewService(db)
+	setupSvc := setupService.NewService(db, cfg.Password)
+
+	// Banco sem usuários: o primeiro admin é criado em POST /setup/admin com o
+	// token mostrado aqui, que só vale até esse admin existir
+	setupToken, err := setupSvc.Start()
+	if err != nil {
+		log.Fatalf("failed to check setup: %v", err)
+	}
+	if setupToken != "" {
+		log.Printf("No users yet: create the first admin with POST /setup/admin and the header %s: %s", setupHandler.TokenHeader, setupToken)
+	}
 
 	// Handlers
-	pacientH := pacientsHandler.NewHandler(pacientSvc)
+	pacientH := pacientsHandler.NewHandler(pacientSvc, userSvc, permissionSvc, cfg.Location)
 	userH := usersHandler.NewHandler(userSvc)
 	authH := authHandlers.NewHandler(authSvc)
+	availabilityH := availabilityHandler.NewHandler(availabilitySvc)
+	sessionH := sessionsHandler.NewHandler(sessionSvc)
+	lockoutH := lockoutsHandler.NewHandler(lockoutSvc)
+	passwordH := passwordsHandler.NewHandler(passwordSvc)
+	twoFactorH := twoFactorHandler.NewHandler(twoFactorSvc)
+	auditH := auditHandler.NewHandler(auditSvc)
+	permissionH := permissionsHandler.NewHandler(permissionSvc)
+	doctorH := doctorsHandler.NewHandler(doctorSvc)
+	setupH := setupHandler.NewHandler(setupSvc)
 
 	// Middlewares
-	jwtMw := middlewares.JWTAuthMiddleware(userSvc)
-	roleAdmin := middlewares.RoleMiddleware(enums.Admin)
-	roleRecepAdmin := middlewares.RoleMiddleware(enums.Receptionist, enums.Admin)
-	roleRecepDoctor := middlewares.RoleMiddleware(enums.Receptionist, enums.Doctor)
+	jwtMw := middlewares.JWTAuthMiddleware(jwtSigner, sessionSvc)
+	// Quem precisa trocar a senha só consegue trocá-la ou sair
+	passwordChangeMw := middlewares.PasswordChangeMiddleware("/me/password", "/logout")
+	// Papéis com segundo fator obrigatório só cadastram o TOTP, trocam a senha ou saem
+	totpEnrollmentMw := middlewares.TOTPEnrollmentMiddleware("/me/2fa/setup", "/me/2fa/enable", "/me/password", "/logout")
+	// Trilha de auditoria (LGPD) de todo acesso a pacientes e usuários
+	auditPacient := middlewares.AuditMiddleware(auditSvc, middlewares.AuditTarget{
+		Type: enums.AuditPacient,
+		Load: func(id uint64) (any, error) { return pacientSvc.Get(id) },
+	})
+	// Consultas trazem e alteram dados do paciente; o :id da rota é o da
+	// consulta, usado para o antes e o depois das alterações
+	auditAppointment := middlewares.AuditMiddleware(auditSvc, middlewares.AuditTarget{
+		Type:           enums.AuditPacient,
+		Load:           func(id uint64) (any, error) { return pacientSvc.GetAppointment(id) },
+		ForeignRouteID: true,
+	})
+	auditUser := middlewares.AuditMiddleware(auditSvc, middlewares.AuditTarget{
+		Type: enums.AuditUser,
+		Load: func(id uint64) (any, error) { return userSvc.Get(id) },
+	})
+	// Autorização pela matriz papel → permissões (tabela role_permissions)
+	can := func(permission enums.Permission) gin.HandlerFunc {
+		return middlewares.RequirePermission(permissionSvc, permission)
+	}
 
 	// Setup Gin
 	r := gin.Default()
@@ -72,76 +169,307 @@ func main() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
+		AllowOrigins:     cfg.Server.AllowedOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", setupHandler.TokenHeader},
 		AllowCredentials: true,
 	}))
 
-	// Rota pública de login
+	// Rotas públicas de login (com segundo passo para quem tem TOTP) e renovação do token
 	r.POST("/login", authH.Login)
+	r.POST("/login/2fa", authH.VerifyTOTP)
+	r.POST("/token/refresh", authH.Refresh)
 
-	// Tudo que vier a seguir exige JWT
+	// Rota pública de redefinição de senha com token emitido pelo admin
+	r.POST("/password/reset", passwordH.ResetPassword)
+
+	// Setup inicial: criação do primeiro admin, só enquanto não houver usuários
+	r.GET("/setup", setupH.GetStatus)
+	r.POST("/setup/admin", auditUser, setupH.CreateAdmin)
+
+	// Tudo que vier a seguir exige JWT; os papéis indicados são os da matriz padrão de permissões
 	authGroup := r.Group("/")
-	authGroup.Use(jwtMw)
+	authGroup.Use(jwtMw, passwordChangeMw, totpEnrollmentMw)
 	{
+		// Encerrar a própria sessão → qualquer usuário logado
+		authGroup.POST("/logout", authH.Logout)
+
+		// Trocar a própria senha → qualquer usuário logado
+		authGroup.POST("/me/password", passwordH.ChangeMyPassword)
+
+		// Segundo fator (TOTP) do próprio usuário → qualquer usuário logado
+		authGroup.POST("/me/2fa/setup", twoFactorH.SetupTOTP)
+		authGroup.POST("/me/2fa/enable", twoFactorH.EnableTOTP)
+		authGroup.POST("/me/2fa/disable", twoFactorH.DisableTOTP)
+		authGroup.POST("/me/2fa/recovery-codes", twoFactorH.RegenerateRecoveryCodes)
+
 		// Registro só por Admin
 		authGroup.POST("/register",
-			roleAdmin,
+			can(enums.PermUserCreate),
 			authH.Register,
 		)
 
-		// Listar médicos (para agendamento) → Recepcionist ou Admin
+		// Listar médicos (para agendamento, com ?specialty=) → qualquer funcionário
 		authGroup.GET("/doctors",
-			roleRecepAdmin,
+			can(enums.PermDoctorRead),
 			userH.GetDoctors,
 		)
 
+		// Perfil profissional do médico (CRM, especialidades, duração da consulta)
+		// Consulta → qualquer funcionário; alteração → Admin
+		authGroup.GET("/doctors/:id/profile",
+			can(enums.PermDoctorRead),
+			doctorH.GetProfile,
+		)
+		authGroup.PUT("/doctors/:id/profile",
+			auditUser,
+			can(enums.PermDoctorProfile),
+			doctorH.SaveProfile,
+		)
+
+		// Catálogo de especialidades
+		// Consulta → qualquer funcionário; alteração → Admin
+		authGroup.GET("/specialties",
+			can(enums.PermDoctorRead),
+			doctorH.GetSpecialties,
+		)
+		authGroup.POST("/specialties",
+			can(enums.PermSpecialtyManage),
+			doctorH.AddSpecialty,
+		)
+		authGroup.DELETE("/specialties/:id",
+			can(enums.PermSpecialtyManage),
+			doctorH.DeleteSpecialty,
+		)
+
+		// Turnos semanais e bloqueios da agenda médica
+		// Consulta → qualquer funcionário; alteração → Recepcionist ou Admin
+		authGroup.GET("/doctors/:id/shifts",
+			can(enums.PermScheduleRead),
+			availabilityH.GetShifts,
+		)
+		authGroup.POST("/doctors/:id/shifts",
+			can(enums.PermScheduleWrite),
+			availabilityH.AddShift,
+		)
+		authGroup.PUT("/doctors/:id/shifts/:shiftId",
+			can(enums.PermScheduleWrite),
+			availabilityH.UpdateShift,
+		)
+		authGroup.DELETE("/doctors/:id/shifts/:shiftId",
+			can(enums.PermScheduleWrite),
+			availabilityH.DeleteShift,
+		)
+		authGroup.GET("/doctors/:id/blocks",
+			can(enums.PermScheduleRead),
+			availabilityH.GetBlocks,
+		)
+		authGroup.POST("/doctors/:id/blocks",
+			can(enums.PermScheduleWrite),
+			availabilityH.AddBlock,
+		)
+		authGroup.PUT("/doctors/:id/blocks/:blockId",
+			can(enums.PermScheduleWrite),
+			availabilityH.UpdateBlock,
+		)
+		authGroup.DELETE("/doctors/:id/blocks/:blockId",
+			can(enums.PermScheduleWrite),
+			availabilityH.DeleteBlock,
+		)
+
+		// Horários livres para agendamento → Recepcionist ou Admin
+		authGroup.GET("/doctors/:id/slots",
+			can(enums.PermSlotRead),
+			availabilityH.GetDoctorSlots,
+		)
+		authGroup.GET("/slots",
+			can(enums.PermSlotRead),
+			availabilityH.SearchSlots,
+		)
+
 		// 1. Cadastrar novo paciente → Recepcionist ou Admin
 		authGroup.POST("/pacients",
-			roleRecepAdmin,
+			auditPacient,
+			can(enums.PermPacientCreate),
 			pacientH.AddPacient,
 		)
 
-		// 2. Consultar dados de paciente → Recepcionist ou Doctor
+		// 2. Consultar dados de paciente → qualquer funcionário; médicos só veem
+		// os pacientes com quem têm consulta ou acesso de emergência
 		authGroup.GET("/pacients",
-			roleRecepDoctor,
+			auditPacient,
+			can(enums.PermPacientRead),
 			pacientH.GetAllPacients,
 		)
 		authGroup.GET("/pacients/:id",
-			roleRecepDoctor,
+			auditPacient,
+			can(enums.PermPacientRead),
 			pacientH.GetPacient,
 		)
 
+		// Acesso de emergência (quebra de vidro) a qualquer paciente → Doctor
+		authGroup.POST("/pacients/:id/emergency-access",
+			auditPacient,
+			can(enums.PermPacientEmergency),
+			pacientH.GrantEmergencyAccess,
+		)
+
+		// Revisão dos acessos de emergência → Admin
+		authGroup.GET("/emergency-accesses",
+			can(enums.PermAuditRead),
+			pacientH.ListEmergencyAccesses,
+		)
+
 		// 3. Atualizar dados de paciente → Recepcionist ou Admin
 		authGroup.PUT("/pacients/:id",
-			roleRecepAdmin,
+			auditPacient,
+			can(enums.PermPacientUpdate),
 			pacientH.UpdatePacient,
 		)
 
 		// 4. Inativar cadastro de paciente → Recepcionist ou Admin
 		authGroup.DELETE("/pacients/:id",
-			roleRecepAdmin,
+			auditPacient,
+			can(enums.PermPacientDeactivate),
 			pacientH.DeletePacient,
 		)
 
+		// Reativar cadastro de paciente → Recepcionist ou Admin
+		authGroup.POST("/pacients/:id/reactivate",
+			auditPacient,
+			can(enums.PermPacientDeactivate),
+			pacientH.ReactivatePacient,
+		)
+
 		// 5. Agendamento de consulta → Recepcionist ou Admin
 		authGroup.POST("/pacients/:id/appointment",
-			roleRecepAdmin,
+			auditPacient,
+			can(enums.PermAppointmentCreate),
 			pacientH.ScheduleAppointment,
 		)
 
+		// Consulta de consultas → qualquer funcionário
+		authGroup.GET("/appointments",
+			auditAppointment,
+			can(enums.PermAppointmentRead),
+			pacientH.ListAppointments,
+		)
+		authGroup.GET("/appointments/:id",
+			auditAppointment,
+			can(enums.PermAppointmentRead),
+			pacientH.GetAppointment,
+		)
+
+		// Agenda do médico logado → Doctor
+		authGroup.GET("/me/agenda",
+			auditAppointment,
+			can(enums.PermAgendaRead),
+			pacientH.GetMyAgenda,
+		)
+
+		// Ciclo de vida da consulta
+		// Cancelar e reagendar → Recepcionist ou Admin
+		// Atualizar status (check-in, atendimento, conclusão) → qualquer funcionário
+		authGroup.POST("/appointments/:id/cancel",
+			auditAppointment,
+			can(enums.PermAppointmentUpdate),
+			pacientH.CancelAppointment,
+		)
+		authGroup.POST("/appointments/:id/reschedule",
+			auditAppointment,
+			can(enums.PermAppointmentUpdate),
+			pacientH.RescheduleAppointment,
+		)
+		authGroup.PATCH("/appointments/:id/status",
+			auditAppointment,
+			can(enums.PermAppointmentStatus),
+			pacientH.UpdateAppointmentStatus,
+		)
+
 		// Gestão de usuários (listar e consultar) → apenas Admin
 		authGroup.GET("/users",
-			roleAdmin,
+			auditUser,
+			can(enums.PermUserRead),
 			userH.GetAllUsers,
 		)
 		authGroup.GET("/users/:id",
-			roleAdmin,
+			auditUser,
+			can(enums.PermUserRead),
 			userH.GetUser,
 		)
+
+		// Gestão de usuários (editar e desativar) → apenas Admin
+		authGroup.PUT("/users/:id",
+			auditUser,
+			can(enums.PermUserUpdate),
+			userH.UpdateUser,
+		)
+		authGroup.PATCH("/users/:id",
+			auditUser,
+			can(enums.PermUserUpdate),
+			userH.PatchUser,
+		)
+		authGroup.DELETE("/users/:id",
+			auditUser,
+			can(enums.PermUserUpdate),
+			userH.DeleteUser,
+		)
+
+		// Token de redefinição de senha → apenas Admin
+		authGroup.POST("/users/:id/password-reset",
+			auditUser,
+			can(enums.PermUserUpdate),
+			passwordH.IssueReset,
+		)
+
+		// Remover o segundo fator de quem perdeu o aplicativo → apenas Admin
+		authGroup.DELETE("/users/:id/2fa",
+			auditUser,
+			can(enums.PermUserUpdate),
+			twoFactorH.ResetTOTP,
+		)
+
+		// Permissões efetivas de um usuário → apenas Admin
+		authGroup.GET("/users/:id/permissions",
+			auditUser,
+			can(enums.PermPermissionRead),
+			permissionH.GetUserPermissions,
+		)
+
+		// Sessões do usuário (listar e revogar) → apenas Admin
+		authGroup.GET("/users/:id/sessions",
+			auditUser,
+			can(enums.PermSessionManage),
+			sessionH.GetUserSessions,
+		)
+		authGroup.DELETE("/users/:id/sessions",
+			auditUser,
+			can(enums.PermSessionManage),
+			sessionH.RevokeAllSessions,
+		)
+		authGroup.DELETE("/users/:id/sessions/:sessionId",
+			auditUser,
+			can(enums.PermSessionManage),
+			sessionH.RevokeSession,
+		)
+
+		// Trilha de auditoria → apenas Admin
+		authGroup.GET("/audit",
+			can(enums.PermAuditRead),
+			auditH.GetAuditLogs,
+		)
+
+		// Bloqueios de login por excesso de falhas (consultar e liberar) → apenas Admin
+		authGroup.GET("/lockouts",
+			can(enums.PermLockoutManage),
+			lockoutH.GetLockouts,
+		)
+		authGroup.DELETE("/lockouts/:id",
+			can(enums.PermLockoutManage),
+			lockoutH.ClearLockout,
+		)
 	}
 
 	// Start server
-	r.Run(":" + env.PORT)
+	r.Run(":" + cfg.Server.Port)
 }
@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditResourceIDKey é a chave do contexto onde os handlers de criação
+// informam o ID do registro criado, que não está na rota.
+const AuditResourceIDKey = "auditResourceID"
+
+// AuditSubjectsKey é a chave do contexto onde os handlers informam os IDs dos
+// registros devolvidos quando eles não são o da rota: as listas e as consultas,
+// que trazem dados dos pacientes. Cada um vira uma linha na trilha.
+const AuditSubjectsKey = "auditSubjects"
+
+// AuditEmergencyKey é a chave do contexto que marca a requisição como feita
+// sob acesso de emergência ("quebra de vidro"), destacada na auditoria.
+const AuditEmergencyKey = "auditEmergency"
+
+// AuditTarget descreve o recurso auditado. Load busca o registro pelo ID
+// para montar o antes e o depois das alterações. ForeignRouteID vale para
+// rotas cujo :id é de outro registro (ex.: /appointments/:id): o :id só serve
+// para Load, e os registros auditados vêm de AuditSubjectsKey.
+type AuditTarget struct {
+	Type           enums.AuditResource
+	Load           func(id uint64) (any, error)
+	ForeignRouteID bool
+}
+
+// AuditMiddleware grava na trilha de auditoria cada requisição ao recurso,
+// inclusive as negadas, com o usuário, a rota, o status, o IP e o user
+// agent. Em escritas bem-sucedidas grava também o diff do registro. Deve
+// vir depois do JWTAuthMiddleware e antes das checagens de papel.
+func AuditMiddleware(service audit.AuditService, target AuditTarget) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, hasID := routeID(c)
+		write := c.Request.Method != http.MethodGet
+
+		var before any
+		if write && hasID && target.Load != nil {
+			before, _ = target.Load(id)
+		}
+
+		c.Next()
+
+		action := auditAction(c.Request.Method)
+		if target.ForeignRouteID && hasID && action == enums.AuditCreate {
+			// POST em /appointments/:id/cancel e afins altera o registro da rota
+			action = enums.AuditUpdate
+		}
+
+		entry := models.AuditLog{
+			UserID:       c.GetUint("userID"),
+			Action:       action,
+			Method:       c.Request.Method,
+			Route:        c.FullPath(),
+			Path:         c.Request.URL.RequestURI(),
+			ResourceType: target.Type,
+			Status:       c.Writer.Status(),
+			IP:           c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+			Emergency:    c.GetBool(AuditEmergencyKey),
+		}
+
+		if !hasID {
+			if created := c.GetUint(AuditResourceIDKey); created != 0 {
+				id, hasID = uint64(created), true
+			}
+		}
+		if hasID && !target.ForeignRouteID {
+			resourceID := uint(id)
+			entry.ResourceID = &resourceID
+		}
+
+		if write && hasID && target.Load != nil && entry.Status < http.StatusBadRequest {
+			after, _ := target.Load(id)
+			changes, err := audit.Diff(before, after)
+			if err != nil {
+				log.Printf("audit: unable to diff %s %d: %v", target.Type, id, err)
+			}
+			entry.Changes = changes
+		}
+
+		entries := []*models.AuditLog{&entry}
+		if subjects, ok := c.Get(AuditSubjectsKey); ok && (!hasID || target.ForeignRouteID) {
+			entries = subjectEntries(entry, subjects.([]uint))
+		}
+
+		// a resposta já foi enviada: uma falha aqui só pode ser registrada no log
+		if err := service.Record(entries...); err != nil {
+			log.Printf("audit: %v", err)
+		}
+	}
+}
+
+// subjectEntries repete a entrada para cada registro devolvido; sem nenhum,
+// fica a entrada sem ResourceID, para que a consulta também conste na trilha
+func subjectEntries(entry models.AuditLog, subjects []uint) []*models.AuditLog {
+	if len(subjects) == 0 {
+		return []*models.AuditLog{&entry}
+	}
+
+	entries := make([]*models.AuditLog, 0, len(subjects))
+	for _, subject := range slices.Compact(slices.Sorted(slices.Values(subjects))) {
+		e := entry
+		e.ResourceID = &subject
+		entries = append(entries, &e)
+	}
+	return entries
+}
+
+func routeID(c *gin.Context) (uint64, bool) {
+	value := c.Param("id")
+	if value == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+func auditAction(method string) enums.AuditAction {
+	switch method {
+	case http.MethodGet:
+		return enums.AuditRead
+	case http.MethodPost:
+		return enums.AuditCreate
+	case http.MethodDelete:
+		return enums.AuditDelete
+	default:
+		return enums.AuditUpdate
+	}
+}
@@ -1,16 +1,19 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
-	"github.com/andresidrim/cesupa-hospital/enums"
-	us "github.com/andresidrim/cesupa-hospital/services/users"
+	ss "github.com/andresidrim/cesupa-hospital/services/sessions"
 	"github.com/andresidrim/cesupa-hospital/utils"
 	"github.com/gin-gonic/gin"
 )
 
-func JWTAuthMiddleware(userService us.UserService) gin.HandlerFunc {
+// JWTAuthMiddleware valida o access token e a sessão dele. Usuário, papel e
+// sessão vêm das claims; trocar papel ou senha e desativar o usuário revogam
+// as sessões, então o token não fica com dados desatualizados.
+func JWTAuthMiddleware(signer *utils.JWTSigner, sessionService ss.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
@@ -19,20 +22,26 @@ func JWTAuthMiddleware(userService us.UserService) gin.HandlerFunc {
 		}
 		tokenString := strings.TrimPrefix(auth, "Bearer ")
 
-		userID, err := utils.ParseJWT(tokenString)
+		claims, err := signer.Parse(tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid token: " + err.Error()})
 			return
 		}
 
-		user, err := userService.Get(uint64(userID))
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
+		if err := sessionService.Validate(claims.SessionID, claims.UserID()); err != nil {
+			if errors.Is(err, ss.ErrUserInactive) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "User inactive"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid session: " + err.Error()})
 			return
 		}
 
-		c.Set("userID", userID)
-		c.Set("role", enums.Role(user.Role))
+		c.Set("userID", claims.UserID())
+		c.Set("sessionID", claims.SessionID)
+		c.Set("role", claims.Role)
+		c.Set("mustChangePassword", claims.MustChangePassword)
+		c.Set("mustEnrollTOTP", claims.MustEnrollTOTP)
 
 		c.Next()
 	}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordChangeMiddleware bloqueia quem precisa trocar a senha em todas as
+// rotas, menos nas permitidas (a própria troca e o logout).
+func PasswordChangeMiddleware(allowedPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("mustChangePassword") && !slices.Contains(allowedPaths, c.FullPath()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Password change required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	ps "github.com/andresidrim/cesupa-hospital/services/permissions"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission libera a rota só para papéis que têm a permissão na
+// matriz de permissões. O papel vem do token (JWTAuthMiddleware).
+func RequirePermission(service ps.PermissionService, permission enums.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := c.Get("role")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "role not provided"})
+			return
+		}
+		userRole, ok := role.(enums.Role)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "invalid role type"})
+			return
+		}
+		if !service.Allowed(userRole, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "access forbidden: missing permission " + string(permission)})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TOTPEnrollmentMiddleware bloqueia quem tem papel com segundo fator
+// obrigatório e ainda não o cadastrou, menos nas rotas permitidas (o próprio
+// cadastro, a troca de senha e o logout).
+func TOTPEnrollmentMiddleware(allowedPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("mustEnrollTOTP") && !slices.Contains(allowedPaths, c.FullPath()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Two-factor enrollment required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/audit"
+)
+
+type MockAuditService struct {
+	MockRecord func(entry *models.AuditLog) error
+	MockGetAll func(filter audit.AuditFilter) ([]models.AuditLog, int64, error)
+}
+
+func (m *MockAuditService) Record(entries ...*models.AuditLog) error {
+	if m.MockRecord == nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if err := m.MockRecord(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockAuditService) GetAll(filter audit.AuditFilter) ([]models.AuditLog, int64, error) {
+	if m.MockGetAll != nil {
+		return m.MockGetAll(filter)
+	}
+	return []models.AuditLog{}, 0, nil
+}
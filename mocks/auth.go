@@ -1,19 +1,45 @@
 package mocks
 
 import (
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/auth"
 )
 
 type MockAuthService struct {
-	MockLogin    func(cpf, password string) (string, error)
-	MockRegister func(user *models.User) error
+	MockLogin      func(document cpf.CPF, password, userAgent, ip string) (*auth.LoginResult, error)
+	MockVerifyTOTP func(challengeToken, code string) (*auth.TokenPair, error)
+	MockRefresh    func(refreshToken string) (*auth.TokenPair, error)
+	MockLogout     func(userID, sessionID uint) error
+	MockRegister   func(user *models.User) error
 }
 
-func (m *MockAuthService) Login(cpf, password string) (string, error) {
+func (m *MockAuthService) Login(document cpf.CPF, password, userAgent, ip string) (*auth.LoginResult, error) {
 	if m.MockLogin != nil {
-		return m.MockLogin(cpf, password)
+		return m.MockLogin(document, password, userAgent, ip)
 	}
-	return "", nil
+	return &auth.LoginResult{Tokens: &auth.TokenPair{}}, nil
+}
+
+func (m *MockAuthService) VerifyTOTP(challengeToken, code string) (*auth.TokenPair, error) {
+	if m.MockVerifyTOTP != nil {
+		return m.MockVerifyTOTP(challengeToken, code)
+	}
+	return &auth.TokenPair{}, nil
+}
+
+func (m *MockAuthService) Refresh(refreshToken string) (*auth.TokenPair, error) {
+	if m.MockRefresh != nil {
+		return m.MockRefresh(refreshToken)
+	}
+	return &auth.TokenPair{}, nil
+}
+
+func (m *MockAuthService) Logout(userID, sessionID uint) error {
+	if m.MockLogout != nil {
+		return m.MockLogout(userID, sessionID)
+	}
+	return nil
 }
 
 func (m *MockAuthService) Register(user *models.User) error {
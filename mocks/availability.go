@@ -0,0 +1,91 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/availability"
+)
+
+type MockAvailabilityService struct {
+	MockCreateShift       func(shift *models.Shift) error
+	MockGetShifts         func(doctorID uint64) ([]models.Shift, error)
+	MockUpdateShift       func(id uint64, shift *models.Shift) error
+	MockDeleteShift       func(doctorID uint64, id uint64) error
+	MockCreateBlock       func(block *models.AvailabilityBlock) error
+	MockGetBlocks         func(doctorID uint64, from, to time.Time) ([]models.AvailabilityBlock, error)
+	MockUpdateBlock       func(id uint64, block *models.AvailabilityBlock) error
+	MockDeleteBlock       func(doctorID uint64, id uint64) error
+	MockCheckAvailability func(doctorID uint, start, end time.Time) error
+	MockFindSlots         func(filter availability.SlotFilter) ([]availability.Slot, error)
+}
+
+func (m *MockAvailabilityService) CreateShift(shift *models.Shift) error {
+	if m.MockCreateShift != nil {
+		return m.MockCreateShift(shift)
+	}
+	return nil
+}
+
+func (m *MockAvailabilityService) GetShifts(doctorID uint64) ([]models.Shift, error) {
+	if m.MockGetShifts != nil {
+		return m.MockGetShifts(doctorID)
+	}
+	return []models.Shift{}, nil
+}
+
+func (m *MockAvailabilityService) UpdateShift(id uint64, shift *models.Shift) error {
+	if m.MockUpdateShift != nil {
+		return m.MockUpdateShift(id, shift)
+	}
+	return nil
+}
+
+func (m *MockAvailabilityService) DeleteShift(doctorID uint64, id uint64) error {
+	if m.MockDeleteShift != nil {
+		return m.MockDeleteShift(doctorID, id)
+	}
+	return nil
+}
+
+func (m *MockAvailabilityService) CreateBlock(block *models.AvailabilityBlock) error {
+	if m.MockCreateBlock != nil {
+		return m.MockCreateBlock(block)
+	}
+	return nil
+}
+
+func (m *MockAvailabilityService) GetBlocks(doctorID uint64, from, to time.Time) ([]models.AvailabilityBlock, error) {
+	if m.MockGetBlocks != nil {
+		return m.MockGetBlocks(doctorID, from, to)
+	}
+	return []models.AvailabilityBlock{}, nil
+}
+
+func (m *MockAvailabilityService) UpdateBlock(id uint64, block *models.AvailabilityBlock) error {
+	if m.MockUpdateBlock != nil {
+		return m.MockUpdateBlock(id, block)
+	}
+	return nil
+}
+
+func (m *MockAvailabilityService) DeleteBlock(doctorID uint64, id uint64) error {
+	if m.MockDeleteBlock != nil {
+		return m.MockDeleteBlock(doctorID, id)
+	}
+	return nil
+}
+
+func (m *MockAvailabilityService) CheckAvailability(doctorID uint, start, end time.Time) error {
+	if m.MockCheckAvailability != nil {
+		return m.MockCheckAvailability(doctorID, start, end)
+	}
+	return nil
+}
+
+func (m *MockAvailabilityService) FindSlots(filter availability.SlotFilter) ([]availability.Slot, error) {
+	if m.MockFindSlots != nil {
+		return m.MockFindSlots(filter)
+	}
+	return []availability.Slot{}, nil
+}
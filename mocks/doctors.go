@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/doctors"
+)
+
+type MockDoctorService struct {
+	MockGetProfile      func(userID uint64) (*models.DoctorProfile, error)
+	MockSaveProfile     func(userID uint64, input doctors.ProfileInput) (*models.DoctorProfile, error)
+	MockListSpecialties func() ([]models.Specialty, error)
+	MockCreateSpecialty func(name string) (*models.Specialty, error)
+	MockDeleteSpecialty func(id uint64) error
+}
+
+func (m *MockDoctorService) GetProfile(userID uint64) (*models.DoctorProfile, error) {
+	if m.MockGetProfile != nil {
+		return m.MockGetProfile(userID)
+	}
+	return &models.DoctorProfile{UserID: uint(userID)}, nil
+}
+
+func (m *MockDoctorService) SaveProfile(userID uint64, input doctors.ProfileInput) (*models.DoctorProfile, error) {
+	if m.MockSaveProfile != nil {
+		return m.MockSaveProfile(userID, input)
+	}
+	return &models.DoctorProfile{UserID: uint(userID), CRM: input.CRM, CRMUF: input.UF, ConsultationMinutes: input.ConsultationMinutes}, nil
+}
+
+func (m *MockDoctorService) ListSpecialties() ([]models.Specialty, error) {
+	if m.MockListSpecialties != nil {
+		return m.MockListSpecialties()
+	}
+	return []models.Specialty{}, nil
+}
+
+func (m *MockDoctorService) CreateSpecialty(name string) (*models.Specialty, error) {
+	if m.MockCreateSpecialty != nil {
+		return m.MockCreateSpecialty(name)
+	}
+	return &models.Specialty{Name: name}, nil
+}
+
+func (m *MockDoctorService) DeleteSpecialty(id uint64) error {
+	if m.MockDeleteSpecialty != nil {
+		return m.MockDeleteSpecialty(id)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/models"
+)
+
+type MockLockoutService struct {
+	MockCheck           func(document cpf.CPF, ip string) error
+	MockRegisterFailure func(document cpf.CPF, ip string) error
+	MockRegisterSuccess func(document cpf.CPF) error
+	MockGetAll          func(lockedOnly bool) ([]models.LoginAttempt, error)
+	MockClear           func(id uint64) error
+}
+
+func (m *MockLockoutService) Check(document cpf.CPF, ip string) error {
+	if m.MockCheck != nil {
+		return m.MockCheck(document, ip)
+	}
+	return nil
+}
+
+func (m *MockLockoutService) RegisterFailure(document cpf.CPF, ip string) error {
+	if m.MockRegisterFailure != nil {
+		return m.MockRegisterFailure(document, ip)
+	}
+	return nil
+}
+
+func (m *MockLockoutService) RegisterSuccess(document cpf.CPF) error {
+	if m.MockRegisterSuccess != nil {
+		return m.MockRegisterSuccess(document)
+	}
+	return nil
+}
+
+func (m *MockLockoutService) GetAll(lockedOnly bool) ([]models.LoginAttempt, error) {
+	if m.MockGetAll != nil {
+		return m.MockGetAll(lockedOnly)
+	}
+	return []models.LoginAttempt{}, nil
+}
+
+func (m *MockLockoutService) Clear(id uint64) error {
+	if m.MockClear != nil {
+		return m.MockClear(id)
+	}
+	return nil
+}
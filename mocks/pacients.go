@@ -1,18 +1,38 @@
 package mocks
 
-import "github.com/andresidrim/cesupa-hospital/models"
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/pacients"
+	"gorm.io/gorm"
+)
 
 type MockPacientService struct {
 	MockCreate              func(pacient *models.Pacient) error
 	MockGet                 func(id uint64) (*models.Pacient, error)
-	MockGetAll              func(name string, ageStr string) ([]models.Pacient, error)
+	MockGetAll              func(filter pacients.PacientFilter) ([]models.Pacient, int64, error)
 	MockUpdate              func(id uint64, pacient *models.Pacient) error
-	MockDelete              func(id uint64) error
+	MockInactivate          func(id uint64, reason string) (*models.Pacient, error)
+	MockReactivate          func(id uint64) (*models.Pacient, error)
 	MockScheduleAppointment func(appointment *models.Appointment) error
+	MockUpdateStatus        func(id uint64, status enums.AppointmentStatus, actorID uint) (*models.Appointment, error)
+	MockCancelAppointment   func(id uint64, reason string, actorID uint) (*models.Appointment, error)
+	MockReschedule          func(id uint64, date time.Time, actorID uint) (*models.Appointment, error)
+	MockGetAppointment      func(id uint64) (*models.Appointment, error)
+	MockListAppointments    func(filter pacients.AppointmentFilter) ([]models.Appointment, int64, error)
+	MockPacientAccess       func(doctorID uint, pacientID uint64) (bool, error)
+	MockGrantEmergency      func(doctorID uint, pacientID uint64, justification string) (*models.EmergencyAccess, error)
+	MockListEmergency       func(activeOnly bool) ([]models.EmergencyAccess, error)
 }
 
-func (m *MockPacientService) GetAll(name, ageStr string) ([]models.Pacient, error) {
-	return m.MockGetAll(name, ageStr)
+func (m *MockPacientService) GetAll(filter pacients.PacientFilter) ([]models.Pacient, int64, error) {
+	if m.MockGetAll != nil {
+		return m.MockGetAll(filter)
+	}
+
+	return []models.Pacient{}, 0, nil
 }
 
 func (m *MockPacientService) Get(id uint64) (*models.Pacient, error) {
@@ -36,12 +56,20 @@ func (m *MockPacientService) Update(id uint64, pacient *models.Pacient) error {
 	return nil
 }
 
-func (m *MockPacientService) Delete(id uint64) error {
-	if m.MockDelete != nil {
-		return m.MockDelete(id)
+func (m *MockPacientService) Inactivate(id uint64, reason string) (*models.Pacient, error) {
+	if m.MockInactivate != nil {
+		return m.MockInactivate(id, reason)
 	}
 
-	return nil
+	return &models.Pacient{Status: enums.PacientInactive}, nil
+}
+
+func (m *MockPacientService) Reactivate(id uint64) (*models.Pacient, error) {
+	if m.MockReactivate != nil {
+		return m.MockReactivate(id)
+	}
+
+	return &models.Pacient{Status: enums.PacientActive}, nil
 }
 
 func (m *MockPacientService) ScheduleAppointment(appointment *models.Appointment) error {
@@ -51,3 +79,67 @@ func (m *MockPacientService) ScheduleAppointment(appointment *models.Appointment
 
 	return nil
 }
+
+func (m *MockPacientService) UpdateAppointmentStatus(id uint64, status enums.AppointmentStatus, actorID uint) (*models.Appointment, error) {
+	if m.MockUpdateStatus != nil {
+		return m.MockUpdateStatus(id, status, actorID)
+	}
+
+	return &models.Appointment{Status: status}, nil
+}
+
+func (m *MockPacientService) CancelAppointment(id uint64, reason string, actorID uint) (*models.Appointment, error) {
+	if m.MockCancelAppointment != nil {
+		return m.MockCancelAppointment(id, reason, actorID)
+	}
+
+	return &models.Appointment{Status: enums.Cancelled, CancelReason: &reason}, nil
+}
+
+func (m *MockPacientService) RescheduleAppointment(id uint64, date time.Time, actorID uint) (*models.Appointment, error) {
+	if m.MockReschedule != nil {
+		return m.MockReschedule(id, date, actorID)
+	}
+
+	return &models.Appointment{Date: date, Status: enums.Scheduled}, nil
+}
+
+func (m *MockPacientService) GetAppointment(id uint64) (*models.Appointment, error) {
+	if m.MockGetAppointment != nil {
+		return m.MockGetAppointment(id)
+	}
+
+	return &models.Appointment{Model: gorm.Model{ID: uint(id)}, Status: enums.Scheduled}, nil
+}
+
+func (m *MockPacientService) ListAppointments(filter pacients.AppointmentFilter) ([]models.Appointment, int64, error) {
+	if m.MockListAppointments != nil {
+		return m.MockListAppointments(filter)
+	}
+
+	return []models.Appointment{}, 0, nil
+}
+
+func (m *MockPacientService) PacientAccess(doctorID uint, pacientID uint64) (bool, error) {
+	if m.MockPacientAccess != nil {
+		return m.MockPacientAccess(doctorID, pacientID)
+	}
+
+	return false, nil
+}
+
+func (m *MockPacientService) GrantEmergencyAccess(doctorID uint, pacientID uint64, justification string) (*models.EmergencyAccess, error) {
+	if m.MockGrantEmergency != nil {
+		return m.MockGrantEmergency(doctorID, pacientID, justification)
+	}
+
+	return &models.EmergencyAccess{UserID: doctorID, PacientID: uint(pacientID), Justification: justification}, nil
+}
+
+func (m *MockPacientService) ListEmergencyAccesses(activeOnly bool) ([]models.EmergencyAccess, error) {
+	if m.MockListEmergency != nil {
+		return m.MockListEmergency(activeOnly)
+	}
+
+	return []models.EmergencyAccess{}, nil
+}
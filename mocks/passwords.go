@@ -0,0 +1,30 @@
+package mocks
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type MockPasswordService struct {
+	MockChange     func(userID uint, current, next string) error
+	MockIssueReset func(userID uint64, issuedByID uint) (*models.PasswordResetToken, string, error)
+	MockReset      func(token, next string) error
+}
+
+func (m *MockPasswordService) Change(userID uint, current, next string) error {
+	if m.MockChange != nil {
+		return m.MockChange(userID, current, next)
+	}
+	return nil
+}
+
+func (m *MockPasswordService) IssueReset(userID uint64, issuedByID uint) (*models.PasswordResetToken, string, error) {
+	if m.MockIssueReset != nil {
+		return m.MockIssueReset(userID, issuedByID)
+	}
+	return &models.PasswordResetToken{UserID: uint(userID), IssuedByID: issuedByID}, "", nil
+}
+
+func (m *MockPasswordService) Reset(token, next string) error {
+	if m.MockReset != nil {
+		return m.MockReset(token, next)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/services/permissions"
+)
+
+type MockPermissionService struct {
+	MockAllowed   func(role enums.Role, permission enums.Permission) bool
+	MockEffective func(userID uint64) (*permissions.Effective, error)
+}
+
+func (m *MockPermissionService) Allowed(role enums.Role, permission enums.Permission) bool {
+	if m.MockAllowed != nil {
+		return m.MockAllowed(role, permission)
+	}
+	return true
+}
+
+func (m *MockPermissionService) Effective(userID uint64) (*permissions.Effective, error) {
+	if m.MockEffective != nil {
+		return m.MockEffective(userID)
+	}
+	return &permissions.Effective{UserID: uint(userID)}, nil
+}
@@ -0,0 +1,56 @@
+package mocks
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type MockSessionService struct {
+	MockCreate    func(userID uint, userAgent, ip string) (*models.Session, string, error)
+	MockRotate    func(refreshToken string) (*models.Session, string, error)
+	MockValidate  func(sessionID, userID uint) error
+	MockGetByUser func(userID uint64) ([]models.Session, error)
+	MockRevoke    func(userID, id uint64) error
+	MockRevokeAll func(userID uint64) error
+}
+
+func (m *MockSessionService) Create(userID uint, userAgent, ip string) (*models.Session, string, error) {
+	if m.MockCreate != nil {
+		return m.MockCreate(userID, userAgent, ip)
+	}
+	return &models.Session{UserID: userID}, "", nil
+}
+
+func (m *MockSessionService) Rotate(refreshToken string) (*models.Session, string, error) {
+	if m.MockRotate != nil {
+		return m.MockRotate(refreshToken)
+	}
+	return &models.Session{}, "", nil
+}
+
+// Validate aceita qualquer sessão por padrão, para que testes de rota não
+// precisem configurar sessões.
+func (m *MockSessionService) Validate(sessionID, userID uint) error {
+	if m.MockValidate != nil {
+		return m.MockValidate(sessionID, userID)
+	}
+	return nil
+}
+
+func (m *MockSessionService) GetByUser(userID uint64) ([]models.Session, error) {
+	if m.MockGetByUser != nil {
+		return m.MockGetByUser(userID)
+	}
+	return []models.Session{}, nil
+}
+
+func (m *MockSessionService) Revoke(userID, id uint64) error {
+	if m.MockRevoke != nil {
+		return m.MockRevoke(userID, id)
+	}
+	return nil
+}
+
+func (m *MockSessionService) RevokeAll(userID uint64) error {
+	if m.MockRevokeAll != nil {
+		return m.MockRevokeAll(userID)
+	}
+	return nil
+}
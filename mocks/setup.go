@@ -0,0 +1,22 @@
+package mocks
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type MockSetupService struct {
+	MockAvailable   func() (bool, error)
+	MockCreateAdmin func(token string, admin *models.User) error
+}
+
+func (m *MockSetupService) Available() (bool, error) {
+	if m.MockAvailable != nil {
+		return m.MockAvailable()
+	}
+	return true, nil
+}
+
+func (m *MockSetupService) CreateAdmin(token string, admin *models.User) error {
+	if m.MockCreateAdmin != nil {
+		return m.MockCreateAdmin(token, admin)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import "github.com/andresidrim/cesupa-hospital/services/twofactor"
+
+type MockTwoFactorService struct {
+	MockSetup                   func(userID uint) (*twofactor.Enrollment, error)
+	MockEnable                  func(userID uint, code string) ([]string, error)
+	MockDisable                 func(userID uint, code string) error
+	MockRegenerateRecoveryCodes func(userID uint, code string) ([]string, error)
+	MockReset                   func(userID uint64) error
+}
+
+func (m *MockTwoFactorService) Setup(userID uint) (*twofactor.Enrollment, error) {
+	if m.MockSetup != nil {
+		return m.MockSetup(userID)
+	}
+	return &twofactor.Enrollment{}, nil
+}
+
+func (m *MockTwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	if m.MockEnable != nil {
+		return m.MockEnable(userID, code)
+	}
+	return []string{}, nil
+}
+
+func (m *MockTwoFactorService) Disable(userID uint, code string) error {
+	if m.MockDisable != nil {
+		return m.MockDisable(userID, code)
+	}
+	return nil
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if m.MockRegenerateRecoveryCodes != nil {
+		return m.MockRegenerateRecoveryCodes(userID, code)
+	}
+	return []string{}, nil
+}
+
+func (m *MockTwoFactorService) Reset(userID uint64) error {
+	if m.MockReset != nil {
+		return m.MockReset(userID)
+	}
+	return nil
+}
@@ -3,11 +3,15 @@ package mocks
 import (
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/users"
 )
 
 type MockUserService struct {
-	MockGet    func(id uint64) (*models.User, error)
-	MockGetAll func(roles []enums.Role) ([]models.User, error)
+	MockGet        func(id uint64) (*models.User, error)
+	MockGetAll     func(roles []enums.Role) ([]models.User, error)
+	MockGetDoctors func(specialty string) ([]models.User, error)
+	MockUpdate     func(id uint64, changes users.UserUpdate) (*models.User, error)
+	MockDeactivate func(id uint64) (*models.User, error)
 }
 
 func (m *MockUserService) Get(id uint64) (*models.User, error) {
@@ -17,3 +21,23 @@ func (m *MockUserService) Get(id uint64) (*models.User, error) {
 func (m *MockUserService) GetAll(roles []enums.Role) ([]models.User, error) {
 	return m.MockGetAll(roles)
 }
+
+func (m *MockUserService) GetDoctors(specialty string) ([]models.User, error) {
+	return m.MockGetDoctors(specialty)
+}
+
+func (m *MockUserService) Update(id uint64, changes users.UserUpdate) (*models.User, error) {
+	if m.MockUpdate != nil {
+		return m.MockUpdate(id, changes)
+	}
+
+	return &models.User{}, nil
+}
+
+func (m *MockUserService) Deactivate(id uint64) (*models.User, error) {
+	if m.MockDeactivate != nil {
+		return m.MockDeactivate(id)
+	}
+
+	return &models.User{}, nil
+}
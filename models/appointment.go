@@ -3,14 +3,33 @@ package models
 import (
 	"time"
 
+	"github.com/andresidrim/cesupa-hospital/enums"
 	"gorm.io/gorm"
 )
 
 type Appointment struct {
-	gorm.Model `swaggerignore:"true"`
-	PacientID  uint      `gorm:"not null" json:"pacientId"`
-	Pacient    Pacient   `json:"pacient" swaggerignore:"true"`
-	UserID     uint      `gorm:"not null" json:"userId"`
-	User       User      `json:"user"`
-	Date       time.Time `gorm:"not null" json:"date"`
+	gorm.Model   `swaggerignore:"true"`
+	PacientID    uint                    `gorm:"not null;index" json:"pacientId"`
+	Pacient      Pacient                 `json:"pacient" swaggerignore:"true"`
+	UserID       uint                    `gorm:"not null;index" json:"userId"`
+	User         User                    `json:"user"`
+	Date         time.Time               `gorm:"not null" json:"date"`
+	EndDate      time.Time               `gorm:"not null" json:"endDate"`
+	Status       enums.AppointmentStatus `gorm:"not null;default:scheduled;index" json:"status"`
+	CancelReason *string                 `json:"cancelReason,omitempty"`
+
+	History []AppointmentEvent `gorm:"constraint:OnDelete:CASCADE" json:"history,omitempty"`
+}
+
+// AppointmentEvent registra cada mudança de status ou de horário de uma consulta
+type AppointmentEvent struct {
+	ID            uint                    `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time               `json:"createdAt"`
+	AppointmentID uint                    `gorm:"not null;index" json:"appointmentId"`
+	ActorID       *uint                   `json:"actorId"`
+	FromStatus    enums.AppointmentStatus `json:"fromStatus"`
+	ToStatus      enums.AppointmentStatus `gorm:"not null" json:"toStatus"`
+	FromDate      *time.Time              `json:"fromDate,omitempty"`
+	ToDate        *time.Time              `json:"toDate,omitempty"`
+	Reason        *string                 `json:"reason,omitempty"`
 }
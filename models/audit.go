@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"gorm.io/gorm"
+)
+
+var ErrAuditAppendOnly = errors.New("audit log is append-only")
+
+// AuditLog registra quem acessou ou alterou um paciente ou usuário, quando e
+// de onde. Em alterações, Changes guarda o antes e o depois de cada campo.
+// Emergency marca os acessos feitos sob quebra de vidro. A trilha só recebe
+// inserções: os hooks recusam update e delete, e os gatilhos da migração
+// 0008 fazem o mesmo no banco.
+type AuditLog struct {
+	ID           uint                `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time           `gorm:"index" json:"createdAt"`
+	UserID       uint                `gorm:"not null;index" json:"userId"`
+	Action       enums.AuditAction   `gorm:"not null" json:"action"`
+	Method       string              `gorm:"not null" json:"method"`
+	Route        string              `gorm:"not null" json:"route"`
+	Path         string              `gorm:"not null" json:"path"`
+	ResourceType enums.AuditResource `gorm:"not null;index:idx_audit_resource" json:"resourceType"`
+	ResourceID   *uint               `gorm:"index:idx_audit_resource" json:"resourceId,omitempty"`
+	Status       int                 `json:"status"`
+	IP           string              `json:"ip"`
+	UserAgent    string              `json:"userAgent"`
+	Changes      AuditChanges        `gorm:"type:text" json:"changes,omitempty"`
+	Emergency    bool                `gorm:"not null;default:false;index" json:"emergency"`
+}
+
+func (AuditLog) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+func (AuditLog) BeforeDelete(*gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// AuditChange é o valor de um campo antes e depois da alteração
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditChanges é gravado como JSON, indexado pelo nome do campo
+type AuditChanges map[string]AuditChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (c *AuditChanges) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	default:
+		return fmt.Errorf("unable to scan %T into AuditChanges", value)
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"gorm.io/gorm"
+)
+
+// Shift é um turno semanal recorrente de um médico (ex.: segunda, 08:00–12:00)
+type Shift struct {
+	gorm.Model `swaggerignore:"true"`
+	UserID     uint         `gorm:"not null;index" json:"doctorId"`
+	Weekday    time.Weekday `gorm:"not null" json:"weekday" swaggertype:"integer"`
+	StartTime  string       `gorm:"not null" json:"startTime"`
+	EndTime    string       `gorm:"not null" json:"endTime"`
+}
+
+// AvailabilityBlock é um bloqueio pontual da agenda (férias, congresso, licença)
+type AvailabilityBlock struct {
+	gorm.Model `swaggerignore:"true"`
+	UserID     uint              `gorm:"not null;index" json:"doctorId"`
+	StartsAt   time.Time         `gorm:"not null" json:"startsAt"`
+	EndsAt     time.Time         `gorm:"not null" json:"endsAt"`
+	Reason     enums.BlockReason `gorm:"not null" json:"reason"`
+	Notes      *string           `json:"notes"`
+}
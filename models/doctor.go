@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/crm"
+)
+
+// DoctorProfile guarda os dados profissionais de um usuário médico: o
+// registro no CRM (número + UF, único), as especialidades e a duração
+// padrão das consultas dele.
+type DoctorProfile struct {
+	ID                  uint        `gorm:"primarykey" json:"id"`
+	CreatedAt           time.Time   `json:"createdAt"`
+	UpdatedAt           time.Time   `json:"updatedAt"`
+	UserID              uint        `gorm:"not null;uniqueIndex" json:"userId"`
+	CRM                 string      `gorm:"not null;uniqueIndex:idx_doctor_crm" json:"crm"`
+	CRMUF               string      `gorm:"column:crm_uf;not null;uniqueIndex:idx_doctor_crm" json:"crmUf"`
+	ConsultationMinutes int         `gorm:"not null;default:30" json:"consultationMinutes"`
+	Specialties         []Specialty `gorm:"many2many:doctor_specialties" json:"specialties"`
+}
+
+// Registration devolve o registro como aparece em documentos (CRM/PA 123456)
+func (p DoctorProfile) Registration() string {
+	return crm.Format(p.CRM, p.CRMUF)
+}
+
+// ConsultationDuration é a duração padrão das consultas do médico
+func (p DoctorProfile) ConsultationDuration() time.Duration {
+	return time.Duration(p.ConsultationMinutes) * time.Minute
+}
+
+// Specialty é um item do catálogo de especialidades médicas mantido pelos
+// admins.
+type Specialty struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `gorm:"not null;uniqueIndex" json:"name"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// EmergencyAccess é a quebra de vidro: um acesso temporário de um médico a
+// um paciente com quem ele não tem consulta, liberado mediante justificativa.
+type EmergencyAccess struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time `gorm:"index" json:"createdAt"`
+	UserID        uint      `gorm:"not null;index:idx_emergency_access" json:"userId"`
+	PacientID     uint      `gorm:"not null;index:idx_emergency_access" json:"pacientId"`
+	Justification string    `gorm:"not null" json:"justification"`
+	ExpiresAt     time.Time `gorm:"not null" json:"expiresAt"`
+}
+
+func (a EmergencyAccess) IsActive(now time.Time) bool {
+	return now.Before(a.ExpiresAt)
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+)
+
+// LoginAttempt conta as falhas de login seguidas de um CPF ou de um IP e,
+// passado o limite, até quando novas tentativas ficam bloqueadas.
+type LoginAttempt struct {
+	ID            uint              `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	Kind          enums.AttemptKind `gorm:"not null;uniqueIndex:idx_login_attempt_key" json:"kind"`
+	Identifier    string            `gorm:"not null;uniqueIndex:idx_login_attempt_key" json:"identifier"`
+	Failures      int               `gorm:"not null" json:"failures"`
+	LastFailureAt time.Time         `gorm:"not null" json:"lastFailureAt"`
+	LockedUntil   *time.Time        `json:"lockedUntil,omitempty"`
+}
+
+func (a LoginAttempt) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
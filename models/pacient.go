@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"gorm.io/gorm"
 )
@@ -11,7 +12,7 @@ type Pacient struct {
 	gorm.Model  `swaggerignore:"true"`
 	Name        string    `gorm:"not null" json:"name"`
 	BirthDate   time.Time `gorm:"type:date;not null" json:"birthDate"`
-	CPF         string    `gorm:"unique;not null" json:"cpf"`
+	CPF         cpf.CPF   `gorm:"unique;not null" json:"cpf" swaggertype:"string" example:"529.982.247-25"`
 	Sex         enums.Sex `gorm:"not null" json:"sex"`
 	PhoneNumber string    `gorm:"not null" json:"phoneNumber"`
 	Address     string    `gorm:"not null" json:"address"`
@@ -20,5 +21,9 @@ type Pacient struct {
 	BloodType *enums.BloodType `json:"bloodType"`
 	Allergies *string          `json:"allergies"`
 
-	Appointments []Appointment `gorm:"foreignKey=PacientID;constraint:OnDelete:CASCADE" json:"appointments" swaggerignore:"true"`
+	Status             enums.PacientStatus `gorm:"not null;default:active;index" json:"status"`
+	InactivatedAt      *time.Time          `json:"inactivatedAt,omitempty"`
+	InactivationReason *string             `json:"inactivationReason,omitempty"`
+
+	Appointments []Appointment `gorm:"foreignKey=PacientID;constraint:OnDelete:CASCADE" json:"appointments,omitempty" swaggerignore:"true"`
 }
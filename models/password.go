@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PasswordHistory guarda os hashes das senhas já usadas, para impedir que o
+// usuário volte a uma senha recente.
+type PasswordHistory struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UserID    uint      `gorm:"not null;index" json:"userId"`
+	Hash      string    `gorm:"not null" json:"-"`
+}
+
+// PasswordResetToken é um token de uso único emitido por um admin para o
+// usuário definir uma nova senha. Só o hash do token fica no banco.
+type PasswordResetToken struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UserID     uint       `gorm:"not null;index" json:"userId"`
+	IssuedByID uint       `gorm:"not null" json:"issuedById"`
+	TokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt     *time.Time `json:"usedAt,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+)
+
+// RolePermission concede uma permissão a um papel. A tabela é preenchida
+// com a matriz padrão na primeira execução e pode ser ajustada depois.
+type RolePermission struct {
+	ID         uint             `gorm:"primarykey" json:"id"`
+	Role       enums.Role       `gorm:"not null;uniqueIndex:idx_role_permission" json:"role"`
+	Permission enums.Permission `gorm:"not null;uniqueIndex:idx_role_permission" json:"permission"`
+}
+
+// PermissionSeed registra que uma concessão da matriz padrão já foi gravada
+// uma vez, para que não volte depois de revogada pelo administrador.
+type PermissionSeed struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	Role       enums.Role       `gorm:"not null;uniqueIndex:idx_permission_seed"`
+	Permission enums.Permission `gorm:"not null;uniqueIndex:idx_permission_seed"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session é um login ativo. O refresh token é rotacionado a cada uso e só o
+// hash dele fica no banco; o hash anterior é guardado para detectar reuso.
+type Session struct {
+	gorm.Model        `swaggerignore:"true"`
+	UserID            uint       `gorm:"not null;index" json:"userId"`
+	RefreshTokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	PreviousTokenHash *string    `gorm:"index" json:"-"`
+	UserAgent         string     `json:"userAgent"`
+	IP                string     `json:"ip"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expiresAt"`
+	LastUsedAt        time.Time  `json:"lastUsedAt"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty"`
+}
+
+func (s Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
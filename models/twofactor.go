@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RecoveryCode é um código de uso único que substitui o TOTP quando o
+// usuário perde o aplicativo autenticador. Só o hash fica no banco.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	CodeHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+// LoginChallenge é o passo intermediário do login de quem tem TOTP: a senha
+// já foi conferida e o token do desafio é trocado pelos tokens da sessão
+// depois que o segundo fator é informado.
+type LoginChallenge struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	UserAgent string     `json:"userAgent"`
+	IP        string     `json:"ip"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
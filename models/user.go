@@ -1,6 +1,9 @@
 package models
 
 import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"gorm.io/gorm"
 )
@@ -8,8 +11,36 @@ import (
 type User struct {
 	gorm.Model   `swaggerignore:"true"`
 	Name         string        `gorm:"not null" json:"name"`
-	CPF          string        `gorm:"unique;not null" json:"cpf"`
+	CPF          cpf.CPF       `gorm:"unique;not null" json:"cpf" swaggertype:"string" example:"529.982.247-25"`
 	Password     string        `gorm:"not null" json:"-"`
 	Role         enums.Role    `gorm:"not null" json:"role"`
 	Appointments []Appointment `gorm:"foreignKey=UserID;constraint:OnDelete:CASCADE" json:"appointments"`
+
+	// DoctorProfile só existe para médicos (CRM, especialidades)
+	DoctorProfile *DoctorProfile `gorm:"constraint:OnDelete:CASCADE" json:"doctorProfile,omitempty"`
+
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+
+	// MustChangePassword obriga a troca da senha antes de usar o sistema;
+	// vale para senhas definidas por um admin (cadastro ou edição).
+	MustChangePassword bool `gorm:"not null;default:false" json:"mustChangePassword"`
+
+	// Segundo fator (TOTP). O segredo é gravado no início do cadastro e só
+	// passa a ser exigido no login depois de confirmado (TOTPEnabledAt).
+	// TOTPLastStep guarda o último passo aceito, para um código não valer
+	// duas vezes.
+	TOTPSecret    *string    `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totpEnabledAt,omitempty"`
+	TOTPLastStep  int64      `gorm:"not null;default:0" json:"-"`
+}
+
+// IsActive informa se o usuário não foi desligado; inativos não fazem login
+// nem recebem consultas.
+func (u User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
+// HasTOTP informa se o usuário confirmou o cadastro do segundo fator
+func (u User) HasTOTP() bool {
+	return u.TOTPEnabledAt != nil
 }
@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ignoredFields não entram no diff: mudam a cada gravação sem dizer nada
+var ignoredFields = map[string]bool{"UpdatedAt": true, "updatedAt": true}
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Record grava as entradas de uma vez; a requisição que devolve vários
+// pacientes gera uma entrada por paciente
+func (s *Service) Record(entries ...*models.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := s.db.Create(entries).Error; err != nil {
+		return fmt.Errorf("unable to record audit log: %v", err)
+	}
+	return nil
+}
+
+// AuditFilter filtra a trilha por quem fez o acesso, paciente acessado e
+// período, ou só os acessos de emergência; campos zerados não filtram.
+type AuditFilter struct {
+	UserID    uint
+	PacientID uint
+	Emergency bool
+	From      time.Time
+	To        time.Time
+	Page      int
+	Limit     int
+}
+
+// GetAll lista a trilha do mais recente para o mais antigo, paginada, e
+// devolve também o total de registros do filtro.
+func (s *Service) GetAll(filter AuditFilter) ([]models.AuditLog, int64, error) {
+	query := s.db.Model(&models.AuditLog{})
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.PacientID != 0 {
+		query = query.Where("resource_type = ? AND resource_id = ?", enums.AuditPacient, filter.PacientID)
+	}
+	if filter.Emergency {
+		query = query.Where("emergency = ?", true)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("unable to count audit logs: %v", err)
+	}
+
+	page, limit := NormalizePage(filter.Page, filter.Limit)
+
+	var entries []models.AuditLog
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// NormalizePage aplica os padrões de paginação: página 1 e DefaultPageSize
+// itens, limitado a MaxPageSize
+func NormalizePage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	return page, limit
+}
+
+// Diff compara as representações JSON de before e after e devolve os campos
+// que mudaram. Um lado nulo (criação ou registro não encontrado) aparece
+// como nil em From ou To.
+func Diff(before, after any) (models.AuditChanges, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := models.AuditChanges{}
+	for name, value := range to {
+		if ignoredFields[name] {
+			continue
+		}
+		if old, ok := from[name]; !ok || !reflect.DeepEqual(old, value) {
+			changes[name] = models.AuditChange{From: from[name], To: value}
+		}
+	}
+	for name, old := range from {
+		if _, ok := to[name]; !ok && !ignoredFields[name] {
+			changes[name] = models.AuditChange{From: old, To: nil}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+func fields(value any) (map[string]any, error) {
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Pointer && reflect.ValueOf(value).IsNil() {
+		return map[string]any{}, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,8 @@
+package audit
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type AuditService interface {
+	Record(entries ...*models.AuditLog) error
+	GetAll(filter AuditFilter) ([]models.AuditLog, int64, error)
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.AuditLog{})
+}
+
+func entry(userID uint, resource enums.AuditResource, resourceID uint, at time.Time) *models.AuditLog {
+	return &models.AuditLog{
+		CreatedAt:    at,
+		UserID:       userID,
+		Action:       enums.AuditRead,
+		Method:       "GET",
+		Route:        "/pacients/:id",
+		Path:         "/pacients/1",
+		ResourceType: resource,
+		ResourceID:   &resourceID,
+		Status:       200,
+	}
+}
+
+func TestServiceGetAll(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, svc.Record(entry(1, enums.AuditPacient, 10, day)))
+	assert.NoError(t, svc.Record(entry(1, enums.AuditPacient, 11, day.Add(time.Hour))))
+	assert.NoError(t, svc.Record(entry(2, enums.AuditPacient, 10, day.AddDate(0, 0, 1))))
+	assert.NoError(t, svc.Record(entry(2, enums.AuditUser, 10, day.AddDate(0, 0, 2))))
+	emergency := entry(3, enums.AuditPacient, 12, day.AddDate(0, 0, -1))
+	emergency.Emergency = true
+	assert.NoError(t, svc.Record(emergency))
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		want   int64
+	}{
+		{name: "no filter", filter: AuditFilter{}, want: 5},
+		{name: "by user", filter: AuditFilter{UserID: 1}, want: 2},
+		{name: "by pacient ignores users with the same ID", filter: AuditFilter{PacientID: 10}, want: 2},
+		{name: "by period", filter: AuditFilter{From: day.Add(30 * time.Minute), To: day.AddDate(0, 0, 2)}, want: 2},
+		{name: "combined", filter: AuditFilter{UserID: 2, PacientID: 10}, want: 1},
+		{name: "emergency only", filter: AuditFilter{Emergency: true}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, total, err := svc.GetAll(tt.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, total)
+			assert.Len(t, entries, int(tt.want))
+		})
+	}
+
+	// mais recente primeiro
+	entries, _, err := svc.GetAll(AuditFilter{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, enums.AuditUser, entries[0].ResourceType)
+}
+
+func TestServiceRecordMany(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+
+	now := time.Now()
+	assert.NoError(t, svc.Record())
+	assert.NoError(t, svc.Record(entry(1, enums.AuditPacient, 10, now), entry(1, enums.AuditPacient, 11, now)))
+
+	for _, pacientID := range []uint{10, 11} {
+		_, total, err := svc.GetAll(AuditFilter{PacientID: pacientID})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+	}
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+
+	record := entry(1, enums.AuditPacient, 10, time.Now())
+	record.Changes = models.AuditChanges{"name": {From: "Ana", To: "Ana Maria"}}
+	assert.NoError(t, svc.Record(record))
+
+	assert.ErrorIs(t, db.Model(record).Update("user_id", 99).Error, models.ErrAuditAppendOnly)
+	assert.ErrorIs(t, db.Delete(record).Error, models.ErrAuditAppendOnly)
+
+	var saved models.AuditLog
+	assert.NoError(t, db.First(&saved, record.ID).Error)
+	assert.Equal(t, uint(1), saved.UserID)
+	assert.Equal(t, models.AuditChange{From: "Ana", To: "Ana Maria"}, saved.Changes["name"])
+}
+
+func TestDiff(t *testing.T) {
+	type record struct {
+		Name      string
+		Phone     string
+		UpdatedAt time.Time
+	}
+
+	before := &record{Name: "Ana", Phone: "91", UpdatedAt: time.Now()}
+	after := &record{Name: "Ana Maria", Phone: "91", UpdatedAt: time.Now().Add(time.Second)}
+
+	changes, err := Diff(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AuditChanges{"Name": {From: "Ana", To: "Ana Maria"}}, changes)
+
+	changes, err = Diff(before, before)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+
+	// criação: não há estado anterior
+	var missing *record
+	changes, err = Diff(missing, after)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AuditChange{From: nil, To: "91"}, changes["Phone"])
+}
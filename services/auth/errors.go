@@ -0,0 +1,11 @@
+package auth
+
+import "errors"
+
+// ErrInvalidCredentials é a única resposta para CPF inexistente, senha errada
+// ou usuário inativo, para não revelar quais CPFs têm cadastro.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidChallenge cobre desafio de login inexistente, expirado, já usado
+// ou com tentativas esgotadas.
+var ErrInvalidChallenge = errors.New("invalid or expired login challenge")
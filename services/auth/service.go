@@ -1,44 +1,272 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/lockouts"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/services/sessions"
+	"github.com/andresidrim/cesupa-hospital/services/twofactor"
 	"github.com/andresidrim/cesupa-hospital/utils"
 	"gorm.io/gorm"
 )
 
+// TokenPair é o par entregue no login e no refresh: o access token (JWT de
+// curta duração) e o refresh token da sessão, que é trocado a cada uso.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
+}
+
+// Challenge é devolvido no lugar dos tokens quando o usuário tem segundo
+// fator: o token do desafio é trocado pelo par em VerifyTOTP.
+type Challenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// LoginResult traz os tokens da sessão ou, para quem tem TOTP, o desafio do
+// segundo passo.
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *Challenge
+}
+
+const (
+	challengeTTL         = 5 * time.Minute
+	challengeMaxAttempts = 5
+)
+
 type Service struct {
-	db *gorm.DB
+	db       *gorm.DB
+	sessions sessions.SessionService
+	lockouts lockouts.LockoutService
+	signer   *utils.JWTSigner
+	policy   passwords.Policy
+	totp     config.TOTP
+}
+
+func NewService(db *gorm.DB, sessionService sessions.SessionService, lockoutService lockouts.LockoutService, signer *utils.JWTSigner, policy passwords.Policy, totp config.TOTP) *Service {
+	return &Service{
+		db:       db,
+		sessions: sessionService,
+		lockouts: lockoutService,
+		signer:   signer,
+		policy:   policy,
+		totp:     totp,
+	}
 }
 
-func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+// dummyHash é comparado quando o CPF não existe, para que a resposta leve o
+// mesmo tempo de uma senha errada.
+var dummyHash, _ = utils.HashPassword("cesupa-hospital-dummy-password")
+
+// Login autentica pelo CPF. Bloqueios por excesso de falhas são checados
+// antes da senha; qualquer falha de credencial vira ErrInvalidCredentials.
+// Com TOTP ativo a sessão só é aberta em VerifyTOTP, e o contador de falhas
+// do CPF só é zerado lá.
+func (s *Service) Login(document cpf.CPF, password, userAgent, ip string) (*LoginResult, error) {
+	if err := s.lockouts.Check(document, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.authenticate(document, password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		if ferr := s.lockouts.RegisterFailure(document, ip); ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.HasTOTP() {
+		challenge, err := s.createChallenge(user.ID, userAgent, ip)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{Challenge: challenge}, nil
+	}
+
+	tokens, err := s.openSession(user, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
 }
 
-func (s *Service) Login(cpf, password string) (string, error) {
+// VerifyTOTP conclui o login de quem tem segundo fator. Códigos errados
+// contam para o bloqueio do CPF e do IP, e o desafio deixa de valer depois
+// de challengeMaxAttempts tentativas.
+func (s *Service) VerifyTOTP(challengeToken, code string) (*TokenPair, error) {
+	var challenge models.LoginChallenge
+	err := s.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?",
+		utils.HashToken(challengeToken), time.Now().UTC(), challengeMaxAttempts).
+		First(&challenge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	if err := s.db.Where("cpf = ?", cpf).First(&user).Error; err != nil {
-		return "", fmt.Errorf("user not found: %v", err)
+	if err := s.db.First(&user, challenge.UserID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+	if !user.IsActive() {
+		return nil, ErrInvalidChallenge
 	}
 
-	if err := utils.CheckPassword(user.Password, password); err != nil {
-		return "", fmt.Errorf("incorrect password: %v", err)
+	if err := s.lockouts.Check(user.CPF, challenge.IP); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&challenge).Where("used_at IS NULL").Update("used_at", time.Now().UTC())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidChallenge
+		}
+		return twofactor.Verify(tx, &user, code)
+	})
+	if errors.Is(err, twofactor.ErrInvalidCode) {
+		if uerr := s.db.Model(&challenge).Update("attempts", gorm.Expr("attempts + 1")).Error; uerr != nil {
+			return nil, uerr
+		}
+		if ferr := s.lockouts.RegisterFailure(user.CPF, challenge.IP); ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.openSession(&user, challenge.UserAgent, challenge.IP)
+}
+
+// Refresh troca o refresh token por um novo par. Usuários desativados
+// depois do login não conseguem renovar: Rotate confere o dono da sessão
+// antes da troca e devolve sessions.ErrUserInactive.
+func (s *Service) Refresh(refreshToken string) (*TokenPair, error) {
+	session, newRefreshToken, err := s.sessions.Rotate(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, session.UserID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+	if !user.IsActive() {
+		return nil, sessions.ErrUserInactive
+	}
+
+	return s.newTokenPair(&user, session.ID, newRefreshToken)
+}
+
+func (s *Service) Logout(userID, sessionID uint) error {
+	return s.sessions.Revoke(uint64(userID), uint64(sessionID))
+}
+
+func (s *Service) openSession(user *models.User, userAgent, ip string) (*TokenPair, error) {
+	if err := s.lockouts.RegisterSuccess(user.CPF); err != nil {
+		return nil, err
+	}
+
+	session, refreshToken, err := s.sessions.Create(user.ID, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newTokenPair(user, session.ID, refreshToken)
+}
+
+// createChallenge abre o desafio do segundo passo; só o hash do token fica no banco
+func (s *Service) createChallenge(userID uint, userAgent, ip string) (*Challenge, error) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
 	}
 
-	token, err := utils.GenerateJWT(user.ID)
+	challenge := models.LoginChallenge{
+		UserID:    userID,
+		TokenHash: utils.HashToken(token),
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().UTC().Add(challengeTTL),
+	}
+	if err := s.db.Create(&challenge).Error; err != nil {
+		return nil, fmt.Errorf("unable to create login challenge: %v", err)
+	}
+
+	return &Challenge{Token: token, ExpiresAt: challenge.ExpiresAt}, nil
+}
+
+func (s *Service) authenticate(document cpf.CPF, password string) (*models.User, error) {
+	var user models.User
+	err := s.db.Where("cpf = ?", document).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_ = utils.CheckPassword(dummyHash, password)
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("unable to fetch user: %v", err)
+	}
+
+	if err := utils.CheckPassword(user.Password, password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.IsActive() {
+		return nil, ErrInvalidCredentials
 	}
 
-	return token, nil
+	return &user, nil
 }
 
+// Register cadastra o usuário com uma senha definida pelo admin, que por
+// isso precisa ser trocada no primeiro acesso.
 func (s *Service) Register(user *models.User) error {
-	hashed, err := utils.HashPassword(user.Password)
+	hashed, err := s.policy.Hash(user.Password)
 	if err != nil {
 		return err
 	}
 	user.Password = hashed
-	return s.db.Create(user).Error
+	user.MustChangePassword = true
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return s.policy.Remember(tx, user.ID, hashed)
+	})
+}
+
+func (s *Service) newTokenPair(user *models.User, sessionID uint, refreshToken string) (*TokenPair, error) {
+	accessToken, err := s.signer.Generate(utils.TokenSubject{
+		UserID:             user.ID,
+		Role:               user.Role,
+		SessionID:          sessionID,
+		MustChangePassword: user.MustChangePassword,
+		MustEnrollTOTP:     twofactor.Required(s.totp, user.Role) && !user.HasTOTP(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.signer.AccessTokenTTL().Seconds()),
+	}, nil
 }
@@ -1,8 +1,14 @@
 package auth
 
-import "github.com/andresidrim/cesupa-hospital/models"
+import (
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/models"
+)
 
 type AuthService interface {
-	Login(cpf, password string) (string, error)
+	Login(document cpf.CPF, password, userAgent, ip string) (*LoginResult, error)
+	VerifyTOTP(challengeToken, code string) (*TokenPair, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	Logout(userID, sessionID uint) error
 	Register(user *models.User) error
 }
@@ -2,27 +2,43 @@ package auth
 
 import (
 	"testing"
+	"time"
 
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/lockouts"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/services/sessions"
+	"github.com/andresidrim/cesupa-hospital/services/twofactor"
+	"github.com/andresidrim/cesupa-hospital/totp"
 	"github.com/andresidrim/cesupa-hospital/utils"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-// setupTestDB abre um DB SQLite em memória e faz AutoMigrate de models.User
+// setupTestDB abre um DB de teste com usuários, sessões, tentativas de login e segundo fator
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	assert.NoError(t, err)
-	err = db.AutoMigrate(&models.User{})
+	return dbtest.Open(t, &models.User{}, &models.Session{}, &models.LoginAttempt{}, &models.PasswordHistory{},
+		&models.RecoveryCode{}, &models.LoginChallenge{})
+}
+
+// newTestService monta o serviço com as dependências reais e a configuração padrão
+func newTestService(t *testing.T, db *gorm.DB) *Service {
+	cfg := config.Default()
+	cfg.JWT.SecretKey = "0123456789abcdef0123456789abcdef"
+	signer, err := utils.NewJWTSigner(cfg.JWT)
 	assert.NoError(t, err)
-	return db
+
+	return NewService(db, sessions.NewService(db, cfg.Session), lockouts.NewService(db, cfg.Login), signer,
+		passwords.NewPolicy(cfg.Password), cfg.TOTP)
 }
 
 // TestServiceRegister cobre registro bem-sucedido e duplicidade de CPF
 func TestServiceRegister(t *testing.T) {
 	db := setupTestDB(t)
-	svc := NewService(db)
+	svc := newTestService(t, db)
 
 	tests := []struct {
 		name      string
@@ -30,8 +46,9 @@ func TestServiceRegister(t *testing.T) {
 		preInsert bool
 		wantErr   bool
 	}{
-		{name: "successful register", input: models.User{Name: "Alice", CPF: "12345678900", Password: "secret", Role: "admin"}, wantErr: false},
-		{name: "duplicate cpf", input: models.User{Name: "Bob", CPF: "12345678900", Password: "pwd123", Role: "doctor"}, preInsert: true, wantErr: true},
+		{name: "successful register", input: models.User{Name: "Alice", CPF: "12345678900", Password: "Secret-123", Role: "admin"}, wantErr: false},
+		{name: "weak password", input: models.User{Name: "Weak", CPF: "12345678909", Password: "secret", Role: "admin"}, wantErr: true},
+		{name: "duplicate cpf", input: models.User{Name: "Bob", CPF: "12345678900", Password: "Pwd-12345", Role: "doctor"}, preInsert: true, wantErr: true},
 	}
 
 	for _, tt := range tests {
@@ -41,7 +58,7 @@ func TestServiceRegister(t *testing.T) {
 
 			if tt.preInsert {
 				// insere user inicial para duplicidade
-				assert.NoError(t, svc.Register(&models.User{Name: "Init", CPF: tt.input.CPF, Password: "x", Role: "admin"}))
+				assert.NoError(t, svc.Register(&models.User{Name: "Init", CPF: tt.input.CPF, Password: "Init-pass1", Role: "admin"}))
 			}
 
 			// armazena senha antes de hash
@@ -62,6 +79,8 @@ func TestServiceRegister(t *testing.T) {
 			// valida hash
 			err = utils.CheckPassword(saved.Password, raw)
 			assert.NoError(t, err)
+			// senha definida pelo admin: troca obrigatória no primeiro acesso
+			assert.True(t, saved.MustChangePassword)
 		})
 	}
 }
@@ -69,39 +88,212 @@ func TestServiceRegister(t *testing.T) {
 // TestServiceLogin cobre erros e sucesso de login via JWT
 func TestServiceLogin(t *testing.T) {
 	db := setupTestDB(t)
-	svc := NewService(db)
+	svc := newTestService(t, db)
 
 	// cria usuário para login
-	plain := "mypassword"
+	plain := "My-password1"
 	user := models.User{Name: "Carol", CPF: "55544433322", Password: plain, Role: "doctor"}
 	assert.NoError(t, svc.Register(&user))
 
+	deactivatedAt := time.Now()
+	inactive := models.User{Name: "Dave", CPF: "11122233344", Password: plain, Role: "doctor", DeactivatedAt: &deactivatedAt}
+	assert.NoError(t, svc.Register(&inactive))
+
 	tests := []struct {
 		name        string
-		cpf         string
+		cpf         cpf.CPF
 		password    string
 		wantErr     bool
 		wantParseOK bool
 	}{
 		{name: "user_not_found", cpf: "nouser", password: "any", wantErr: true},
 		{name: "incorrect_password", cpf: user.CPF, password: "wrongpass", wantErr: true},
+		{name: "inactive_user", cpf: inactive.CPF, password: plain, wantErr: true},
 		{name: "success", cpf: user.CPF, password: plain, wantErr: false, wantParseOK: true},
+		{name: "success_formatted", cpf: "555.444.333-22", password: plain, wantErr: false, wantParseOK: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := svc.Login(tt.cpf, tt.password)
+			result, err := svc.Login(tt.cpf, tt.password, "test-agent", "127.0.0.1")
 			if tt.wantErr {
-				assert.Error(t, err)
+				// mesma resposta para CPF inexistente, senha errada e inativo
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
 				return
 			}
 			assert.NoError(t, err)
-			assert.NotEmpty(t, token)
+			assert.Nil(t, result.Challenge)
+			tokens := result.Tokens
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
 			if tt.wantParseOK {
-				uid, perr := utils.ParseJWT(token)
+				claims, perr := svc.signer.Parse(tokens.AccessToken)
 				assert.NoError(t, perr)
-				assert.Equal(t, user.ID, uid)
+				assert.Equal(t, user.ID, claims.UserID())
+				assert.Equal(t, user.Role, claims.Role)
+
+				var session models.Session
+				assert.NoError(t, db.First(&session, claims.SessionID).Error)
+				assert.Equal(t, user.ID, session.UserID)
+				assert.Equal(t, "test-agent", session.UserAgent)
 			}
 		})
 	}
 }
+
+// TestServiceRefreshAndLogout cobre a rotação do refresh token e o logout
+func TestServiceRefreshAndLogout(t *testing.T) {
+	db := setupTestDB(t)
+	db.Exec("DELETE FROM users")
+	svc := newTestService(t, db)
+
+	user := models.User{Name: "Erin", CPF: "99988877766", Password: "My-password1", Role: "doctor"}
+	assert.NoError(t, svc.Register(&user))
+
+	result, err := svc.Login(user.CPF, "My-password1", "", "")
+	assert.NoError(t, err)
+	tokens := result.Tokens
+
+	refreshed, err := svc.Refresh(tokens.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+	claims, err := svc.signer.Parse(refreshed.AccessToken)
+	assert.NoError(t, err)
+	sid := claims.SessionID
+
+	// reapresentar o refresh token antigo revoga a sessão
+	_, err = svc.Refresh(tokens.RefreshToken)
+	assert.Error(t, err)
+	var session models.Session
+	assert.NoError(t, db.First(&session, sid).Error)
+	assert.NotNil(t, session.RevokedAt)
+
+	other, err := svc.Login(user.CPF, "My-password1", "", "")
+	assert.NoError(t, err)
+	otherClaims, err := svc.signer.Parse(other.Tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.Logout(user.ID, otherClaims.SessionID))
+	_, err = svc.Refresh(other.Tokens.RefreshToken)
+	assert.Error(t, err)
+
+	// usuário desativado não renova
+	last, err := svc.Login(user.CPF, "My-password1", "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(&user).Update("deactivated_at", time.Now()).Error)
+	_, err = svc.Refresh(last.Tokens.RefreshToken)
+	assert.ErrorIs(t, err, sessions.ErrUserInactive)
+}
+
+// TestServiceLoginLockout cobre o bloqueio após falhas seguidas
+func TestServiceLoginLockout(t *testing.T) {
+	db := setupTestDB(t)
+	db.Exec("DELETE FROM login_attempts")
+	svc := newTestService(t, db)
+
+	user := models.User{Name: "Frank", CPF: "52998224725", Password: "My-password1", Role: "doctor"}
+	assert.NoError(t, svc.Register(&user))
+
+	for range config.Default().Login.MaxAttempts {
+		_, err := svc.Login(user.CPF, "wrong", "", "10.1.1.1")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// bloqueado, nem a senha certa entra
+	_, err := svc.Login(user.CPF, "My-password1", "", "10.1.1.2")
+	var locked *lockouts.LockedError
+	assert.ErrorAs(t, err, &locked)
+
+	// CPF sem cadastro é bloqueado do mesmo jeito, sem revelar que não existe
+	for range config.Default().Login.MaxAttempts {
+		_, err := svc.Login("11144477735", "any", "", "10.1.1.3")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = svc.Login("11144477735", "any", "", "10.1.1.4")
+	assert.ErrorAs(t, err, &locked)
+
+	// liberado pelo admin, o login volta a funcionar e zera o contador
+	assert.NoError(t, db.Exec("DELETE FROM login_attempts WHERE identifier = ?", user.CPF.Digits()).Error)
+	_, err = svc.Login(user.CPF, "My-password1", "", "10.1.1.2")
+	assert.NoError(t, err)
+}
+
+// TestServiceLoginTOTP cobre o login em dois passos de quem tem segundo fator
+func TestServiceLoginTOTP(t *testing.T) {
+	db := setupTestDB(t)
+	db.Exec("DELETE FROM login_attempts")
+	svc := newTestService(t, db)
+
+	user := models.User{Name: "Grace", CPF: "39053344705", Password: "My-password1", Role: "doctor"}
+	assert.NoError(t, svc.Register(&user))
+
+	// admin sem TOTP recebe token restrito ao cadastro do segundo fator
+	admin := models.User{Name: "Heidi", CPF: "86288366757", Password: "My-password1", Role: "admin"}
+	assert.NoError(t, svc.Register(&admin))
+	adminLogin, err := svc.Login(admin.CPF, "My-password1", "", "")
+	assert.NoError(t, err)
+	adminClaims, err := svc.signer.Parse(adminLogin.Tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, adminClaims.MustEnrollTOTP)
+
+	tfs := twofactor.NewService(db, config.Default().TOTP)
+	enrollment, err := tfs.Setup(user.ID)
+	assert.NoError(t, err)
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	assert.NoError(t, err)
+	recoveryCodes, err := tfs.Enable(user.ID, code)
+	assert.NoError(t, err)
+
+	login := func() *Challenge {
+		result, err := svc.Login(user.CPF, "My-password1", "agent", "10.2.2.2")
+		assert.NoError(t, err)
+		assert.Nil(t, result.Tokens)
+		assert.NotNil(t, result.Challenge)
+		return result.Challenge
+	}
+
+	challenge := login()
+	_, err = svc.VerifyTOTP(challenge.Token, "000000")
+	assert.ErrorIs(t, err, twofactor.ErrInvalidCode)
+
+	// o código usado no cadastro não vale de novo; o do próximo passo vale
+	_, err = svc.VerifyTOTP(challenge.Token, code)
+	assert.ErrorIs(t, err, twofactor.ErrInvalidCode)
+	next, err := totp.Code(enrollment.Secret, time.Now().Add(totp.Period))
+	assert.NoError(t, err)
+	tokens, err := svc.VerifyTOTP(challenge.Token, next)
+	assert.NoError(t, err)
+	claims, err := svc.signer.Parse(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID())
+	assert.False(t, claims.MustEnrollTOTP)
+
+	var session models.Session
+	assert.NoError(t, db.First(&session, claims.SessionID).Error)
+	assert.Equal(t, "agent", session.UserAgent)
+
+	// desafio já usado não abre outra sessão
+	_, err = svc.VerifyTOTP(challenge.Token, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	// código de recuperação vale uma única vez
+	_, err = svc.VerifyTOTP(login().Token, recoveryCodes[0])
+	assert.NoError(t, err)
+	_, err = svc.VerifyTOTP(login().Token, recoveryCodes[0])
+	assert.ErrorIs(t, err, twofactor.ErrInvalidCode)
+
+	// códigos errados contam para o bloqueio do CPF e esgotam o desafio
+	assert.NoError(t, db.Exec("DELETE FROM login_attempts").Error)
+	challenge = login()
+	for range challengeMaxAttempts {
+		_, err = svc.VerifyTOTP(challenge.Token, "000000")
+		assert.ErrorIs(t, err, twofactor.ErrInvalidCode)
+	}
+	_, err = svc.Login(user.CPF, "My-password1", "", "10.2.2.2")
+	var locked *lockouts.LockedError
+	assert.ErrorAs(t, err, &locked)
+
+	assert.NoError(t, db.Exec("DELETE FROM login_attempts").Error)
+	_, err = svc.VerifyTOTP(challenge.Token, recoveryCodes[1])
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+}
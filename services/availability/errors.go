@@ -0,0 +1,46 @@
+package availability
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/models"
+)
+
+var (
+	ErrInvalidTime      = errors.New("times must use the HH:MM format")
+	ErrInvalidInterval  = errors.New("end must be after start")
+	ErrInvalidWeekday   = errors.New("weekday must be between 0 (sunday) and 6 (saturday)")
+	ErrNotDoctor        = errors.New("user is not a doctor")
+	ErrOutsideShift     = errors.New("doctor does not work at the requested time")
+	ErrOverlappingShift = errors.New("shift overlaps another shift of the same doctor")
+	ErrMultiDayInterval = errors.New("appointment must start and end on the same day")
+	ErrRangeTooLarge    = errors.New("search range must not exceed 31 days")
+)
+
+// BlockedError indica que o horário cai dentro de um bloqueio da agenda do
+// médico. Location é o fuso em que o bloqueio é mostrado (UTC se nil).
+type BlockedError struct {
+	Block    models.AvailabilityBlock
+	Location *time.Location
+}
+
+func (e *BlockedError) Error() string {
+	loc := e.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return fmt.Sprintf("doctor is unavailable (%s) from %s to %s",
+		e.Block.Reason,
+		e.Block.StartsAt.In(loc).Format("2006-01-02 15:04"),
+		e.Block.EndsAt.In(loc).Format("2006-01-02 15:04"),
+	)
+}
+
+// IsUnavailable informa se o erro indica que o médico não atende no horário.
+func IsUnavailable(err error) bool {
+	var blockedErr *BlockedError
+	return errors.Is(err, ErrOutsideShift) || errors.Is(err, ErrMultiDayInterval) || errors.As(err, &blockedErr)
+}
@@ -0,0 +1,252 @@
+package availability
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"gorm.io/gorm"
+)
+
+const clockLayout = "15:04"
+
+type Service struct {
+	db *gorm.DB
+
+	// location é o fuso em que os horários dos turnos são interpretados
+	location *time.Location
+}
+
+func NewService(db *gorm.DB, location *time.Location) *Service {
+	return &Service{db: db, location: location}
+}
+
+func (s *Service) CreateShift(shift *models.Shift) error {
+	if err := s.validateShift(shift); err != nil {
+		return err
+	}
+
+	return s.db.Create(shift).Error
+}
+
+func (s *Service) GetShifts(doctorID uint64) ([]models.Shift, error) {
+	var shifts []models.Shift
+	if err := s.db.Where("user_id = ?", doctorID).Order("weekday, start_time").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+
+	return shifts, nil
+}
+
+func (s *Service) UpdateShift(id uint64, shift *models.Shift) error {
+	shift.ID = uint(id)
+	if err := s.validateShift(shift); err != nil {
+		return err
+	}
+
+	result := s.db.Model(&models.Shift{}).
+		Where("id = ? AND user_id = ?", id, shift.UserID).
+		Select("weekday", "start_time", "end_time").
+		Updates(shift)
+	if result.Error != nil {
+		return fmt.Errorf("unable to update shift: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *Service) DeleteShift(doctorID uint64, id uint64) error {
+	result := s.db.Delete(&models.Shift{}, "id = ? AND user_id = ?", id, doctorID)
+	if result.Error != nil {
+		return fmt.Errorf("unable to delete shift: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *Service) CreateBlock(block *models.AvailabilityBlock) error {
+	if err := s.validateBlock(block); err != nil {
+		return err
+	}
+
+	return s.db.Create(block).Error
+}
+
+func (s *Service) GetBlocks(doctorID uint64, from, to time.Time) ([]models.AvailabilityBlock, error) {
+	var blocks []models.AvailabilityBlock
+	query := s.db.Where("user_id = ?", doctorID)
+
+	if !from.IsZero() {
+		query = query.Where("ends_at > ?", from.UTC())
+	}
+	if !to.IsZero() {
+		query = query.Where("starts_at < ?", to.UTC())
+	}
+
+	if err := query.Order("starts_at").Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+func (s *Service) UpdateBlock(id uint64, block *models.AvailabilityBlock) error {
+	if err := s.validateBlock(block); err != nil {
+		return err
+	}
+
+	result := s.db.Model(&models.AvailabilityBlock{}).
+		Where("id = ? AND user_id = ?", id, block.UserID).
+		Select("starts_at", "ends_at", "reason", "notes").
+		Updates(block)
+	if result.Error != nil {
+		return fmt.Errorf("unable to update block: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *Service) DeleteBlock(doctorID uint64, id uint64) error {
+	result := s.db.Delete(&models.AvailabilityBlock{}, "id = ? AND user_id = ?", id, doctorID)
+	if result.Error != nil {
+		return fmt.Errorf("unable to delete block: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CheckAvailability confirma que o intervalo [start, end) cabe inteiro em um
+// turno do médico e não cruza nenhum bloqueio. Os horários dos turnos são
+// interpretados no fuso configurado em TIMEZONE.
+func (s *Service) CheckAvailability(doctorID uint, start, end time.Time) error {
+	if !end.After(start) {
+		return ErrInvalidInterval
+	}
+
+	start, end = start.In(s.location), end.In(s.location)
+	if !sameDay(start, end) && !isMidnightAfter(start, end) {
+		return ErrMultiDayInterval
+	}
+
+	endClock := end.Format(clockLayout)
+	if isMidnightAfter(start, end) {
+		endClock = "24:00"
+	}
+
+	var shift models.Shift
+	err := s.db.
+		Where("user_id = ? AND weekday = ? AND start_time <= ? AND end_time >= ?",
+			doctorID, start.Weekday(), start.Format(clockLayout), endClock).
+		First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrOutsideShift
+	}
+	if err != nil {
+		return fmt.Errorf("unable to check shifts: %v", err)
+	}
+
+	var block models.AvailabilityBlock
+	err = s.db.
+		Where("user_id = ? AND starts_at < ? AND ends_at > ?", doctorID, end.UTC(), start.UTC()).
+		Order("starts_at").
+		First(&block).Error
+	if err == nil {
+		return &BlockedError{Block: block, Location: s.location}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("unable to check blocks: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) validateShift(shift *models.Shift) error {
+	if shift.Weekday < time.Sunday || shift.Weekday > time.Saturday {
+		return ErrInvalidWeekday
+	}
+
+	start, err := normalizeClock(shift.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := normalizeClock(shift.EndTime)
+	if err != nil {
+		return err
+	}
+	if end <= start {
+		return ErrInvalidInterval
+	}
+	shift.StartTime, shift.EndTime = start, end
+
+	if err := s.ensureDoctor(shift.UserID); err != nil {
+		return err
+	}
+
+	var overlapping int64
+	err = s.db.Model(&models.Shift{}).
+		Where("user_id = ? AND weekday = ? AND start_time < ? AND end_time > ? AND id <> ?",
+			shift.UserID, shift.Weekday, shift.EndTime, shift.StartTime, shift.ID).
+		Count(&overlapping).Error
+	if err != nil {
+		return fmt.Errorf("unable to check shifts: %v", err)
+	}
+	if overlapping > 0 {
+		return ErrOverlappingShift
+	}
+
+	return nil
+}
+
+func (s *Service) validateBlock(block *models.AvailabilityBlock) error {
+	if !block.EndsAt.After(block.StartsAt) {
+		return ErrInvalidInterval
+	}
+	block.StartsAt, block.EndsAt = block.StartsAt.UTC(), block.EndsAt.UTC()
+
+	return s.ensureDoctor(block.UserID)
+}
+
+func (s *Service) ensureDoctor(userID uint) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.Role != enums.Doctor {
+		return ErrNotDoctor
+	}
+	return nil
+}
+
+// normalizeClock aceita "8:00" ou "08:00" e devolve sempre "08:00", para que
+// a comparação textual no banco respeite a ordem cronológica.
+func normalizeClock(value string) (string, error) {
+	if value == "24:00" {
+		return value, nil
+	}
+
+	t, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return "", ErrInvalidTime
+	}
+	return t.Format(clockLayout), nil
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func isMidnightAfter(start, end time.Time) bool {
+	return end.Hour() == 0 && end.Minute() == 0 && sameDay(start.AddDate(0, 0, 1), end)
+}
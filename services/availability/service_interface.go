@@ -0,0 +1,20 @@
+package availability
+
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/models"
+)
+
+type AvailabilityService interface {
+	CreateShift(shift *models.Shift) error
+	GetShifts(doctorID uint64) ([]models.Shift, error)
+	UpdateShift(id uint64, shift *models.Shift) error
+	DeleteShift(doctorID uint64, id uint64) error
+	CreateBlock(block *models.AvailabilityBlock) error
+	GetBlocks(doctorID uint64, from, to time.Time) ([]models.AvailabilityBlock, error)
+	UpdateBlock(id uint64, block *models.AvailabilityBlock) error
+	DeleteBlock(doctorID uint64, id uint64) error
+	CheckAvailability(doctorID uint, start, end time.Time) error
+	FindSlots(filter SlotFilter) ([]Slot, error)
+}
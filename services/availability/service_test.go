@@ -0,0 +1,327 @@
+package availability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// testLocation é o fuso padrão da clínica
+var testLocation = config.Default().Location
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.User{}, &models.Shift{}, &models.AvailabilityBlock{})
+}
+
+func createDoctor(t *testing.T, db *gorm.DB, document string) models.User {
+	doctor := models.User{Name: "Dr. " + document, CPF: cpf.CPF(document), Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	return doctor
+}
+
+// mondayAt devolve uma segunda-feira fixa no fuso da clínica
+func mondayAt(hour, minute int) time.Time {
+	return time.Date(2030, 1, 7, hour, minute, 0, 0, testLocation)
+}
+
+func TestServiceCreateShift(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testLocation)
+
+	doctor := createDoctor(t, db, "111")
+	receptionist := models.User{Name: "Carol", CPF: "222", Role: enums.Receptionist}
+	assert.NoError(t, db.Create(&receptionist).Error)
+
+	assert.NoError(t, service.CreateShift(&models.Shift{UserID: doctor.ID, Weekday: time.Monday, StartTime: "08:00", EndTime: "12:00"}))
+
+	tests := []struct {
+		name    string
+		shift   models.Shift
+		wantErr error
+	}{
+		{
+			name:  "afternoon shift",
+			shift: models.Shift{UserID: doctor.ID, Weekday: time.Monday, StartTime: "14:00", EndTime: "18:00"},
+		},
+		{
+			name:  "single digit hour is normalized",
+			shift: models.Shift{UserID: doctor.ID, Weekday: time.Tuesday, StartTime: "8:00", EndTime: "9:30"},
+		},
+		{
+			name:    "overlapping shift",
+			shift:   models.Shift{UserID: doctor.ID, Weekday: time.Monday, StartTime: "11:00", EndTime: "13:00"},
+			wantErr: ErrOverlappingShift,
+		},
+		{
+			name:    "end before start",
+			shift:   models.Shift{UserID: doctor.ID, Weekday: time.Friday, StartTime: "12:00", EndTime: "08:00"},
+			wantErr: ErrInvalidInterval,
+		},
+		{
+			name:    "invalid time",
+			shift:   models.Shift{UserID: doctor.ID, Weekday: time.Friday, StartTime: "8h", EndTime: "12:00"},
+			wantErr: ErrInvalidTime,
+		},
+		{
+			name:    "invalid weekday",
+			shift:   models.Shift{UserID: doctor.ID, Weekday: 7, StartTime: "08:00", EndTime: "12:00"},
+			wantErr: ErrInvalidWeekday,
+		},
+		{
+			name:    "not a doctor",
+			shift:   models.Shift{UserID: receptionist.ID, Weekday: time.Monday, StartTime: "08:00", EndTime: "12:00"},
+			wantErr: ErrNotDoctor,
+		},
+		{
+			name:    "unknown user",
+			shift:   models.Shift{UserID: 9999, Weekday: time.Monday, StartTime: "08:00", EndTime: "12:00"},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.CreateShift(&tt.shift)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotZero(t, tt.shift.ID)
+		})
+	}
+
+	shifts, err := service.GetShifts(uint64(doctor.ID))
+	assert.NoError(t, err)
+	assert.Len(t, shifts, 3)
+	assert.Equal(t, "08:00", shifts[2].StartTime)
+}
+
+func TestServiceUpdateAndDeleteShift(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testLocation)
+
+	doctor := createDoctor(t, db, "111")
+	other := createDoctor(t, db, "222")
+
+	shift := models.Shift{UserID: doctor.ID, Weekday: time.Monday, StartTime: "08:00", EndTime: "12:00"}
+	assert.NoError(t, service.CreateShift(&shift))
+
+	// o próprio turno não conta como sobreposição
+	update := models.Shift{UserID: doctor.ID, Weekday: time.Monday, StartTime: "09:00", EndTime: "13:00"}
+	assert.NoError(t, service.UpdateShift(uint64(shift.ID), &update))
+
+	wrongOwner := models.Shift{UserID: other.ID, Weekday: time.Monday, StartTime: "09:00", EndTime: "13:00"}
+	assert.ErrorIs(t, service.UpdateShift(uint64(shift.ID), &wrongOwner), gorm.ErrRecordNotFound)
+
+	assert.ErrorIs(t, service.DeleteShift(uint64(other.ID), uint64(shift.ID)), gorm.ErrRecordNotFound)
+	assert.NoError(t, service.DeleteShift(uint64(doctor.ID), uint64(shift.ID)))
+
+	shifts, err := service.GetShifts(uint64(doctor.ID))
+	assert.NoError(t, err)
+	assert.Empty(t, shifts)
+}
+
+func TestServiceBlocks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testLocation)
+
+	doctor := createDoctor(t, db, "111")
+
+	vacation := models.AvailabilityBlock{UserID: doctor.ID, StartsAt: mondayAt(0, 0), EndsAt: mondayAt(0, 0).AddDate(0, 0, 7), Reason: enums.Vacation}
+	assert.NoError(t, service.CreateBlock(&vacation))
+
+	invalid := models.AvailabilityBlock{UserID: doctor.ID, StartsAt: mondayAt(10, 0), EndsAt: mondayAt(9, 0), Reason: enums.SickLeave}
+	assert.ErrorIs(t, service.CreateBlock(&invalid), ErrInvalidInterval)
+
+	blocks, err := service.GetBlocks(uint64(doctor.ID), mondayAt(0, 0).AddDate(0, 0, 3), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, blocks, 1)
+
+	blocks, err = service.GetBlocks(uint64(doctor.ID), mondayAt(0, 0).AddDate(0, 0, 8), time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, blocks)
+
+	vacation.Reason = enums.Congress
+	assert.NoError(t, service.UpdateBlock(uint64(vacation.ID), &vacation))
+	assert.NoError(t, service.DeleteBlock(uint64(doctor.ID), uint64(vacation.ID)))
+	assert.ErrorIs(t, service.DeleteBlock(uint64(doctor.ID), uint64(vacation.ID)), gorm.ErrRecordNotFound)
+}
+
+func TestServiceCheckAvailability(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testLocation)
+
+	doctor := createDoctor(t, db, "111")
+	assert.NoError(t, service.CreateShift(&models.Shift{UserID: doctor.ID, Weekday: time.Monday, StartTime: "08:00", EndTime: "12:00"}))
+	assert.NoError(t, service.CreateShift(&models.Shift{UserID: doctor.ID, Weekday: time.Monday, StartTime: "20:00", EndTime: "24:00"}))
+	assert.NoError(t, service.CreateBlock(&models.AvailabilityBlock{
+		UserID:   doctor.ID,
+		StartsAt: mondayAt(10, 0),
+		EndsAt:   mondayAt(10, 30),
+		Reason:   enums.SickLeave,
+	}))
+
+	tests := []struct {
+		name        string
+		start       time.Time
+		duration    time.Duration
+		wantErr     error
+		wantBlocked bool
+	}{
+		{name: "start of shift", start: mondayAt(8, 0), duration: 30 * time.Minute},
+		{name: "end of shift", start: mondayAt(11, 30), duration: 30 * time.Minute},
+		{name: "until midnight", start: mondayAt(23, 30), duration: 30 * time.Minute},
+		{name: "same instant in UTC", start: mondayAt(9, 0).UTC(), duration: 30 * time.Minute},
+		{name: "before shift", start: mondayAt(7, 30), duration: 30 * time.Minute, wantErr: ErrOutsideShift},
+		{name: "crossing end of shift", start: mondayAt(11, 45), duration: 30 * time.Minute, wantErr: ErrOutsideShift},
+		{name: "between shifts", start: mondayAt(15, 0), duration: 30 * time.Minute, wantErr: ErrOutsideShift},
+		{name: "other day", start: mondayAt(9, 0).AddDate(0, 0, 1), duration: 30 * time.Minute, wantErr: ErrOutsideShift},
+		{name: "crossing midnight", start: mondayAt(23, 45), duration: 30 * time.Minute, wantErr: ErrMultiDayInterval},
+		{name: "empty interval", start: mondayAt(9, 0), wantErr: ErrInvalidInterval},
+		{name: "overlapping block", start: mondayAt(10, 15), duration: 30 * time.Minute, wantBlocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.CheckAvailability(doctor.ID, tt.start, tt.start.Add(tt.duration))
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantBlocked:
+				var blockedErr *BlockedError
+				assert.ErrorAs(t, err, &blockedErr)
+				assert.True(t, IsUnavailable(err))
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestServiceFindSlots(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&models.Pacient{}, &models.Appointment{}, &models.Specialty{}, &models.DoctorProfile{}))
+	service := NewService(db, testLocation)
+
+	smith := createDoctor(t, db, "111")
+	house := createDoctor(t, db, "222")
+	assert.NoError(t, service.CreateShift(&models.Shift{UserID: smith.ID, Weekday: time.Monday, StartTime: "08:00", EndTime: "10:00"}))
+	assert.NoError(t, service.CreateShift(&models.Shift{UserID: house.ID, Weekday: time.Monday, StartTime: "09:00", EndTime: "10:00"}))
+
+	// Smith tem uma consulta às 08:30 e um bloqueio às 09:30
+	pacient := models.Pacient{Name: "John", BirthDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "333", Sex: "male", PhoneNumber: "1", Address: "Street"}
+	assert.NoError(t, db.Create(&pacient).Error)
+	assert.NoError(t, db.Create(&models.Appointment{PacientID: pacient.ID, UserID: smith.ID, Date: mondayAt(8, 30).UTC(), EndDate: mondayAt(9, 0).UTC()}).Error)
+	assert.NoError(t, service.CreateBlock(&models.AvailabilityBlock{UserID: smith.ID, StartsAt: mondayAt(9, 30), EndsAt: mondayAt(10, 0), Reason: enums.Congress}))
+
+	day := SlotFilter{From: mondayAt(0, 0), To: mondayAt(0, 0).AddDate(0, 0, 1), Duration: 30 * time.Minute}
+
+	t.Run("single doctor", func(t *testing.T) {
+		filter := day
+		filter.DoctorIDs = []uint{smith.ID}
+
+		slots, err := service.FindSlots(filter)
+		assert.NoError(t, err)
+		if assert.Len(t, slots, 2) {
+			assert.True(t, mondayAt(8, 0).Equal(slots[0].Start))
+			assert.True(t, mondayAt(9, 0).Equal(slots[1].Start))
+		}
+	})
+
+	t.Run("all doctors with limit", func(t *testing.T) {
+		filter := day
+		filter.Limit = 3
+
+		slots, err := service.FindSlots(filter)
+		assert.NoError(t, err)
+		if assert.Len(t, slots, 3) {
+			assert.Equal(t, smith.ID, slots[0].DoctorID)
+			assert.True(t, mondayAt(9, 0).Equal(slots[1].Start))
+			assert.Equal(t, smith.ID, slots[1].DoctorID)
+			assert.Equal(t, house.ID, slots[2].DoctorID)
+		}
+	})
+
+	t.Run("by specialty", func(t *testing.T) {
+		cardiology := models.Specialty{Name: "Cardiologia"}
+		assert.NoError(t, db.Create(&cardiology).Error)
+		profile := models.DoctorProfile{UserID: house.ID, CRM: "1234", CRMUF: "PA", Specialties: []models.Specialty{cardiology}}
+		assert.NoError(t, db.Create(&profile).Error)
+
+		filter := day
+		filter.Specialty = "cardiologia"
+
+		slots, err := service.FindSlots(filter)
+		assert.NoError(t, err)
+		if assert.Len(t, slots, 2) {
+			assert.Equal(t, house.ID, slots[0].DoctorID)
+			assert.Equal(t, house.ID, slots[1].DoctorID)
+		}
+
+		filter.DoctorIDs = []uint{smith.ID}
+		slots, err = service.FindSlots(filter)
+		assert.NoError(t, err)
+		assert.Empty(t, slots)
+
+		filter = day
+		filter.Specialty = "Dermatologia"
+		slots, err = service.FindSlots(filter)
+		assert.NoError(t, err)
+		assert.Empty(t, slots)
+	})
+
+	t.Run("longer duration", func(t *testing.T) {
+		filter := day
+		filter.DoctorIDs = []uint{house.ID}
+		filter.Duration = time.Hour
+
+		slots, err := service.FindSlots(filter)
+		assert.NoError(t, err)
+		assert.Len(t, slots, 1)
+	})
+
+	t.Run("doctor's consultation duration", func(t *testing.T) {
+		assert.NoError(t, db.Model(&models.DoctorProfile{}).Where("user_id = ?", house.ID).Update("consultation_minutes", 60).Error)
+
+		filter := day
+		filter.Duration = 0
+		slots, err := service.FindSlots(filter)
+		assert.NoError(t, err)
+
+		// Smith não tem perfil e fica com a duração padrão; House tem consultas de uma hora
+		var houseSlots []Slot
+		for _, slot := range slots {
+			if slot.DoctorID == house.ID {
+				houseSlots = append(houseSlots, slot)
+			} else {
+				assert.Equal(t, 30*time.Minute, slot.End.Sub(slot.Start))
+			}
+		}
+		if assert.Len(t, houseSlots, 1) {
+			assert.True(t, mondayAt(9, 0).Equal(houseSlots[0].Start))
+			assert.Equal(t, time.Hour, houseSlots[0].End.Sub(houseSlots[0].Start))
+		}
+	})
+
+	t.Run("range too large", func(t *testing.T) {
+		filter := day
+		filter.To = filter.From.AddDate(0, 2, 0)
+
+		_, err := service.FindSlots(filter)
+		assert.ErrorIs(t, err, ErrRangeTooLarge)
+	})
+
+	t.Run("past range", func(t *testing.T) {
+		slots, err := service.FindSlots(SlotFilter{From: time.Now().AddDate(0, 0, -8), To: time.Now().AddDate(0, 0, -1), Duration: 30 * time.Minute})
+		assert.NoError(t, err)
+		assert.Empty(t, slots)
+	})
+}
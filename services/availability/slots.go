@@ -0,0 +1,193 @@
+package availability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/doctors"
+)
+
+// MaxSlotRange limita o intervalo pesquisado para manter a busca barata
+const MaxSlotRange = 31 * 24 * time.Hour
+
+// Slot é um horário livre de um médico
+type Slot struct {
+	DoctorID uint      `json:"doctorId"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// SlotFilter define a busca de horários livres. Sem DoctorIDs a busca cobre
+// todos os médicos; Specialty restringe aos médicos com a especialidade, pelo
+// nome e sem diferenciar maiúsculas; Duration 0 usa a duração de consulta de
+// cada médico, a mesma do agendamento; Limit 0 devolve todos os horários
+// encontrados.
+type SlotFilter struct {
+	DoctorIDs []uint
+	Specialty string
+	From      time.Time
+	To        time.Time
+	Duration  time.Duration
+	Limit     int
+}
+
+// FindSlots calcula os horários livres a partir dos turnos dos médicos,
+// descontando bloqueios e consultas já marcadas. Os horários começam no
+// início de cada turno e avançam de Duration em Duration (ou da duração de
+// consulta do médico).
+func (s *Service) FindSlots(filter SlotFilter) ([]Slot, error) {
+	if filter.Duration < 0 || !filter.To.After(filter.From) {
+		return nil, ErrInvalidInterval
+	}
+	if filter.To.Sub(filter.From) > MaxSlotRange {
+		return nil, ErrRangeTooLarge
+	}
+
+	if now := time.Now(); filter.From.Before(now) {
+		filter.From = now
+	}
+
+	doctorIDs := filter.DoctorIDs
+	if len(doctorIDs) == 0 || filter.Specialty != "" {
+		query := s.db.Model(&models.User{}).Where("role = ? AND deactivated_at IS NULL", enums.Doctor)
+		if len(doctorIDs) > 0 {
+			query = query.Where("id IN ?", doctorIDs)
+		}
+		if filter.Specialty != "" {
+			withSpecialty := s.db.Table("doctor_profiles").
+				Select("1").
+				Joins("JOIN doctor_specialties ON doctor_specialties.doctor_profile_id = doctor_profiles.id").
+				Joins("JOIN specialties ON specialties.id = doctor_specialties.specialty_id").
+				Where("doctor_profiles.user_id = users.id AND LOWER(specialties.name) = LOWER(?)", strings.TrimSpace(filter.Specialty))
+			query = query.Where("EXISTS (?)", withSpecialty)
+		}
+
+		doctorIDs = nil
+		if err := query.Order("id").Pluck("id", &doctorIDs).Error; err != nil {
+			return nil, fmt.Errorf("unable to list doctors: %v", err)
+		}
+	}
+
+	slots := []Slot{}
+	for _, doctorID := range doctorIDs {
+		found, err := s.findDoctorSlots(doctorID, filter)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, found...)
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		if slots[i].Start.Equal(slots[j].Start) {
+			return slots[i].DoctorID < slots[j].DoctorID
+		}
+		return slots[i].Start.Before(slots[j].Start)
+	})
+
+	if filter.Limit > 0 && len(slots) > filter.Limit {
+		slots = slots[:filter.Limit]
+	}
+
+	return slots, nil
+}
+
+func (s *Service) findDoctorSlots(doctorID uint, filter SlotFilter) ([]Slot, error) {
+	var shifts []models.Shift
+	if err := s.db.Where("user_id = ?", doctorID).Find(&shifts).Error; err != nil {
+		return nil, fmt.Errorf("unable to load shifts: %v", err)
+	}
+	if len(shifts) == 0 {
+		return nil, nil
+	}
+
+	duration := filter.Duration
+	if duration == 0 {
+		var err error
+		if duration, err = doctors.ConsultationDuration(s.db, doctorID); err != nil {
+			return nil, err
+		}
+		if duration == 0 {
+			duration = time.Duration(doctors.DefaultConsultationMinutes) * time.Minute
+		}
+	}
+
+	from, to := filter.From.UTC(), filter.To.UTC()
+
+	var blocks []models.AvailabilityBlock
+	if err := s.db.Where("user_id = ? AND starts_at < ? AND ends_at > ?", doctorID, to, from).Find(&blocks).Error; err != nil {
+		return nil, fmt.Errorf("unable to load blocks: %v", err)
+	}
+
+	var appointments []models.Appointment
+	err := s.db.
+		Where("user_id = ? AND date < ? AND end_date > ? AND status NOT IN ?",
+			doctorID, to, from, []enums.AppointmentStatus{enums.Cancelled, enums.NoShow}).
+		Find(&appointments).Error
+	if err != nil {
+		return nil, fmt.Errorf("unable to load appointments: %v", err)
+	}
+
+	busy := make([][2]time.Time, 0, len(blocks)+len(appointments))
+	for _, b := range blocks {
+		busy = append(busy, [2]time.Time{b.StartsAt, b.EndsAt})
+	}
+	for _, a := range appointments {
+		busy = append(busy, [2]time.Time{a.Date, a.EndDate})
+	}
+
+	var slots []Slot
+	first := filter.From.In(s.location)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, s.location)
+	for ; day.Before(filter.To); day = day.AddDate(0, 0, 1) {
+		for _, shift := range shifts {
+			if shift.Weekday != day.Weekday() {
+				continue
+			}
+
+			shiftStart, err := clockOn(day, shift.StartTime)
+			if err != nil {
+				return nil, err
+			}
+			shiftEnd, err := clockOn(day, shift.EndTime)
+			if err != nil {
+				return nil, err
+			}
+
+			for start := shiftStart; !start.Add(duration).After(shiftEnd); start = start.Add(duration) {
+				end := start.Add(duration)
+				if start.Before(filter.From) || end.After(filter.To) || overlapsAny(busy, start, end) {
+					continue
+				}
+				slots = append(slots, Slot{DoctorID: doctorID, Start: start, End: end})
+			}
+		}
+	}
+
+	return slots, nil
+}
+
+// clockOn posiciona um horário "HH:MM" (ou "24:00") no dia informado
+func clockOn(day time.Time, clock string) (time.Time, error) {
+	if clock == "24:00" {
+		return day.AddDate(0, 0, 1), nil
+	}
+
+	t, err := time.Parse(clockLayout, clock)
+	if err != nil {
+		return time.Time{}, ErrInvalidTime
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
+
+func overlapsAny(intervals [][2]time.Time, start, end time.Time) bool {
+	for _, interval := range intervals {
+		if interval[0].Before(end) && interval[1].After(start) {
+			return true
+		}
+	}
+	return false
+}
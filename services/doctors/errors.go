@@ -0,0 +1,11 @@
+package doctors
+
+import "errors"
+
+var (
+	ErrNotDoctor        = errors.New("user is not a doctor")
+	ErrCRMTaken         = errors.New("crm already belongs to another doctor")
+	ErrUnknownSpecialty = errors.New("unknown specialty")
+	ErrSpecialtyExists  = errors.New("specialty already exists")
+	ErrSpecialtyInUse   = errors.New("specialty is assigned to doctors")
+)
@@ -0,0 +1,182 @@
+package doctors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/crm"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"gorm.io/gorm"
+)
+
+// DefaultConsultationMinutes é usada quando o perfil não informa a duração
+const DefaultConsultationMinutes = 30
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// ProfileInput são os dados profissionais do médico. O CRM e a UF são
+// normalizados (só dígitos; UF maiúscula) antes de gravar.
+type ProfileInput struct {
+	CRM                 string
+	UF                  string
+	SpecialtyIDs        []uint
+	ConsultationMinutes int
+}
+
+func (s *Service) GetProfile(userID uint64) (*models.DoctorProfile, error) {
+	var profile models.DoctorProfile
+	if err := s.db.Preload("Specialties").Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// SaveProfile cria ou substitui o perfil profissional de um médico
+func (s *Service) SaveProfile(userID uint64, input ProfileInput) (*models.DoctorProfile, error) {
+	number, err := crm.Parse(input.CRM)
+	if err != nil {
+		return nil, err
+	}
+	uf, err := crm.ParseUF(input.UF)
+	if err != nil {
+		return nil, err
+	}
+	if input.ConsultationMinutes == 0 {
+		input.ConsultationMinutes = DefaultConsultationMinutes
+	}
+
+	var profile models.DoctorProfile
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.Role != enums.Doctor {
+			return ErrNotDoctor
+		}
+
+		var specialties []models.Specialty
+		if err := tx.Where("id IN ?", input.SpecialtyIDs).Find(&specialties).Error; err != nil {
+			return fmt.Errorf("unable to load specialties: %v", err)
+		}
+		if len(specialties) != len(unique(input.SpecialtyIDs)) {
+			return ErrUnknownSpecialty
+		}
+
+		var taken int64
+		err := tx.Model(&models.DoctorProfile{}).
+			Where("crm = ? AND crm_uf = ? AND user_id <> ?", number, uf, user.ID).
+			Count(&taken).Error
+		if err != nil {
+			return fmt.Errorf("unable to check crm: %v", err)
+		}
+		if taken > 0 {
+			return ErrCRMTaken
+		}
+
+		err = tx.Where("user_id = ?", user.ID).First(&profile).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		profile.UserID = user.ID
+		profile.CRM = number
+		profile.CRMUF = uf
+		profile.ConsultationMinutes = input.ConsultationMinutes
+		if err := tx.Omit("Specialties").Save(&profile).Error; err != nil {
+			return fmt.Errorf("unable to save doctor profile: %v", err)
+		}
+
+		return tx.Model(&profile).Association("Specialties").Replace(specialties)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProfile(userID)
+}
+
+func (s *Service) ListSpecialties() ([]models.Specialty, error) {
+	var specialties []models.Specialty
+	if err := s.db.Order("name").Find(&specialties).Error; err != nil {
+		return nil, err
+	}
+
+	return specialties, nil
+}
+
+// CreateSpecialty adiciona uma especialidade ao catálogo. Nomes que só
+// diferem na caixa ou nos espaços contam como a mesma especialidade.
+func (s *Service) CreateSpecialty(name string) (*models.Specialty, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	var existing int64
+	if err := s.db.Model(&models.Specialty{}).Where("LOWER(name) = LOWER(?)", name).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("unable to check specialty: %v", err)
+	}
+	if existing > 0 {
+		return nil, ErrSpecialtyExists
+	}
+
+	specialty := models.Specialty{Name: name}
+	if err := s.db.Create(&specialty).Error; err != nil {
+		return nil, fmt.Errorf("unable to create specialty: %v", err)
+	}
+	return &specialty, nil
+}
+
+// DeleteSpecialty remove uma especialidade que nenhum médico tem
+func (s *Service) DeleteSpecialty(id uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var specialty models.Specialty
+		if err := tx.First(&specialty, id).Error; err != nil {
+			return err
+		}
+
+		var assigned int64
+		if err := tx.Table("doctor_specialties").Where("specialty_id = ?", specialty.ID).Count(&assigned).Error; err != nil {
+			return fmt.Errorf("unable to check specialty: %v", err)
+		}
+		if assigned > 0 {
+			return ErrSpecialtyInUse
+		}
+
+		return tx.Delete(&specialty).Error
+	})
+}
+
+// ConsultationDuration devolve a duração padrão das consultas do médico, ou
+// zero se ele não tiver perfil. Recebe a transação de quem agenda.
+func ConsultationDuration(tx *gorm.DB, doctorID uint) (time.Duration, error) {
+	var profile models.DoctorProfile
+	err := tx.Select("consultation_minutes").Where("user_id = ?", doctorID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to load doctor profile: %v", err)
+	}
+	return profile.ConsultationDuration(), nil
+}
+
+func unique(ids []uint) []uint {
+	seen := map[uint]bool{}
+	var result []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,11 @@
+package doctors
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type DoctorService interface {
+	GetProfile(userID uint64) (*models.DoctorProfile, error)
+	SaveProfile(userID uint64, input ProfileInput) (*models.DoctorProfile, error)
+	ListSpecialties() ([]models.Specialty, error)
+	CreateSpecialty(name string) (*models.Specialty, error)
+	DeleteSpecialty(id uint64) error
+}
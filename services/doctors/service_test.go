@@ -0,0 +1,110 @@
+package doctors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/crm"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.User{}, &models.Specialty{}, &models.DoctorProfile{})
+}
+
+func TestServiceSaveProfile(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+
+	house := models.User{Name: "Dr. House", CPF: "12345678901", Password: "x", Role: enums.Doctor}
+	grey := models.User{Name: "Dr. Grey", CPF: "12345678902", Password: "x", Role: enums.Doctor}
+	alice := models.User{Name: "Alice", CPF: "12345678903", Password: "x", Role: enums.Receptionist}
+	for _, u := range []*models.User{&house, &grey, &alice} {
+		assert.NoError(t, db.Create(u).Error)
+	}
+	cardiology, err := svc.CreateSpecialty("Cardiologia")
+	assert.NoError(t, err)
+	pediatrics, err := svc.CreateSpecialty("Pediatria")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		userID  uint
+		input   ProfileInput
+		wantErr error
+	}{
+		{name: "unknown user", userID: 9999, input: ProfileInput{CRM: "1234", UF: "PA", SpecialtyIDs: []uint{cardiology.ID}}, wantErr: gorm.ErrRecordNotFound},
+		{name: "not a doctor", userID: alice.ID, input: ProfileInput{CRM: "1234", UF: "PA", SpecialtyIDs: []uint{cardiology.ID}}, wantErr: ErrNotDoctor},
+		{name: "invalid crm", userID: house.ID, input: ProfileInput{CRM: "12A4", UF: "PA", SpecialtyIDs: []uint{cardiology.ID}}, wantErr: crm.ErrInvalid},
+		{name: "invalid uf", userID: house.ID, input: ProfileInput{CRM: "1234", UF: "XX", SpecialtyIDs: []uint{cardiology.ID}}, wantErr: crm.ErrInvalidUF},
+		{name: "unknown specialty", userID: house.ID, input: ProfileInput{CRM: "1234", UF: "PA", SpecialtyIDs: []uint{cardiology.ID, 9999}}, wantErr: ErrUnknownSpecialty},
+		{name: "created", userID: house.ID, input: ProfileInput{CRM: "001.234", UF: "pa", SpecialtyIDs: []uint{cardiology.ID, cardiology.ID}}},
+		{name: "crm of another doctor", userID: grey.ID, input: ProfileInput{CRM: "1234", UF: "PA", SpecialtyIDs: []uint{pediatrics.ID}}, wantErr: ErrCRMTaken},
+		{name: "same number in another UF", userID: grey.ID, input: ProfileInput{CRM: "1234", UF: "SP", SpecialtyIDs: []uint{pediatrics.ID}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SaveProfile(uint64(tt.userID), tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	profile, err := svc.GetProfile(uint64(house.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, "CRM/PA 1234", profile.Registration())
+	assert.Equal(t, DefaultConsultationMinutes, profile.ConsultationMinutes)
+	assert.Len(t, profile.Specialties, 1)
+
+	// salvar de novo substitui o perfil e as especialidades
+	profile, err = svc.SaveProfile(uint64(house.ID), ProfileInput{CRM: "1234", UF: "PA", SpecialtyIDs: []uint{pediatrics.ID}, ConsultationMinutes: 45})
+	assert.NoError(t, err)
+	assert.Equal(t, 45, profile.ConsultationMinutes)
+	assert.Len(t, profile.Specialties, 1)
+	assert.Equal(t, pediatrics.ID, profile.Specialties[0].ID)
+	var count int64
+	db.Model(&models.DoctorProfile{}).Where("user_id = ?", house.ID).Count(&count)
+	assert.EqualValues(t, 1, count)
+
+	duration, err := ConsultationDuration(db, house.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, duration)
+	duration, err = ConsultationDuration(db, alice.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, duration)
+}
+
+func TestServiceSpecialties(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+
+	cardiology, err := svc.CreateSpecialty("  Cardiologia ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Cardiologia", cardiology.Name)
+	_, err = svc.CreateSpecialty("CARDIOLOGIA")
+	assert.ErrorIs(t, err, ErrSpecialtyExists)
+	dermatology, err := svc.CreateSpecialty("Dermatologia")
+	assert.NoError(t, err)
+
+	specialties, err := svc.ListSpecialties()
+	assert.NoError(t, err)
+	assert.Len(t, specialties, 2)
+	assert.Equal(t, "Cardiologia", specialties[0].Name)
+
+	doctor := models.User{Name: "Dr. House", CPF: "12345678901", Password: "x", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	_, err = svc.SaveProfile(uint64(doctor.ID), ProfileInput{CRM: "1234", UF: "PA", SpecialtyIDs: []uint{cardiology.ID}})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, svc.DeleteSpecialty(uint64(cardiology.ID)), ErrSpecialtyInUse)
+	assert.NoError(t, svc.DeleteSpecialty(uint64(dermatology.ID)))
+	assert.ErrorIs(t, svc.DeleteSpecialty(uint64(dermatology.ID)), gorm.ErrRecordNotFound)
+}
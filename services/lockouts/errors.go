@@ -0,0 +1,24 @@
+package lockouts
+
+import (
+	"fmt"
+	"time"
+)
+
+// LockedError indica que o CPF ou o IP está bloqueado por excesso de falhas
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts; try again after %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+// RetryAfter devolve quanto falta para o bloqueio acabar, arredondado para cima em segundos
+func (e *LockedError) RetryAfter(now time.Time) time.Duration {
+	wait := e.Until.Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait.Truncate(time.Second) + time.Second
+}
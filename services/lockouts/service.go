@@ -0,0 +1,154 @@
+package lockouts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ipAttemptFactor multiplica o limite de falhas para IPs: vários usuários
+// legítimos podem sair pelo mesmo IP (ex.: a rede do hospital).
+const ipAttemptFactor = 4
+
+type Service struct {
+	db     *gorm.DB
+	config config.Login
+	now    func() time.Time
+}
+
+func NewService(db *gorm.DB, cfg config.Login) *Service {
+	return &Service{db: db, config: cfg, now: time.Now}
+}
+
+// Check recusa a tentativa se o CPF ou o IP estiver bloqueado. O bloqueio
+// vale mesmo para CPFs sem cadastro, para não revelar quais existem.
+func (s *Service) Check(document cpf.CPF, ip string) error {
+	now := s.now().UTC()
+
+	var attempts []models.LoginAttempt
+	err := s.db.
+		Where("(kind = ? AND identifier = ?) OR (kind = ? AND identifier = ?)", enums.AttemptCPF, document.Digits(), enums.AttemptIP, ip).
+		Where("locked_until > ?", now).
+		Order("locked_until DESC").
+		Find(&attempts).Error
+	if err != nil {
+		return fmt.Errorf("unable to check login attempts: %v", err)
+	}
+
+	if len(attempts) > 0 {
+		return &LockedError{Until: *attempts[0].LockedUntil}
+	}
+	return nil
+}
+
+// RegisterFailure conta a falha para o CPF e para o IP
+func (s *Service) RegisterFailure(document cpf.CPF, ip string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.fail(tx, enums.AttemptCPF, document.Digits(), s.config.MaxAttempts); err != nil {
+			return err
+		}
+		if ip == "" {
+			return nil
+		}
+		return s.fail(tx, enums.AttemptIP, ip, s.config.MaxAttempts*ipAttemptFactor)
+	})
+}
+
+// RegisterSuccess zera o contador do CPF. O do IP continua, senão uma conta
+// própria serviria para liberar tentativas contra as demais.
+func (s *Service) RegisterSuccess(document cpf.CPF) error {
+	err := s.db.
+		Where("kind = ? AND identifier = ?", enums.AttemptCPF, document.Digits()).
+		Delete(&models.LoginAttempt{}).Error
+	if err != nil {
+		return fmt.Errorf("unable to reset login attempts: %v", err)
+	}
+	return nil
+}
+
+// GetAll lista os contadores de falha, opcionalmente só os bloqueados agora
+func (s *Service) GetAll(lockedOnly bool) ([]models.LoginAttempt, error) {
+	query := s.db.Order("last_failure_at DESC")
+	if lockedOnly {
+		query = query.Where("locked_until > ?", s.now().UTC())
+	}
+
+	var attempts []models.LoginAttempt
+	if err := query.Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// Clear remove o contador, liberando o CPF ou o IP na hora
+func (s *Service) Clear(id uint64) error {
+	result := s.db.Delete(&models.LoginAttempt{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("unable to clear lockout: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// fail incrementa o contador do CPF ou IP. Atingido o limite, bloqueia por
+// Lockout, dobrando a cada falha extra até LockoutMax. Falhas mais antigas
+// que LockoutMax deixam de contar. O incremento é um upsert no próprio banco,
+// para que falhas simultâneas não se percam lendo o mesmo valor.
+func (s *Service) fail(tx *gorm.DB, kind enums.AttemptKind, identifier string, limit int) error {
+	now := s.now().UTC()
+
+	// o contador volta a 1 se não está bloqueado e a última falha já expirou;
+	// as colunas levam o nome da tabela porque o Postgres também enxerga
+	// as de "excluded" no DO UPDATE
+	expired := gorm.Expr("(login_attempts.locked_until IS NULL OR login_attempts.locked_until <= ?) AND login_attempts.last_failure_at < ?",
+		now, now.Add(-s.config.LockoutMax))
+	attempt := models.LoginAttempt{Kind: kind, Identifier: identifier, Failures: 1, LastFailureAt: now}
+	err := tx.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "kind"}, {Name: "identifier"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"failures":        gorm.Expr("CASE WHEN ? THEN 1 ELSE login_attempts.failures + 1 END", expired),
+				"locked_until":    gorm.Expr("CASE WHEN ? THEN NULL ELSE login_attempts.locked_until END", expired),
+				"last_failure_at": now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&attempt).Error
+	if err != nil {
+		return fmt.Errorf("unable to register login failure: %v", err)
+	}
+
+	if attempt.Failures < limit {
+		return nil
+	}
+
+	// só grava o bloqueio se nenhuma falha mais nova já tiver passado por aqui
+	until := now.Add(s.lockoutFor(attempt.Failures - limit))
+	err = tx.Model(&models.LoginAttempt{}).
+		Where("id = ? AND failures = ?", attempt.ID, attempt.Failures).
+		Update("locked_until", until).Error
+	if err != nil {
+		return fmt.Errorf("unable to lock login attempts: %v", err)
+	}
+	return nil
+}
+
+// lockoutFor devolve Lockout * 2^extra, limitado a LockoutMax
+func (s *Service) lockoutFor(extra int) time.Duration {
+	lockout := s.config.Lockout
+	for range extra {
+		lockout *= 2
+		if lockout >= s.config.LockoutMax {
+			return s.config.LockoutMax
+		}
+	}
+	return min(lockout, s.config.LockoutMax)
+}
@@ -0,0 +1,14 @@
+package lockouts
+
+import (
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/models"
+)
+
+type LockoutService interface {
+	Check(document cpf.CPF, ip string) error
+	RegisterFailure(document cpf.CPF, ip string) error
+	RegisterSuccess(document cpf.CPF) error
+	GetAll(lockedOnly bool) ([]models.LoginAttempt, error)
+	Clear(id uint64) error
+}
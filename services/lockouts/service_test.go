@@ -0,0 +1,131 @@
+package lockouts
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupService abre um banco isolado e um relógio controlado pelo teste
+func setupService(t *testing.T) (*Service, *time.Time) {
+	db := dbtest.Open(t, &models.LoginAttempt{})
+
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewService(db, config.Default().Login)
+	svc.now = func() time.Time { return now }
+	return svc, &now
+}
+
+func TestLockoutBackoff(t *testing.T) {
+	svc, now := setupService(t)
+	document := cpf.CPF("529.982.247-25")
+
+	for range svc.config.MaxAttempts - 1 {
+		assert.NoError(t, svc.RegisterFailure(document, "10.0.0.1"))
+	}
+	assert.NoError(t, svc.Check(document, "10.0.0.1"))
+
+	// a falha que atinge o limite bloqueia por LOGIN_LOCKOUT
+	assert.NoError(t, svc.RegisterFailure(document, "10.0.0.1"))
+	var locked *LockedError
+	assert.ErrorAs(t, svc.Check(document, "10.0.0.2"), &locked)
+	assert.Equal(t, now.Add(svc.config.Lockout), locked.Until)
+
+	// mesmo CPF sem máscara cai no mesmo contador
+	assert.Error(t, svc.Check("52998224725", ""))
+
+	// passado o bloqueio, a próxima falha dobra a espera
+	*now = now.Add(svc.config.Lockout)
+	assert.NoError(t, svc.Check(document, "10.0.0.1"))
+	assert.NoError(t, svc.RegisterFailure(document, "10.0.0.1"))
+	assert.ErrorAs(t, svc.Check(document, ""), &locked)
+	assert.Equal(t, now.Add(2*svc.config.Lockout), locked.Until)
+
+	// sucesso zera o CPF
+	*now = now.Add(2 * svc.config.Lockout)
+	assert.NoError(t, svc.RegisterSuccess(document))
+	attempts, err := svc.GetAll(false)
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 1)
+	assert.Equal(t, enums.AttemptIP, attempts[0].Kind)
+}
+
+func TestLockoutByIP(t *testing.T) {
+	svc, _ := setupService(t)
+
+	// CPFs diferentes a partir do mesmo IP
+	for i := range svc.config.MaxAttempts * ipAttemptFactor {
+		document := cpf.CPF(fmt.Sprintf("%011d", i))
+		assert.NoError(t, svc.RegisterFailure(document, "10.0.0.9"))
+	}
+
+	var locked *LockedError
+	assert.ErrorAs(t, svc.Check("52998224725", "10.0.0.9"), &locked)
+	assert.NoError(t, svc.Check("52998224725", "10.0.0.10"))
+}
+
+func TestLockoutCapAndDecay(t *testing.T) {
+	svc, now := setupService(t)
+	document := cpf.CPF("52998224725")
+
+	for range svc.config.MaxAttempts + 20 {
+		assert.NoError(t, svc.RegisterFailure(document, ""))
+	}
+	var locked *LockedError
+	assert.ErrorAs(t, svc.Check(document, ""), &locked)
+	assert.Equal(t, now.Add(svc.config.LockoutMax), locked.Until)
+
+	// falhas antigas deixam de contar
+	*now = now.Add(2 * svc.config.LockoutMax)
+	assert.NoError(t, svc.RegisterFailure(document, ""))
+	assert.NoError(t, svc.Check(document, ""))
+}
+
+// TestLockoutConcurrentFailures confere que falhas simultâneas não se perdem:
+// cada uma soma no contador, como quem tenta várias senhas em paralelo
+func TestLockoutConcurrentFailures(t *testing.T) {
+	svc, _ := setupService(t)
+	document := cpf.CPF("52998224725")
+	failures := svc.config.MaxAttempts * 2
+
+	var wg sync.WaitGroup
+	for i := range failures {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, svc.RegisterFailure(document, fmt.Sprintf("10.0.1.%d", i)))
+		}()
+	}
+	wg.Wait()
+
+	var attempt models.LoginAttempt
+	assert.NoError(t, svc.db.Where("kind = ? AND identifier = ?", enums.AttemptCPF, document.Digits()).First(&attempt).Error)
+	assert.Equal(t, failures, attempt.Failures)
+	assert.True(t, attempt.IsLocked(svc.now()))
+}
+
+func TestClearLockout(t *testing.T) {
+	svc, _ := setupService(t)
+	document := cpf.CPF("52998224725")
+
+	for range svc.config.MaxAttempts {
+		assert.NoError(t, svc.RegisterFailure(document, ""))
+	}
+
+	locked, err := svc.GetAll(true)
+	assert.NoError(t, err)
+	assert.Len(t, locked, 1)
+
+	assert.NoError(t, svc.Clear(uint64(locked[0].ID)))
+	assert.NoError(t, svc.Check(document, ""))
+	assert.ErrorIs(t, svc.Clear(uint64(locked[0].ID)), gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,256 @@
+package pacients
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"gorm.io/gorm"
+)
+
+// transitions define a máquina de estados da consulta: cada status lista os
+// status para os quais pode avançar. Concluída, cancelada e falta são finais.
+var transitions = map[enums.AppointmentStatus][]enums.AppointmentStatus{
+	enums.Scheduled:  {enums.Confirmed, enums.Cancelled, enums.NoShow},
+	enums.Confirmed:  {enums.CheckedIn, enums.Cancelled, enums.NoShow},
+	enums.CheckedIn:  {enums.InProgress, enums.Cancelled},
+	enums.InProgress: {enums.Completed},
+}
+
+// inactiveStatuses são os status que liberam o horário da consulta
+var inactiveStatuses = []enums.AppointmentStatus{enums.Cancelled, enums.NoShow}
+
+// CanTransition informa se a consulta pode passar de from para to
+func CanTransition(from, to enums.AppointmentStatus) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+func (s *Service) UpdateAppointmentStatus(id uint64, status enums.AppointmentStatus, actorID uint) (*models.Appointment, error) {
+	if status == enums.Cancelled {
+		return nil, ErrCancelRequiresReason
+	}
+
+	return s.changeAppointment(id, actorID, func(tx *gorm.DB, appointment *models.Appointment, event *models.AppointmentEvent) error {
+		if !CanTransition(appointment.Status, status) {
+			return &InvalidTransitionError{From: appointment.Status, To: status}
+		}
+
+		appointment.Status = status
+		return nil
+	})
+}
+
+func (s *Service) CancelAppointment(id uint64, reason string, actorID uint) (*models.Appointment, error) {
+	if reason == "" {
+		return nil, ErrCancelRequiresReason
+	}
+
+	return s.changeAppointment(id, actorID, func(tx *gorm.DB, appointment *models.Appointment, event *models.AppointmentEvent) error {
+		if !CanTransition(appointment.Status, enums.Cancelled) {
+			return &InvalidTransitionError{From: appointment.Status, To: enums.Cancelled}
+		}
+
+		appointment.Status = enums.Cancelled
+		appointment.CancelReason = &reason
+		event.Reason = &reason
+		return nil
+	})
+}
+
+// RescheduleAppointment move a consulta para outro horário mantendo a duração.
+// A consulta volta para "scheduled", pois a confirmação valia para o horário
+// anterior, e o horário antigo fica registrado no histórico.
+func (s *Service) RescheduleAppointment(id uint64, date time.Time, actorID uint) (*models.Appointment, error) {
+	return s.changeAppointment(id, actorID, func(tx *gorm.DB, appointment *models.Appointment, event *models.AppointmentEvent) error {
+		if appointment.Status != enums.Scheduled && appointment.Status != enums.Confirmed {
+			return &InvalidTransitionError{From: appointment.Status, To: enums.Scheduled}
+		}
+		if !date.After(time.Now()) {
+			return ErrAppointmentInPast
+		}
+
+		fromDate := appointment.Date
+		duration := appointment.EndDate.Sub(appointment.Date)
+
+		appointment.Date = date.UTC()
+		appointment.EndDate = appointment.Date.Add(duration)
+		appointment.Status = enums.Scheduled
+
+		if err := s.reserve(tx, appointment); err != nil {
+			return err
+		}
+
+		event.FromDate = &fromDate
+		event.ToDate = &appointment.Date
+		return nil
+	})
+}
+
+// changeAppointment carrega a consulta, aplica a mudança e grava a consulta e
+// o evento de histórico na mesma transação.
+func (s *Service) changeAppointment(id uint64, actorID uint, change func(tx *gorm.DB, appointment *models.Appointment, event *models.AppointmentEvent) error) (*models.Appointment, error) {
+	var appointment models.Appointment
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&appointment, id).Error; err != nil {
+			return err
+		}
+
+		event := models.AppointmentEvent{
+			AppointmentID: appointment.ID,
+			FromStatus:    appointment.Status,
+		}
+		if actorID != 0 {
+			event.ActorID = &actorID
+		}
+
+		if err := change(tx, &appointment, &event); err != nil {
+			return err
+		}
+		event.ToStatus = appointment.Status
+
+		if err := tx.Select("date", "end_date", "status", "cancel_reason").Save(&appointment).Error; err != nil {
+			return fmt.Errorf("unable to update appointment: %v", err)
+		}
+
+		return tx.Create(&event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("History").First(&appointment, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// appointmentSorts mapeia as ordenações aceitas para colunas do banco
+var appointmentSorts = map[string]string{
+	"date":      "date",
+	"createdAt": "created_at",
+	"status":    "status",
+}
+
+// AppointmentFilter descreve a listagem de consultas. Campos zerados não
+// filtram; Sort aceita "date", "createdAt" ou "status", com "-" para ordem
+// decrescente. VisibleTo, se preenchido, restringe às consultas dos pacientes
+// que esse médico pode ver.
+type AppointmentFilter struct {
+	DoctorID  uint
+	PacientID uint
+	VisibleTo uint
+	From      time.Time
+	To        time.Time
+	Statuses  []enums.AppointmentStatus
+	Page      int
+	Limit     int
+	Sort      string
+}
+
+func (s *Service) GetAppointment(id uint64) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := s.db.
+		Preload("Pacient").
+		Preload("User").
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&appointment, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+func (s *Service) ListAppointments(filter AppointmentFilter) ([]models.Appointment, int64, error) {
+	query := s.db.Model(&models.Appointment{})
+
+	if filter.DoctorID != 0 {
+		query = query.Where("user_id = ?", filter.DoctorID)
+	}
+	if filter.PacientID != 0 {
+		query = query.Where("pacient_id = ?", filter.PacientID)
+	}
+	if filter.VisibleTo != 0 {
+		query = query.Where("pacient_id IN (?)", s.visibleTo(s.db.Model(&models.Pacient{}).Select("pacients.id"), filter.VisibleTo))
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("date >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("date < ?", filter.To.UTC())
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("unable to count appointments: %v", err)
+	}
+
+	order, err := sortOrder(filter.Sort, appointmentSorts, "date, id")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, limit := normalizePage(filter.Page, filter.Limit)
+
+	var appointments []models.Appointment
+	err = query.
+		Preload("Pacient").
+		Preload("User").
+		Order(order).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&appointments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return appointments, total, nil
+}
+
+// sortOrder traduz "campo" ou "-campo" para a cláusula ORDER BY usando as
+// colunas permitidas; o id entra como desempate para a paginação ser estável.
+func sortOrder(sort string, columns map[string]string, fallback string) (string, error) {
+	if sort == "" {
+		return fallback, nil
+	}
+
+	direction := ""
+	if strings.HasPrefix(sort, "-") {
+		sort, direction = sort[1:], " DESC"
+	}
+
+	column, ok := columns[sort]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSort, sort)
+	}
+
+	return column + direction + ", id" + direction, nil
+}
+
+// normalizePage aplica os padrões de paginação: página 1 e DefaultPageSize
+// itens, limitados a MaxPageSize.
+func normalizePage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	return page, limit
+}
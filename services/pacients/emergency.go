@@ -0,0 +1,130 @@
+package pacients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/models"
+	"gorm.io/gorm"
+)
+
+// PacientAccess informa se o médico pode ver o paciente: por ter consulta
+// com ele (passada ou futura) ou por um acesso de emergência ainda válido,
+// caso em que emergency é true. Sem nenhum dos dois, ErrNoPacientAccess.
+func (s *Service) PacientAccess(doctorID uint, pacientID uint64) (bool, error) {
+	var appointments int64
+	err := s.db.Model(&models.Appointment{}).
+		Where("pacient_id = ? AND user_id = ?", pacientID, doctorID).
+		Count(&appointments).Error
+	if err != nil {
+		return false, fmt.Errorf("unable to check appointments: %v", err)
+	}
+	if appointments > 0 {
+		return false, nil
+	}
+
+	var grants int64
+	err = s.db.Model(&models.EmergencyAccess{}).
+		Where("pacient_id = ? AND user_id = ? AND expires_at > ?", pacientID, doctorID, time.Now().UTC()).
+		Count(&grants).Error
+	if err != nil {
+		return false, fmt.Errorf("unable to check emergency access: %v", err)
+	}
+	if grants > 0 {
+		return true, nil
+	}
+
+	return false, ErrNoPacientAccess
+}
+
+// GrantEmergencyAccess libera o paciente para o médico por
+// EMERGENCY_ACCESS_TTL, registra a justificativa e avisa os admins.
+func (s *Service) GrantEmergencyAccess(doctorID uint, pacientID uint64, justification string) (*models.EmergencyAccess, error) {
+	var pacient models.Pacient
+	if err := s.db.First(&pacient, pacientID).Error; err != nil {
+		return nil, err
+	}
+
+	access := models.EmergencyAccess{
+		UserID:        doctorID,
+		PacientID:     pacient.ID,
+		Justification: justification,
+		ExpiresAt:     time.Now().UTC().Add(s.emergencyTTL),
+	}
+	if err := s.db.Create(&access).Error; err != nil {
+		return nil, fmt.Errorf("unable to grant emergency access: %v", err)
+	}
+
+	if s.notify != nil {
+		s.notify(access)
+	}
+	return &access, nil
+}
+
+// ListEmergencyAccesses lista as quebras de vidro, da mais recente para a
+// mais antiga; activeOnly mostra só as que ainda valem.
+func (s *Service) ListEmergencyAccesses(activeOnly bool) ([]models.EmergencyAccess, error) {
+	query := s.db.Order("created_at DESC")
+	if activeOnly {
+		query = query.Where("expires_at > ?", time.Now().UTC())
+	}
+
+	var accesses []models.EmergencyAccess
+	if err := query.Find(&accesses).Error; err != nil {
+		return nil, err
+	}
+	return accesses, nil
+}
+
+// visibleTo restringe a consulta de pacientes aos que o médico pode ver
+func (s *Service) visibleTo(query *gorm.DB, doctorID uint) *gorm.DB {
+	appointment := s.db.Model(&models.Appointment{}).
+		Select("1").
+		Where("appointments.pacient_id = pacients.id AND appointments.user_id = ?", doctorID)
+	grant := s.db.Model(&models.EmergencyAccess{}).
+		Select("1").
+		Where("emergency_accesses.pacient_id = pacients.id AND emergency_accesses.user_id = ? AND emergency_accesses.expires_at > ?",
+			doctorID, time.Now().UTC())
+
+	return query.Where("EXISTS (?) OR EXISTS (?)", appointment, grant)
+}
+
+// adminNotifier registra cada quebra de vidro no log e, se houver webhook
+// (EMERGENCY_WEBHOOK_URL), envia o aviso para ele sem segurar a requisição.
+func adminNotifier(webhookURL string) func(models.EmergencyAccess) {
+	return func(access models.EmergencyAccess) {
+		notifyAdmins(webhookURL, access)
+	}
+}
+
+func notifyAdmins(webhookURL string, access models.EmergencyAccess) {
+	log.Printf("ALERTA: acesso de emergência do usuário %d ao paciente %d até %s: %s",
+		access.UserID, access.PacientID, access.ExpiresAt.Format(time.RFC3339), access.Justification)
+
+	if webhookURL == "" {
+		return
+	}
+
+	go func() {
+		body, err := json.Marshal(map[string]any{"event": "emergency_access", "access": access})
+		if err != nil {
+			log.Printf("emergency webhook: %v", err)
+			return
+		}
+
+		client := http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("emergency webhook: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			log.Printf("emergency webhook: unexpected status %s", resp.Status)
+		}
+	}()
+}
@@ -0,0 +1,70 @@
+package pacients
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+)
+
+var (
+	ErrCancelRequiresReason = errors.New("cancelling an appointment requires a reason")
+	ErrInvalidSort          = errors.New("invalid sort field")
+	ErrAppointmentInPast    = errors.New("appointment date must be in the future")
+	ErrPacientInactive      = errors.New("pacient is inactive")
+	ErrPacientActive        = errors.New("pacient is already active")
+	ErrNoPacientAccess      = errors.New("doctor has no appointment or emergency access for this pacient")
+)
+
+// DuplicateCPFError indica que já existe um paciente com o CPF informado,
+// inclusive inativo; nesse caso o cadastro deve ser reativado, não recriado.
+type DuplicateCPFError struct {
+	Pacient models.Pacient
+}
+
+func (e *DuplicateCPFError) Error() string {
+	if e.Pacient.Status == enums.PacientInactive {
+		return fmt.Sprintf("cpf already belongs to inactive pacient %d; reactivate it instead", e.Pacient.ID)
+	}
+	return fmt.Sprintf("cpf already belongs to pacient %d", e.Pacient.ID)
+}
+
+// InvalidTransitionError indica uma mudança de status não permitida pela
+// máquina de estados da consulta.
+type InvalidTransitionError struct {
+	From enums.AppointmentStatus
+	To   enums.AppointmentStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("appointment cannot go from %s to %s", e.From, e.To)
+}
+
+// AppointmentConflictError indica que o horário pedido sobrepõe uma consulta
+// já existente do médico ou do paciente. Location é o fuso em que a consulta
+// é mostrada (UTC se nil).
+type AppointmentConflictError struct {
+	Appointment models.Appointment
+	DoctorBusy  bool
+	Location    *time.Location
+}
+
+func (e *AppointmentConflictError) Error() string {
+	who := "pacient"
+	if e.DoctorBusy {
+		who = "doctor"
+	}
+	loc := e.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return fmt.Sprintf("%s already has appointment %d from %s to %s",
+		who,
+		e.Appointment.ID,
+		e.Appointment.Date.In(loc).Format("2006-01-02 15:04"),
+		e.Appointment.EndDate.In(loc).Format("2006-01-02 15:04"),
+	)
+}
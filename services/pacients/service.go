@@ -1,23 +1,51 @@
 package pacients
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database"
+	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/availability"
+	"github.com/andresidrim/cesupa-hospital/services/doctors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DefaultAppointmentDuration é usada quando a consulta não informa horário de
+// término e o médico não tem duração padrão no perfil
+const DefaultAppointmentDuration = doctors.DefaultConsultationMinutes * time.Minute
+
 type Service struct {
-	db *gorm.DB
+	db           *gorm.DB
+	location     *time.Location
+	emergencyTTL time.Duration
+
+	// notify avisa os admins de cada acesso de emergência
+	notify func(models.EmergencyAccess)
 }
 
-func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+// NewService recebe o fuso dos turnos dos médicos e a configuração da quebra
+// de vidro
+func NewService(db *gorm.DB, location *time.Location, emergency config.Emergency) *Service {
+	return &Service{
+		db:           db,
+		location:     location,
+		emergencyTTL: emergency.AccessTTL,
+		notify:       adminNotifier(emergency.WebhookURL),
+	}
 }
 
 func (s *Service) Create(pacient *models.Pacient) error {
+	if err := s.ensureUniqueCPF(pacient, 0); err != nil {
+		return err
+	}
+
+	pacient.Status = enums.PacientActive
 	return s.db.Create(pacient).Error
 }
 
@@ -30,48 +58,262 @@ func (s *Service) Get(id uint64) (*models.Pacient, error) {
 	return &pacient, nil
 }
 
-func (s *Service) GetAll(name string, ageStr string) ([]models.Pacient, error) {
-	var pacients []models.Pacient
-	query := s.db.Model(&models.Pacient{}).Preload("Appointments")
+// pacientSorts mapeia as ordenações aceitas para colunas do banco
+var pacientSorts = map[string]string{
+	"name":      "name",
+	"birthDate": "birth_date",
+	"createdAt": "created_at",
+}
 
-	if name != "" {
-		query = query.Where("name LIKE ?", "%"+name+"%")
-	}
+// PacientFilter descreve a listagem de pacientes. Campos zerados não
+// filtram; as idades são ponteiros porque 0 é uma idade válida. Sort aceita
+// "name", "birthDate" ou "createdAt", com "-" para ordem decrescente.
+// VisibleTo, se preenchido, restringe aos pacientes que esse médico pode ver
+// (com consulta ou acesso de emergência válido).
+type PacientFilter struct {
+	Name            string
+	CPF             cpf.CPF
+	Phone           string
+	Sex             enums.Sex
+	BloodType       enums.BloodType
+	Age             *int
+	MinAge          *int
+	MaxAge          *int
+	DoctorID        uint
+	IncludeInactive bool
+	VisibleTo       uint
+	Page            int
+	Limit           int
+	Sort            string
+}
 
-	if ageStr != "" {
-		age, err := strconv.Atoi(ageStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid age: %v", err)
-		}
+// GetAll lista pacientes paginados, sem as consultas (use Get para o
+// cadastro completo), e devolve também o total de registros do filtro.
+func (s *Service) GetAll(filter PacientFilter) ([]models.Pacient, int64, error) {
+	query := s.db.Model(&models.Pacient{})
 
-		from, to := calculateAgeRange(age)
+	if !filter.IncludeInactive {
+		query = query.Where("status = ?", enums.PacientActive)
+	}
+	if filter.Name != "" {
+		query = database.Contains(query, "name", filter.Name)
+	}
+	if filter.CPF != "" {
+		query = query.Where("cpf = ?", filter.CPF)
+	}
+	if filter.Phone != "" {
+		query = database.Contains(query, "phone_number", filter.Phone)
+	}
+	if filter.Sex != "" {
+		query = query.Where("sex = ?", filter.Sex)
+	}
+	if filter.BloodType != "" {
+		query = query.Where("blood_type = ?", filter.BloodType)
+	}
+	if filter.Age != nil {
+		from, to := calculateAgeRange(*filter.Age)
 		query = query.Where("birth_date BETWEEN ? AND ?", from, to)
 	}
+	if filter.MinAge != nil {
+		_, to := calculateAgeRange(*filter.MinAge)
+		query = query.Where("birth_date <= ?", to)
+	}
+	if filter.MaxAge != nil {
+		from, _ := calculateAgeRange(*filter.MaxAge)
+		query = query.Where("birth_date >= ?", from)
+	}
+	if filter.VisibleTo != 0 {
+		query = s.visibleTo(query, filter.VisibleTo)
+	}
+	if filter.DoctorID != 0 {
+		query = query.Where("EXISTS (?)", s.db.Model(&models.Appointment{}).
+			Select("1").
+			Where("appointments.pacient_id = pacients.id AND appointments.user_id = ?", filter.DoctorID))
+	}
 
-	if err := query.Find(&pacients).Error; err != nil {
-		return nil, err
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("unable to count pacients: %v", err)
+	}
+
+	order, err := sortOrder(filter.Sort, pacientSorts, "id")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, limit := normalizePage(filter.Page, filter.Limit)
+
+	var pacients []models.Pacient
+	err = query.
+		Order(order).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&pacients).Error
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return pacients, nil
+	return pacients, total, nil
 }
 
 func (s *Service) Update(id uint64, pacient *models.Pacient) error {
+	if err := s.ensureUniqueCPF(pacient, uint(id)); err != nil {
+		return err
+	}
+
 	return s.db.Model(&models.Pacient{}).Where("id = ?", id).Updates(pacient).Error
 }
 
-func (s *Service) Delete(id uint64) error {
-	result := s.db.Delete(&models.Pacient{}, "id = ?", id)
-	if result.Error != nil {
-		return fmt.Errorf("unable to delete pacient: %v", result.Error)
+// Inactivate marca o paciente como inativo, registrando motivo e data. O
+// cadastro continua no banco (e visível em Get) para preservar o histórico
+// e manter o CPF reservado.
+func (s *Service) Inactivate(id uint64, reason string) (*models.Pacient, error) {
+	var pacient models.Pacient
+	if err := s.db.First(&pacient, id).Error; err != nil {
+		return nil, err
+	}
+	if pacient.Status == enums.PacientInactive {
+		return nil, ErrPacientInactive
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+
+	now := time.Now().UTC()
+	updates := map[string]any{
+		"status":              enums.PacientInactive,
+		"inactivated_at":      now,
+		"inactivation_reason": nil,
 	}
+	if reason != "" {
+		updates["inactivation_reason"] = reason
+	}
+
+	if err := s.db.Model(&pacient).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("unable to inactivate pacient: %v", err)
+	}
+
+	return &pacient, nil
+}
+
+// Reactivate volta o paciente para ativo. Também recupera cadastros que foram
+// removidos pelo soft delete antigo (deleted_at preenchido).
+func (s *Service) Reactivate(id uint64) (*models.Pacient, error) {
+	var pacient models.Pacient
+	if err := s.db.Unscoped().First(&pacient, id).Error; err != nil {
+		return nil, err
+	}
+	if pacient.Status != enums.PacientInactive && !pacient.DeletedAt.Valid {
+		return nil, ErrPacientActive
+	}
+
+	err := s.db.Unscoped().Model(&pacient).Updates(map[string]any{
+		"status":              enums.PacientActive,
+		"inactivated_at":      nil,
+		"inactivation_reason": nil,
+		"deleted_at":          nil,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("unable to reactivate pacient: %v", err)
+	}
+
+	return &pacient, nil
+}
+
+// ensureUniqueCPF procura o CPF em qualquer cadastro, ativo, inativo ou
+// removido, para devolver um erro claro em vez da violação do índice unique.
+func (s *Service) ensureUniqueCPF(pacient *models.Pacient, ignoreID uint) error {
+	if pacient.CPF == "" {
+		return nil
+	}
+
+	var existing models.Pacient
+	err := s.db.Unscoped().Where("cpf = ? AND id <> ?", pacient.CPF, ignoreID).First(&existing).Error
+	switch {
+	case err == nil:
+		if existing.DeletedAt.Valid {
+			existing.Status = enums.PacientInactive
+		}
+		return &DuplicateCPFError{Pacient: existing}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("unable to check cpf: %v", err)
+	}
+
 	return nil
 }
 
 func (s *Service) ScheduleAppointment(appointment *models.Appointment) error {
-	return s.db.Create(appointment).Error
+	if !appointment.Date.After(time.Now()) {
+		return ErrAppointmentInPast
+	}
+	appointment.Status = enums.Scheduled
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if appointment.EndDate.IsZero() {
+			duration, err := doctors.ConsultationDuration(tx, appointment.UserID)
+			if err != nil {
+				return err
+			}
+			if duration == 0 {
+				duration = DefaultAppointmentDuration
+			}
+			appointment.EndDate = appointment.Date.Add(duration)
+		}
+		appointment.Date, appointment.EndDate = appointment.Date.UTC(), appointment.EndDate.UTC()
+
+		if err := s.reserve(tx, appointment); err != nil {
+			return err
+		}
+
+		return tx.Create(appointment).Error
+	})
+}
+
+// reserve confere se o médico atende no horário da consulta e se nem ele nem
+// o paciente têm outra consulta ativa sobreposta. A própria consulta é
+// ignorada, o que permite reaproveitar a checagem no reagendamento.
+//
+// As linhas do médico e do paciente ficam travadas (FOR UPDATE) até o fim da
+// transação, sempre nessa ordem, para que dois agendamentos simultâneos com um
+// deles não passem os dois pela checagem. O SQLite ignora o FOR UPDATE, mas lá
+// a escrita já é serializada pelo banco.
+func (s *Service) reserve(tx *gorm.DB, appointment *models.Appointment) error {
+	lock := clause.Locking{Strength: "UPDATE"}
+
+	err := tx.Clauses(lock).Select("id").Where("id = ?", appointment.UserID).Find(&models.User{}).Error
+	if err != nil {
+		return fmt.Errorf("unable to lock doctor schedule: %v", err)
+	}
+
+	var pacient models.Pacient
+	if err := tx.Clauses(lock).Select("id", "status").First(&pacient, appointment.PacientID).Error; err != nil {
+		return err
+	}
+	if pacient.Status == enums.PacientInactive {
+		return ErrPacientInactive
+	}
+
+	err = availability.NewService(tx, s.location).CheckAvailability(appointment.UserID, appointment.Date, appointment.EndDate)
+	if err != nil {
+		return err
+	}
+
+	var conflict models.Appointment
+	err = tx.
+		Where("(user_id = ? OR pacient_id = ?) AND date < ? AND end_date > ? AND id <> ? AND status NOT IN ?",
+			appointment.UserID, appointment.PacientID, appointment.EndDate, appointment.Date, appointment.ID, inactiveStatuses).
+		Order("date").
+		First(&conflict).Error
+
+	switch {
+	case err == nil:
+		return &AppointmentConflictError{
+			Appointment: conflict,
+			DoctorBusy:  conflict.UserID == appointment.UserID,
+			Location:    s.location,
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("unable to check appointment conflicts: %v", err)
+	}
+
+	return nil
 }
 
 func calculateAgeRange(age int) (time.Time, time.Time) {
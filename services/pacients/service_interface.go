@@ -1,12 +1,26 @@
 package pacients
 
-import "github.com/andresidrim/cesupa-hospital/models"
+import (
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+)
 
 type PacientService interface {
 	Create(pacient *models.Pacient) error
 	Get(id uint64) (*models.Pacient, error)
-	GetAll(name string, ageStr string) ([]models.Pacient, error)
+	GetAll(filter PacientFilter) ([]models.Pacient, int64, error)
 	Update(id uint64, pacient *models.Pacient) error
-	Delete(id uint64) error
+	Inactivate(id uint64, reason string) (*models.Pacient, error)
+	Reactivate(id uint64) (*models.Pacient, error)
 	ScheduleAppointment(appointment *models.Appointment) error
+	UpdateAppointmentStatus(id uint64, status enums.AppointmentStatus, actorID uint) (*models.Appointment, error)
+	CancelAppointment(id uint64, reason string, actorID uint) (*models.Appointment, error)
+	RescheduleAppointment(id uint64, date time.Time, actorID uint) (*models.Appointment, error)
+	GetAppointment(id uint64) (*models.Appointment, error)
+	ListAppointments(filter AppointmentFilter) ([]models.Appointment, int64, error)
+	PacientAccess(doctorID uint, pacientID uint64) (bool, error)
+	GrantEmergencyAccess(doctorID uint, pacientID uint64, justification string) (*models.EmergencyAccess, error)
+	ListEmergencyAccesses(activeOnly bool) ([]models.EmergencyAccess, error)
 }
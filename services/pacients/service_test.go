@@ -1,37 +1,65 @@
 package pacients
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/availability"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	assert.NoError(t, err)
+// testConfig traz o fuso da clínica e a quebra de vidro padrão
+var testConfig = config.Default()
 
-	db.Exec("PRAGMA foreign_keys = ON;")
-
-	err = db.AutoMigrate(
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t,
 		&models.User{},
 		&models.Appointment{},
+		&models.AppointmentEvent{},
 		&models.Pacient{},
+		&models.Shift{},
+		&models.AvailabilityBlock{},
+		&models.EmergencyAccess{},
+		&models.Specialty{},
+		&models.DoctorProfile{},
 	)
-	assert.NoError(t, err)
+
+	// o SQLite só confere chaves estrangeiras quando pedido
+	if db.Dialector.Name() == "sqlite" {
+		db.Exec("PRAGMA foreign_keys = ON;")
+	}
 
 	t.Log("Test DB setup complete")
 
 	return db
 }
 
+// futureAt devolve um horário fixo daqui a uma semana no fuso da clínica
+func futureAt(hour, minute int) time.Time {
+	d := time.Now().In(testConfig.Location).AddDate(0, 0, 7)
+	return time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, testConfig.Location)
+}
+
+// addFullWeekShifts libera a agenda do médico em todos os dias, o dia inteiro
+func addFullWeekShifts(t *testing.T, db *gorm.DB, doctorID uint) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		shift := models.Shift{UserID: doctorID, Weekday: day, StartTime: "00:00", EndTime: "24:00"}
+		assert.NoError(t, db.Create(&shift).Error)
+	}
+}
+
 func TestServiceGet(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewService(db)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
 
 	// Arrange: crie um pacient válido para o cenário de sucesso
 	existing := models.Pacient{
@@ -78,70 +106,125 @@ func TestServiceGet(t *testing.T) {
 
 func TestServiceGetAll(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewService(db)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
 
+	now := time.Now()
+	oPositive := enums.OPositive
 	pacients := []models.Pacient{
-		{Name: "John Doe", BirthDate: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "111", Sex: "male", PhoneNumber: "123", Address: "123 Street"},
-		{Name: "Jane Smith", BirthDate: time.Date(1995, 5, 10, 0, 0, 0, 0, time.UTC), CPF: "222", Sex: "female", PhoneNumber: "456", Address: "456 Avenue"},
+		{Name: "John Doe", BirthDate: now.AddDate(-30, -1, 0), CPF: "111", Sex: "male", PhoneNumber: "91 9999-0001", Address: "123 Street"},
+		{Name: "Jane Smith", BirthDate: now.AddDate(-45, -1, 0), CPF: "222", Sex: "female", PhoneNumber: "91 9999-0002", Address: "456 Avenue", BloodType: &oPositive},
+		{Name: "Ana Souza", BirthDate: now.AddDate(-8, -1, 0), CPF: "333", Sex: "female", PhoneNumber: "11 8888-0003", Address: "789 Road"},
 	}
 
-	for _, p := range pacients {
-		err := service.Create(&p)
+	for i := range pacients {
+		err := service.Create(&pacients[i])
 		assert.NoError(t, err)
-		t.Logf("Created pacient: %s, BirthDate: %s", p.Name, p.BirthDate.Format("2006-01-02"))
 	}
 
+	doctor := models.User{Name: "Dr. Smith", CPF: "999", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	appointment := models.Appointment{PacientID: pacients[1].ID, UserID: doctor.ID, Date: futureAt(10, 0), EndDate: futureAt(10, 30)}
+	assert.NoError(t, db.Create(&appointment).Error)
+
+	age := func(years int) *int { return &years }
+
 	tests := []struct {
-		name       string
-		filterName string
-		filterAge  string
-		wantCount  int
-		wantFirst  string
+		name      string
+		filter    PacientFilter
+		wantErr   error
+		wantTotal int64
+		wantNames []string
 	}{
 		{
-			name:       "no filters",
-			filterName: "",
-			filterAge:  "",
-			wantCount:  2,
-			wantFirst:  "John Doe",
+			name:      "no filters",
+			wantTotal: 3,
+			wantNames: []string{"John Doe", "Jane Smith", "Ana Souza"},
+		},
+		{
+			name:      "filter by name Jane",
+			filter:    PacientFilter{Name: "Jane"},
+			wantTotal: 1,
+			wantNames: []string{"Jane Smith"},
+		},
+		{
+			name:      "filter by exact age",
+			filter:    PacientFilter{Age: age(30)},
+			wantTotal: 1,
+			wantNames: []string{"John Doe"},
+		},
+		{
+			name:   "filter by non-matching age",
+			filter: PacientFilter{Age: age(1000)},
+		},
+		{
+			name:      "filter by age range",
+			filter:    PacientFilter{MinAge: age(18), MaxAge: age(40)},
+			wantTotal: 1,
+			wantNames: []string{"John Doe"},
+		},
+		{
+			name:      "filter by minimum age only",
+			filter:    PacientFilter{MinAge: age(30), Sort: "birthDate"},
+			wantTotal: 2,
+			wantNames: []string{"Jane Smith", "John Doe"},
+		},
+		{
+			name:      "filter by cpf, sex and blood type",
+			filter:    PacientFilter{CPF: "222", Sex: "female", BloodType: enums.OPositive},
+			wantTotal: 1,
+			wantNames: []string{"Jane Smith"},
+		},
+		{
+			name:      "filter by phone",
+			filter:    PacientFilter{Phone: "91 9999", Sort: "-name"},
+			wantTotal: 2,
+			wantNames: []string{"John Doe", "Jane Smith"},
+		},
+		{
+			name:      "filter by doctor",
+			filter:    PacientFilter{DoctorID: doctor.ID},
+			wantTotal: 1,
+			wantNames: []string{"Jane Smith"},
 		},
 		{
-			name:       "filter by name Jane",
-			filterName: "Jane",
-			filterAge:  "",
-			wantCount:  1,
-			wantFirst:  "Jane Smith",
+			name:      "paginated and sorted by name",
+			filter:    PacientFilter{Sort: "name", Page: 2, Limit: 2},
+			wantTotal: 3,
+			wantNames: []string{"John Doe"},
 		},
 		{
-			name:       "filter by non-matching age",
-			filterName: "",
-			filterAge:  "1000",
-			wantCount:  0,
+			name:    "invalid sort",
+			filter:  PacientFilter{Sort: "cpf"},
+			wantErr: ErrInvalidSort,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := service.GetAll(tt.filterName, tt.filterAge)
+			result, total, err := service.GetAll(tt.filter)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
 			assert.NoError(t, err)
-			t.Logf("GetAll with name '%s' and age '%s' returned %d pacients", tt.filterName, tt.filterAge, len(result))
+			assert.Equal(t, tt.wantTotal, total)
 
+			names := []string{}
 			for _, r := range result {
-				t.Logf("Pacient: %s, BirthDate: %s", r.Name, r.BirthDate.Format("2006-01-02"))
+				names = append(names, r.Name)
+				assert.Empty(t, r.Appointments)
 			}
-
-			assert.Len(t, result, tt.wantCount)
-
-			if tt.wantCount > 0 {
-				assert.Equal(t, tt.wantFirst, result[0].Name)
+			if tt.wantNames == nil {
+				tt.wantNames = []string{}
 			}
+			assert.Equal(t, tt.wantNames, names)
 		})
 	}
 }
 
 func TestServiceUpdate(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewService(db)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
 
 	pacient := models.Pacient{
 		Name:        "John Doe",
@@ -196,9 +279,9 @@ func TestServiceUpdate(t *testing.T) {
 	}
 }
 
-func TestServiceDelete(t *testing.T) {
+func TestServiceInactivate(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewService(db)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
 
 	pacient := models.Pacient{
 		Name:        "John Doe",
@@ -208,47 +291,93 @@ func TestServiceDelete(t *testing.T) {
 		PhoneNumber: "+123456789",
 		Address:     "123 Street",
 	}
-	err := service.Create(&pacient)
-	assert.NoError(t, err)
+	assert.NoError(t, service.Create(&pacient))
+	assert.Equal(t, enums.PacientActive, pacient.Status)
 
-	tests := []struct {
-		name          string
-		id            uint64
-		expectedError error
-	}{
-		{
-			name:          "successful delete",
-			id:            uint64(pacient.ID),
-			expectedError: nil,
-		},
-		{
-			name:          "non-existent pacient",
-			id:            9999,
-			expectedError: gorm.ErrRecordNotFound,
-		},
-	}
+	doctor := models.User{Name: "Dr. Smith", CPF: "333", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	addFullWeekShifts(t, db, doctor.ID)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := service.Delete(tt.id)
+	t.Run("non-existent pacient", func(t *testing.T) {
+		_, err := service.Inactivate(9999, "")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
 
-			if tt.expectedError != nil {
-				assert.ErrorIs(t, err, tt.expectedError)
-			} else {
-				assert.NoError(t, err)
-			}
+		_, err = service.Reactivate(9999)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
 
-			if tt.expectedError == nil {
-				_, getErr := service.Get(tt.id)
-				assert.ErrorIs(t, getErr, gorm.ErrRecordNotFound)
-			}
-		})
-	}
+	t.Run("inactivate keeps the record", func(t *testing.T) {
+		inactivated, err := service.Inactivate(uint64(pacient.ID), "Mudou de cidade")
+		assert.NoError(t, err)
+		assert.Equal(t, enums.PacientInactive, inactivated.Status)
+		assert.NotNil(t, inactivated.InactivatedAt)
+
+		found, err := service.Get(uint64(pacient.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, enums.PacientInactive, found.Status)
+		assert.Equal(t, "Mudou de cidade", *found.InactivationReason)
+
+		_, err = service.Inactivate(uint64(pacient.ID), "")
+		assert.ErrorIs(t, err, ErrPacientInactive)
+	})
+
+	t.Run("inactive pacients are hidden from listing by default", func(t *testing.T) {
+		result, _, err := service.GetAll(PacientFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+
+		result, _, err = service.GetAll(PacientFilter{IncludeInactive: true})
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("cpf stays reserved", func(t *testing.T) {
+		duplicate := pacient
+		duplicate.ID = 0
+		err := service.Create(&duplicate)
+
+		var duplicateErr *DuplicateCPFError
+		if assert.ErrorAs(t, err, &duplicateErr) {
+			assert.Equal(t, pacient.ID, duplicateErr.Pacient.ID)
+			assert.Contains(t, err.Error(), "reactivate")
+		}
+	})
+
+	t.Run("inactive pacient cannot be scheduled", func(t *testing.T) {
+		appointment := models.Appointment{PacientID: pacient.ID, UserID: doctor.ID, Date: futureAt(10, 0)}
+		assert.ErrorIs(t, service.ScheduleAppointment(&appointment), ErrPacientInactive)
+	})
+
+	t.Run("reactivate", func(t *testing.T) {
+		reactivated, err := service.Reactivate(uint64(pacient.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, enums.PacientActive, reactivated.Status)
+		assert.Nil(t, reactivated.InactivatedAt)
+		assert.Nil(t, reactivated.InactivationReason)
+
+		_, err = service.Reactivate(uint64(pacient.ID))
+		assert.ErrorIs(t, err, ErrPacientActive)
+
+		appointment := models.Appointment{PacientID: pacient.ID, UserID: doctor.ID, Date: futureAt(10, 0)}
+		assert.NoError(t, service.ScheduleAppointment(&appointment))
+	})
+
+	t.Run("reactivate legacy soft-deleted pacient", func(t *testing.T) {
+		legacy := models.Pacient{Name: "Old", BirthDate: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "456", Sex: "male", PhoneNumber: "1", Address: "Street"}
+		assert.NoError(t, service.Create(&legacy))
+		assert.NoError(t, db.Delete(&legacy).Error)
+
+		_, err := service.Reactivate(uint64(legacy.ID))
+		assert.NoError(t, err)
+
+		_, err = service.Get(uint64(legacy.ID))
+		assert.NoError(t, err)
+	})
 }
 
 func TestServiceScheduleAppointment(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewService(db)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
 
 	pacient := models.Pacient{
 		Name:        "John Doe",
@@ -266,6 +395,7 @@ func TestServiceScheduleAppointment(t *testing.T) {
 		Role: enums.Doctor,
 	}
 	assert.NoError(t, db.Create(&doctor).Error)
+	addFullWeekShifts(t, db, doctor.ID)
 
 	tests := []struct {
 		name          string
@@ -277,7 +407,7 @@ func TestServiceScheduleAppointment(t *testing.T) {
 			appointment: models.Appointment{
 				PacientID: pacient.ID,
 				UserID:    doctor.ID,
-				Date:      time.Now().AddDate(0, 0, 1),
+				Date:      futureAt(10, 0),
 			},
 			expectedError: false,
 		},
@@ -285,7 +415,16 @@ func TestServiceScheduleAppointment(t *testing.T) {
 			name: "missing DoctorID",
 			appointment: models.Appointment{
 				PacientID: pacient.ID,
-				Date:      time.Now().AddDate(0, 0, 1),
+				Date:      futureAt(11, 0),
+			},
+			expectedError: true,
+		},
+		{
+			name: "date in the past",
+			appointment: models.Appointment{
+				PacientID: pacient.ID,
+				UserID:    doctor.ID,
+				Date:      time.Now().Add(-time.Hour),
 			},
 			expectedError: true,
 		},
@@ -307,3 +446,505 @@ func TestServiceScheduleAppointment(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceScheduleAppointmentConflicts(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
+
+	john := models.Pacient{Name: "John Doe", BirthDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "111", Sex: "male", PhoneNumber: "123", Address: "Street"}
+	jane := models.Pacient{Name: "Jane Smith", BirthDate: time.Date(1992, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "222", Sex: "female", PhoneNumber: "456", Address: "Avenue"}
+	assert.NoError(t, service.Create(&john))
+	assert.NoError(t, service.Create(&jane))
+
+	smith := models.User{Name: "Dr. Smith", CPF: "333", Role: enums.Doctor}
+	house := models.User{Name: "Dr. House", CPF: "444", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&smith).Error)
+	assert.NoError(t, db.Create(&house).Error)
+	addFullWeekShifts(t, db, smith.ID)
+	addFullWeekShifts(t, db, house.ID)
+
+	base := futureAt(10, 0)
+	existing := models.Appointment{PacientID: john.ID, UserID: smith.ID, Date: base}
+	assert.NoError(t, service.ScheduleAppointment(&existing))
+	assert.True(t, base.Add(DefaultAppointmentDuration).Equal(existing.EndDate))
+
+	// sem horário de término, vale a duração padrão do perfil do médico
+	profile := models.DoctorProfile{UserID: house.ID, CRM: "1234", CRMUF: "PA", ConsultationMinutes: 45}
+	assert.NoError(t, db.Create(&profile).Error)
+	long := models.Appointment{PacientID: jane.ID, UserID: house.ID, Date: base.AddDate(0, 0, 1)}
+	assert.NoError(t, service.ScheduleAppointment(&long))
+	assert.Equal(t, 45*time.Minute, long.EndDate.Sub(long.Date))
+
+	tests := []struct {
+		name         string
+		appointment  models.Appointment
+		wantConflict bool
+		wantDoctor   bool
+	}{
+		{
+			name:         "same doctor overlapping",
+			appointment:  models.Appointment{PacientID: jane.ID, UserID: smith.ID, Date: base.Add(15 * time.Minute)},
+			wantConflict: true,
+			wantDoctor:   true,
+		},
+		{
+			name:         "same pacient overlapping",
+			appointment:  models.Appointment{PacientID: john.ID, UserID: house.ID, Date: base.Add(-15 * time.Minute)},
+			wantConflict: true,
+			wantDoctor:   false,
+		},
+		{
+			name:        "back to back",
+			appointment: models.Appointment{PacientID: jane.ID, UserID: smith.ID, Date: base.Add(DefaultAppointmentDuration)},
+		},
+		{
+			name:        "other doctor and pacient",
+			appointment: models.Appointment{PacientID: jane.ID, UserID: house.ID, Date: base.Add(-2 * time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ScheduleAppointment(&tt.appointment)
+			if !tt.wantConflict {
+				assert.NoError(t, err)
+				return
+			}
+
+			var conflictErr *AppointmentConflictError
+			assert.ErrorAs(t, err, &conflictErr)
+			assert.Equal(t, existing.ID, conflictErr.Appointment.ID)
+			assert.Equal(t, tt.wantDoctor, conflictErr.DoctorBusy)
+			assert.Zero(t, tt.appointment.ID)
+		})
+	}
+}
+
+func TestServiceScheduleAppointmentConcurrently(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
+
+	const attempts = 8
+	var pacients []models.Pacient
+	var users []models.User
+	for i := 0; i < attempts; i++ {
+		pacient := models.Pacient{Name: fmt.Sprintf("Pacient %d", i), BirthDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), CPF: cpf.CPF(fmt.Sprintf("1%02d", i)), Sex: "male", PhoneNumber: "123", Address: "Street"}
+		assert.NoError(t, service.Create(&pacient))
+		pacients = append(pacients, pacient)
+
+		doctor := models.User{Name: fmt.Sprintf("Dr. %d", i), CPF: cpf.CPF(fmt.Sprintf("2%02d", i)), Role: enums.Doctor}
+		assert.NoError(t, db.Create(&doctor).Error)
+		addFullWeekShifts(t, db, doctor.ID)
+		users = append(users, doctor)
+	}
+
+	// todos disputam o mesmo horário: só um agendamento pode vencer
+	book := func(appointment func(i int) models.Appointment) []error {
+		errs := make([]error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				a := appointment(i)
+				errs[i] = service.ScheduleAppointment(&a)
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	tests := []struct {
+		name        string
+		appointment func(i int) models.Appointment
+	}{
+		{
+			name: "same doctor",
+			appointment: func(i int) models.Appointment {
+				return models.Appointment{PacientID: pacients[i].ID, UserID: users[0].ID, Date: futureAt(9, 0)}
+			},
+		},
+		{
+			name: "same pacient",
+			appointment: func(i int) models.Appointment {
+				return models.Appointment{PacientID: pacients[0].ID, UserID: users[i].ID, Date: futureAt(14, 0)}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var booked int
+			for _, err := range book(tt.appointment) {
+				var conflictErr *AppointmentConflictError
+				switch {
+				case err == nil:
+					booked++
+				case !errors.As(err, &conflictErr):
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			assert.Equal(t, 1, booked)
+		})
+	}
+
+	var count int64
+	assert.NoError(t, db.Model(&models.Appointment{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestServiceScheduleAppointmentAvailability(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
+
+	pacient := models.Pacient{Name: "John Doe", BirthDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "111", Sex: "male", PhoneNumber: "123", Address: "Street"}
+	assert.NoError(t, service.Create(&pacient))
+
+	doctor := models.User{Name: "Dr. Smith", CPF: "333", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+
+	day := futureAt(0, 0)
+	shift := models.Shift{UserID: doctor.ID, Weekday: day.Weekday(), StartTime: "08:00", EndTime: "12:00"}
+	assert.NoError(t, db.Create(&shift).Error)
+
+	block := models.AvailabilityBlock{UserID: doctor.ID, StartsAt: futureAt(10, 0).UTC(), EndsAt: futureAt(11, 0).UTC(), Reason: enums.Congress}
+	assert.NoError(t, db.Create(&block).Error)
+
+	tests := []struct {
+		name       string
+		date       time.Time
+		wantErr    bool
+		wantReason string
+	}{
+		{name: "inside shift", date: futureAt(8, 0)},
+		{name: "before shift", date: futureAt(7, 45), wantErr: true, wantReason: "does not work"},
+		{name: "past end of shift", date: futureAt(11, 45), wantErr: true, wantReason: "does not work"},
+		{name: "other weekday", date: futureAt(9, 0).AddDate(0, 0, 1), wantErr: true, wantReason: "does not work"},
+		{name: "during block", date: futureAt(10, 30), wantErr: true, wantReason: "congress"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appointment := models.Appointment{PacientID: pacient.ID, UserID: doctor.ID, Date: tt.date}
+			err := service.ScheduleAppointment(&appointment)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantReason)
+			}
+			assert.Zero(t, appointment.ID)
+		})
+	}
+}
+
+// TestServiceScheduleSuggestedSlots agenda os horários sugeridos pela busca
+// para um médico com consultas mais longas que o padrão
+func TestServiceScheduleSuggestedSlots(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
+
+	pacient := models.Pacient{Name: "John Doe", BirthDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "111", Sex: "male", PhoneNumber: "123", Address: "Street"}
+	assert.NoError(t, service.Create(&pacient))
+
+	doctor := models.User{Name: "Dr. House", CPF: "333", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	assert.NoError(t, db.Create(&models.DoctorProfile{UserID: doctor.ID, CRM: "1234", CRMUF: "PA", ConsultationMinutes: 60}).Error)
+
+	day := futureAt(0, 0)
+	assert.NoError(t, db.Create(&models.Shift{UserID: doctor.ID, Weekday: day.Weekday(), StartTime: "08:00", EndTime: "11:00"}).Error)
+	first := models.Appointment{PacientID: pacient.ID, UserID: doctor.ID, Date: futureAt(8, 0)}
+	assert.NoError(t, service.ScheduleAppointment(&first))
+
+	slots, err := availability.NewService(db, testConfig.Location).FindSlots(availability.SlotFilter{
+		DoctorIDs: []uint{doctor.ID},
+		From:      day,
+		To:        day.AddDate(0, 0, 1),
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, slots, 2) {
+		return
+	}
+
+	for _, slot := range slots {
+		appointment := models.Appointment{PacientID: pacient.ID, UserID: doctor.ID, Date: slot.Start}
+		assert.NoError(t, service.ScheduleAppointment(&appointment))
+		assert.True(t, slot.End.Equal(appointment.EndDate), "slot %s", slot.Start)
+	}
+}
+
+func TestServiceAppointmentLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
+
+	pacient := models.Pacient{Name: "John Doe", BirthDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "111", Sex: "male", PhoneNumber: "123", Address: "Street"}
+	assert.NoError(t, service.Create(&pacient))
+
+	doctor := models.User{Name: "Dr. Smith", CPF: "333", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	addFullWeekShifts(t, db, doctor.ID)
+
+	schedule := func(t *testing.T, date time.Time) models.Appointment {
+		appointment := models.Appointment{PacientID: pacient.ID, UserID: doctor.ID, Date: date}
+		assert.NoError(t, service.ScheduleAppointment(&appointment))
+		assert.Equal(t, enums.Scheduled, appointment.Status)
+		return appointment
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		appointment := schedule(t, futureAt(8, 0))
+
+		for _, status := range []enums.AppointmentStatus{enums.Confirmed, enums.CheckedIn, enums.InProgress, enums.Completed} {
+			updated, err := service.UpdateAppointmentStatus(uint64(appointment.ID), status, doctor.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, status, updated.Status)
+		}
+
+		updated, err := service.UpdateAppointmentStatus(uint64(appointment.ID), enums.Confirmed, doctor.ID)
+		assert.Nil(t, updated)
+		var transitionErr *InvalidTransitionError
+		assert.ErrorAs(t, err, &transitionErr)
+		assert.Equal(t, enums.Completed, transitionErr.From)
+
+		var history []models.AppointmentEvent
+		assert.NoError(t, db.Where("appointment_id = ?", appointment.ID).Order("id").Find(&history).Error)
+		assert.Len(t, history, 4)
+		assert.Equal(t, enums.Scheduled, history[0].FromStatus)
+		assert.Equal(t, doctor.ID, *history[0].ActorID)
+	})
+
+	t.Run("skipping steps is rejected", func(t *testing.T) {
+		appointment := schedule(t, futureAt(9, 0))
+
+		_, err := service.UpdateAppointmentStatus(uint64(appointment.ID), enums.Completed, 0)
+		var transitionErr *InvalidTransitionError
+		assert.ErrorAs(t, err, &transitionErr)
+	})
+
+	t.Run("cancel frees the slot", func(t *testing.T) {
+		appointment := schedule(t, futureAt(10, 0))
+
+		_, err := service.CancelAppointment(uint64(appointment.ID), "", 0)
+		assert.ErrorIs(t, err, ErrCancelRequiresReason)
+
+		_, err = service.UpdateAppointmentStatus(uint64(appointment.ID), enums.Cancelled, 0)
+		assert.ErrorIs(t, err, ErrCancelRequiresReason)
+
+		cancelled, err := service.CancelAppointment(uint64(appointment.ID), "Paciente viajou", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, enums.Cancelled, cancelled.Status)
+		assert.Equal(t, "Paciente viajou", *cancelled.CancelReason)
+		if assert.Len(t, cancelled.History, 1) {
+			assert.Nil(t, cancelled.History[0].ActorID)
+			assert.Equal(t, "Paciente viajou", *cancelled.History[0].Reason)
+		}
+
+		_, err = service.CancelAppointment(uint64(appointment.ID), "De novo", 0)
+		var transitionErr *InvalidTransitionError
+		assert.ErrorAs(t, err, &transitionErr)
+
+		schedule(t, futureAt(10, 0))
+	})
+
+	t.Run("reschedule keeps duration and history", func(t *testing.T) {
+		appointment := schedule(t, futureAt(14, 0))
+		_, err := service.UpdateAppointmentStatus(uint64(appointment.ID), enums.Confirmed, 0)
+		assert.NoError(t, err)
+
+		moved, err := service.RescheduleAppointment(uint64(appointment.ID), futureAt(15, 0), doctor.ID)
+		assert.NoError(t, err)
+		assert.True(t, futureAt(15, 0).Equal(moved.Date))
+		assert.True(t, futureAt(15, 0).Add(DefaultAppointmentDuration).Equal(moved.EndDate))
+		assert.Equal(t, enums.Scheduled, moved.Status)
+		if assert.Len(t, moved.History, 2) {
+			assert.True(t, futureAt(14, 0).Equal(*moved.History[1].FromDate))
+			assert.True(t, futureAt(15, 0).Equal(*moved.History[1].ToDate))
+		}
+
+		// mover alguns minutos não conflita com o próprio horário
+		_, err = service.RescheduleAppointment(uint64(appointment.ID), futureAt(15, 10), doctor.ID)
+		assert.NoError(t, err)
+
+		_, err = service.RescheduleAppointment(uint64(appointment.ID), time.Now().Add(-time.Hour), doctor.ID)
+		assert.ErrorIs(t, err, ErrAppointmentInPast)
+	})
+
+	t.Run("reschedule onto a busy slot", func(t *testing.T) {
+		busy := schedule(t, futureAt(16, 0))
+		appointment := schedule(t, futureAt(17, 0))
+
+		_, err := service.RescheduleAppointment(uint64(appointment.ID), futureAt(16, 15), 0)
+		var conflictErr *AppointmentConflictError
+		if assert.ErrorAs(t, err, &conflictErr) {
+			assert.Equal(t, busy.ID, conflictErr.Appointment.ID)
+		}
+
+		reloaded, err := service.UpdateAppointmentStatus(uint64(appointment.ID), enums.Confirmed, 0)
+		assert.NoError(t, err)
+		assert.True(t, futureAt(17, 0).Equal(reloaded.Date))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := service.CancelAppointment(9999, "motivo", 0)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestServiceListAppointments(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
+
+	john := models.Pacient{Name: "John Doe", BirthDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "111", Sex: "male", PhoneNumber: "123", Address: "Street"}
+	jane := models.Pacient{Name: "Jane Smith", BirthDate: time.Date(1992, 1, 1, 0, 0, 0, 0, time.UTC), CPF: "222", Sex: "female", PhoneNumber: "456", Address: "Avenue"}
+	assert.NoError(t, service.Create(&john))
+	assert.NoError(t, service.Create(&jane))
+
+	smith := models.User{Name: "Dr. Smith", CPF: "333", Role: enums.Doctor}
+	house := models.User{Name: "Dr. House", CPF: "444", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&smith).Error)
+	assert.NoError(t, db.Create(&house).Error)
+	addFullWeekShifts(t, db, smith.ID)
+	addFullWeekShifts(t, db, house.ID)
+
+	for _, a := range []models.Appointment{
+		{PacientID: john.ID, UserID: smith.ID, Date: futureAt(9, 0)},
+		{PacientID: jane.ID, UserID: smith.ID, Date: futureAt(10, 0)},
+		{PacientID: john.ID, UserID: house.ID, Date: futureAt(11, 0)},
+		{PacientID: jane.ID, UserID: house.ID, Date: futureAt(9, 0).AddDate(0, 0, 1)},
+	} {
+		assert.NoError(t, service.ScheduleAppointment(&a))
+	}
+	first, _, err := service.ListAppointments(AppointmentFilter{Limit: 1})
+	assert.NoError(t, err)
+	_, err = service.CancelAppointment(uint64(first[0].ID), "Desistiu", 0)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		filter    AppointmentFilter
+		wantTotal int64
+		wantLen   int
+		wantFirst time.Time
+		wantErr   error
+	}{
+		{name: "all", filter: AppointmentFilter{}, wantTotal: 4, wantLen: 4, wantFirst: futureAt(9, 0)},
+		{name: "by doctor", filter: AppointmentFilter{DoctorID: smith.ID}, wantTotal: 2, wantLen: 2, wantFirst: futureAt(9, 0)},
+		{name: "by pacient", filter: AppointmentFilter{PacientID: jane.ID}, wantTotal: 2, wantLen: 2, wantFirst: futureAt(10, 0)},
+		{name: "by day", filter: AppointmentFilter{From: futureAt(0, 0), To: futureAt(0, 0).AddDate(0, 0, 1)}, wantTotal: 3, wantLen: 3, wantFirst: futureAt(9, 0)},
+		{name: "by status", filter: AppointmentFilter{Statuses: []enums.AppointmentStatus{enums.Scheduled}}, wantTotal: 3, wantLen: 3, wantFirst: futureAt(10, 0)},
+		{name: "descending", filter: AppointmentFilter{Sort: "-date"}, wantTotal: 4, wantLen: 4, wantFirst: futureAt(9, 0).AddDate(0, 0, 1)},
+		{name: "second page", filter: AppointmentFilter{Page: 2, Limit: 3}, wantTotal: 4, wantLen: 1, wantFirst: futureAt(9, 0).AddDate(0, 0, 1)},
+		{name: "invalid sort", filter: AppointmentFilter{Sort: "pacient"}, wantErr: ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appointments, total, err := service.ListAppointments(tt.filter)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			if assert.Len(t, appointments, tt.wantLen) {
+				assert.True(t, tt.wantFirst.Equal(appointments[0].Date), "first appointment at %s", appointments[0].Date)
+				assert.NotEmpty(t, appointments[0].Pacient.Name)
+				assert.NotEmpty(t, appointments[0].User.Name)
+			}
+		})
+	}
+
+	appointment, err := service.GetAppointment(uint64(first[0].ID))
+	assert.NoError(t, err)
+	assert.Len(t, appointment.History, 1)
+	assert.Equal(t, "John Doe", appointment.Pacient.Name)
+
+	_, err = service.GetAppointment(9999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestServiceEmergencyAccess(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, testConfig.Location, testConfig.Emergency)
+
+	var notified []models.EmergencyAccess
+	service.notify = func(access models.EmergencyAccess) { notified = append(notified, access) }
+
+	now := time.Now()
+	own := models.Pacient{Name: "Own", BirthDate: now.AddDate(-30, 0, 0), CPF: "111", Sex: "male", PhoneNumber: "1", Address: "a"}
+	other := models.Pacient{Name: "Other", BirthDate: now.AddDate(-40, 0, 0), CPF: "222", Sex: "female", PhoneNumber: "2", Address: "b"}
+	expired := models.Pacient{Name: "Expired", BirthDate: now.AddDate(-50, 0, 0), CPF: "333", Sex: "female", PhoneNumber: "3", Address: "c"}
+	for _, p := range []*models.Pacient{&own, &other, &expired} {
+		assert.NoError(t, service.Create(p))
+	}
+
+	doctor := models.User{Name: "Dr. Smith", CPF: "999", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&doctor).Error)
+	past := now.AddDate(0, -1, 0)
+	appointment := models.Appointment{PacientID: own.ID, UserID: doctor.ID, Date: past, EndDate: past.Add(30 * time.Minute)}
+	assert.NoError(t, db.Create(&appointment).Error)
+	old := models.EmergencyAccess{UserID: doctor.ID, PacientID: expired.ID, Justification: "old", ExpiresAt: now.Add(-time.Minute)}
+	assert.NoError(t, db.Create(&old).Error)
+	colleague := models.User{Name: "Dr. Jones", CPF: "888", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&colleague).Error)
+	elsewhere := models.Appointment{PacientID: other.ID, UserID: colleague.ID, Date: past, EndDate: past.Add(30 * time.Minute)}
+	assert.NoError(t, db.Create(&elsewhere).Error)
+
+	visible := func() []string {
+		list, total, err := service.GetAll(PacientFilter{VisibleTo: doctor.ID, Sort: "name"})
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(list), total)
+		var names []string
+		for _, p := range list {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+	visibleAppointments := func() []uint {
+		list, total, err := service.ListAppointments(AppointmentFilter{VisibleTo: doctor.ID})
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(list), total)
+		var pacients []uint
+		for _, a := range list {
+			pacients = append(pacients, a.PacientID)
+		}
+		return pacients
+	}
+
+	// consulta passada ainda dá acesso; grant vencido não dá
+	assert.Equal(t, []string{"Own"}, visible())
+	assert.Equal(t, []uint{own.ID}, visibleAppointments())
+	emergency, err := service.PacientAccess(doctor.ID, uint64(own.ID))
+	assert.NoError(t, err)
+	assert.False(t, emergency)
+	_, err = service.PacientAccess(doctor.ID, uint64(other.ID))
+	assert.ErrorIs(t, err, ErrNoPacientAccess)
+	_, err = service.PacientAccess(doctor.ID, uint64(expired.ID))
+	assert.ErrorIs(t, err, ErrNoPacientAccess)
+
+	_, err = service.GrantEmergencyAccess(doctor.ID, 9999, "Paciente inconsciente no pronto-socorro")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Empty(t, notified)
+
+	access, err := service.GrantEmergencyAccess(doctor.ID, uint64(other.ID), "Paciente inconsciente no pronto-socorro")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, now.Add(testConfig.Emergency.AccessTTL), access.ExpiresAt, time.Minute)
+	assert.Len(t, notified, 1)
+	assert.Equal(t, other.ID, notified[0].PacientID)
+
+	emergency, err = service.PacientAccess(doctor.ID, uint64(other.ID))
+	assert.NoError(t, err)
+	assert.True(t, emergency)
+	assert.Equal(t, []string{"Other", "Own"}, visible())
+	assert.ElementsMatch(t, []uint{own.ID, other.ID}, visibleAppointments())
+
+	active, err := service.ListEmergencyAccesses(true)
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+	all, err := service.ListEmergencyAccesses(false)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+}
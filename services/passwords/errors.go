@@ -0,0 +1,21 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrPasswordReused    = errors.New("password was used recently; choose a different one")
+	ErrWrongPassword     = errors.New("current password is incorrect")
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+)
+
+// PolicyError lista as regras da política de senha que não foram cumpridas
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password " + strings.Join(e.Violations, "; ")
+}
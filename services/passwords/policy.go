@@ -0,0 +1,87 @@
+package passwords
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+)
+
+//go:embed common.txt
+var commonList string
+
+// common são senhas conhecidas demais para serem aceitas, comparadas sem
+// diferenciar maiúsculas.
+var common = func() map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, line := range strings.Split(commonList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return set
+}()
+
+// Policy define as regras de senha. MinClasses conta quantos tipos de
+// caractere (minúscula, maiúscula, dígito, símbolo) a senha precisa ter e
+// History quantas senhas anteriores não podem ser repetidas.
+type Policy struct {
+	MinLength  int
+	MinClasses int
+	History    int
+}
+
+// NewPolicy monta a política a partir da configuração
+func NewPolicy(cfg config.Password) Policy {
+	return Policy{
+		MinLength:  cfg.MinLength,
+		MinClasses: cfg.MinClasses,
+		History:    cfg.History,
+	}
+}
+
+// Validate lista todas as regras que a senha descumpre, não só a primeira
+func (p Policy) Validate(password string) error {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must have at least %d characters", p.MinLength))
+	}
+	if classes := characterClasses(password); classes < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("must mix at least %d of: lowercase, uppercase, digits, symbols", p.MinClasses))
+	}
+	if _, ok := common[strings.ToLower(password)]; ok {
+		violations = append(violations, "is too common")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
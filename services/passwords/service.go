@@ -0,0 +1,205 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/sessions"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"gorm.io/gorm"
+)
+
+var ErrUserInactive = errors.New("user is inactive")
+
+type Service struct {
+	db       *gorm.DB
+	policy   Policy
+	resetTTL time.Duration
+}
+
+func NewService(db *gorm.DB, cfg config.Password) *Service {
+	return &Service{db: db, policy: NewPolicy(cfg), resetTTL: cfg.ResetTTL}
+}
+
+// Change troca a senha do próprio usuário, que precisa informar a atual.
+// Todas as sessões caem e o usuário entra de novo com a senha nova.
+func (s *Service) Change(userID uint, current, next string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := utils.CheckPassword(user.Password, current); err != nil {
+			return ErrWrongPassword
+		}
+
+		return s.policy.Set(tx, &user, next, false)
+	})
+}
+
+// IssueReset emite um token de redefinição de uso único e invalida os
+// anteriores. O token em texto puro só existe no retorno, para o admin
+// repassar ao usuário.
+func (s *Service) IssueReset(userID uint64, issuedByID uint) (*models.PasswordResetToken, string, error) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var reset models.PasswordResetToken
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if !user.IsActive() {
+			return ErrUserInactive
+		}
+
+		now := time.Now().UTC()
+		err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error
+		if err != nil {
+			return fmt.Errorf("unable to invalidate reset tokens: %v", err)
+		}
+
+		reset = models.PasswordResetToken{
+			UserID:     user.ID,
+			IssuedByID: issuedByID,
+			TokenHash:  utils.HashToken(token),
+			ExpiresAt:  now.Add(s.resetTTL),
+		}
+		return tx.Create(&reset).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &reset, token, nil
+}
+
+// Reset define a senha escolhida pelo usuário a partir do token emitido pelo admin
+func (s *Service) Reset(token, next string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		var reset models.PasswordResetToken
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(token), now).
+			First(&reset).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, reset.UserID).Error; err != nil {
+			return err
+		}
+		if !user.IsActive() {
+			return ErrInvalidResetToken
+		}
+
+		if err := s.policy.Set(tx, &user, next, false); err != nil {
+			return err
+		}
+
+		result := tx.Model(&reset).Where("used_at IS NULL").Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		return nil
+	})
+}
+
+// Hash valida a senha contra a política e devolve o hash, para cadastros
+// que ainda não têm histórico.
+func (p Policy) Hash(password string) (string, error) {
+	if err := p.Validate(password); err != nil {
+		return "", err
+	}
+	return utils.HashPassword(password)
+}
+
+// Set valida a nova senha contra a política e o histórico, grava o hash,
+// registra no histórico e encerra as sessões do usuário. É exportada para ser
+// usada dentro de transações de outros serviços.
+func (p Policy) Set(tx *gorm.DB, user *models.User, password string, mustChange bool) error {
+	if err := p.Validate(password); err != nil {
+		return err
+	}
+	if err := ensureNotReused(tx, user, password, p.History); err != nil {
+		return err
+	}
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(user).Updates(map[string]any{
+		"password":             hashed,
+		"must_change_password": mustChange,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("unable to update password: %v", err)
+	}
+
+	if err := p.Remember(tx, user.ID, hashed); err != nil {
+		return err
+	}
+	return sessions.RevokeAll(tx, user.ID)
+}
+
+// Remember registra o hash no histórico e descarta o que passar de History
+func (p Policy) Remember(tx *gorm.DB, userID uint, hash string) error {
+	if err := tx.Create(&models.PasswordHistory{UserID: userID, Hash: hash}).Error; err != nil {
+		return fmt.Errorf("unable to record password history: %v", err)
+	}
+
+	keep := tx.Model(&models.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(p.History)
+
+	err := tx.Where("user_id = ? AND id NOT IN (?)", userID, keep).Delete(&models.PasswordHistory{}).Error
+	if err != nil {
+		return fmt.Errorf("unable to prune password history: %v", err)
+	}
+	return nil
+}
+
+// ensureNotReused compara a senha com a atual e com as últimas do histórico
+func ensureNotReused(tx *gorm.DB, user *models.User, password string, history int) error {
+	if history <= 0 {
+		return nil
+	}
+
+	hashes := []string{user.Password}
+	var previous []string
+	err := tx.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", user.ID).
+		Order("id DESC").
+		Limit(history).
+		Pluck("hash", &previous).Error
+	if err != nil {
+		return err
+	}
+	hashes = append(hashes, previous...)
+
+	for _, hash := range hashes {
+		if hash != "" && utils.CheckPassword(hash, password) == nil {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package passwords
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type PasswordService interface {
+	Change(userID uint, current, next string) error
+	IssueReset(userID uint64, issuedByID uint) (*models.PasswordResetToken, string, error)
+	Reset(token, next string) error
+}
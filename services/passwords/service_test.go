@@ -0,0 +1,152 @@
+package passwords
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var testPolicy = NewPolicy(config.Default().Password)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.User{}, &models.Session{}, &models.PasswordHistory{}, &models.PasswordResetToken{})
+}
+
+func createUser(t *testing.T, db *gorm.DB, password string) models.User {
+	hashed, err := testPolicy.Hash(password)
+	assert.NoError(t, err)
+
+	user := models.User{Name: "Alice", CPF: "52998224725", Password: hashed, Role: enums.Doctor, MustChangePassword: true}
+	assert.NoError(t, db.Create(&user).Error)
+	assert.NoError(t, testPolicy.Remember(db, user.ID, hashed))
+	return user
+}
+
+func TestPolicyValidate(t *testing.T) {
+	policy := Policy{MinLength: 8, MinClasses: 3, History: 5}
+
+	tests := []struct {
+		name       string
+		password   string
+		violations int
+	}{
+		{name: "strong", password: "Plantao-2030"},
+		{name: "unicode letters count", password: "Ação-segura9"},
+		{name: "too short", password: "Ab-1", violations: 1},
+		{name: "single class", password: "abcdefghijk", violations: 1},
+		{name: "common password", password: "Password1", violations: 1},
+		{name: "common regardless of case", password: "SENHA@123", violations: 1},
+		{name: "short, single class and common", password: "123456", violations: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.violations == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PolicyError
+			assert.ErrorAs(t, err, &policyErr)
+			assert.Len(t, policyErr.Violations, tt.violations)
+		})
+	}
+}
+
+func TestServiceChange(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Password)
+	user := createUser(t, db, "First-pass1")
+
+	session := models.Session{UserID: user.ID, RefreshTokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, db.Create(&session).Error)
+
+	assert.ErrorIs(t, svc.Change(user.ID, "wrong", "Second-pass2"), ErrWrongPassword)
+	assert.ErrorIs(t, svc.Change(user.ID, "First-pass1", "First-pass1"), ErrPasswordReused)
+
+	var policyErr *PolicyError
+	assert.ErrorAs(t, svc.Change(user.ID, "First-pass1", "weak"), &policyErr)
+
+	assert.NoError(t, svc.Change(user.ID, "First-pass1", "Second-pass2"))
+
+	var saved models.User
+	assert.NoError(t, db.First(&saved, user.ID).Error)
+	assert.NoError(t, utils.CheckPassword(saved.Password, "Second-pass2"))
+	assert.False(t, saved.MustChangePassword)
+
+	// trocar a senha derruba as sessões
+	assert.NoError(t, db.First(&session, session.ID).Error)
+	assert.NotNil(t, session.RevokedAt)
+
+	// a senha anterior continua no histórico
+	assert.ErrorIs(t, svc.Change(user.ID, "Second-pass2", "First-pass1"), ErrPasswordReused)
+}
+
+func TestHistoryIsPruned(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Password)
+	user := createUser(t, db, "Pass-0000")
+
+	current := "Pass-0000"
+	for _, next := range []string{"Pass-1111", "Pass-2222", "Pass-3333", "Pass-4444", "Pass-5555", "Pass-6666"} {
+		assert.NoError(t, svc.Change(user.ID, current, next))
+		current = next
+	}
+
+	var count int64
+	assert.NoError(t, db.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).Count(&count).Error)
+	assert.Equal(t, int64(testPolicy.History), count)
+
+	// a mais antiga saiu do histórico e pode voltar
+	assert.NoError(t, svc.Change(user.ID, current, "Pass-0000"))
+}
+
+func TestServiceReset(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Password)
+	user := createUser(t, db, "First-pass1")
+
+	_, _, err := svc.IssueReset(9999, 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, first, err := svc.IssueReset(uint64(user.ID), 1)
+	assert.NoError(t, err)
+	reset, second, err := svc.IssueReset(uint64(user.ID), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), reset.IssuedByID)
+
+	// emitir um novo token invalida o anterior
+	assert.ErrorIs(t, svc.Reset(first, "Second-pass2"), ErrInvalidResetToken)
+	assert.ErrorIs(t, svc.Reset("unknown", "Second-pass2"), ErrInvalidResetToken)
+
+	var policyErr *PolicyError
+	assert.ErrorAs(t, svc.Reset(second, "weak"), &policyErr)
+
+	assert.NoError(t, svc.Reset(second, "Second-pass2"))
+	var saved models.User
+	assert.NoError(t, db.First(&saved, user.ID).Error)
+	assert.NoError(t, utils.CheckPassword(saved.Password, "Second-pass2"))
+	assert.False(t, saved.MustChangePassword)
+
+	// uso único
+	assert.ErrorIs(t, svc.Reset(second, "Third-pass3"), ErrInvalidResetToken)
+
+	// token vencido
+	_, expired, err := svc.IssueReset(uint64(user.ID), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(&models.PasswordResetToken{}).Where("used_at IS NULL").Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	assert.ErrorIs(t, svc.Reset(expired, "Third-pass3"), ErrInvalidResetToken)
+
+	// usuário desativado não recebe token
+	assert.NoError(t, db.Model(&saved).Update("deactivated_at", time.Now()).Error)
+	_, _, err = svc.IssueReset(uint64(user.ID), 1)
+	assert.ErrorIs(t, err, ErrUserInactive)
+}
@@ -0,0 +1,195 @@
+package permissions
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Defaults é a matriz gravada no banco na primeira execução
+var Defaults = map[enums.Role][]enums.Permission{
+	enums.Receptionist: {
+		enums.PermPacientCreate, enums.PermPacientRead, enums.PermPacientUpdate, enums.PermPacientDeactivate, enums.PermPacientReadAll,
+		enums.PermAppointmentCreate, enums.PermAppointmentRead, enums.PermAppointmentUpdate, enums.PermAppointmentStatus,
+		enums.PermDoctorRead, enums.PermScheduleRead, enums.PermScheduleWrite, enums.PermSlotRead,
+	},
+	enums.Doctor: {
+		enums.PermPacientRead, enums.PermPacientEmergency,
+		enums.PermAppointmentRead, enums.PermAppointmentStatus, enums.PermAgendaRead,
+		enums.PermDoctorRead, enums.PermScheduleRead,
+	},
+	enums.Admin: {
+		enums.PermPacientCreate, enums.PermPacientRead, enums.PermPacientUpdate, enums.PermPacientDeactivate, enums.PermPacientReadAll,
+		enums.PermAppointmentCreate, enums.PermAppointmentRead, enums.PermAppointmentUpdate, enums.PermAppointmentStatus,
+		enums.PermDoctorRead, enums.PermDoctorProfile, enums.PermSpecialtyManage,
+		enums.PermScheduleRead, enums.PermScheduleWrite, enums.PermSlotRead,
+		enums.PermUserCreate, enums.PermUserRead, enums.PermUserUpdate,
+		enums.PermSessionManage, enums.PermLockoutManage, enums.PermAuditRead, enums.PermPermissionRead,
+	},
+	// Triagem: vê qualquer paciente e acompanha a chegada às consultas
+	enums.Nurse: {
+		enums.PermPacientRead, enums.PermPacientReadAll,
+		enums.PermAppointmentRead, enums.PermAppointmentStatus,
+		enums.PermDoctorRead, enums.PermScheduleRead,
+	},
+	// Dispensação e exames: consultam o paciente e o médico solicitante
+	enums.Pharmacist: {
+		enums.PermPacientRead, enums.PermPacientReadAll,
+		enums.PermDoctorRead,
+	},
+	enums.LabTechnician: {
+		enums.PermPacientRead, enums.PermPacientReadAll,
+		enums.PermDoctorRead,
+	},
+}
+
+// Effective são as permissões que um usuário tem de fato: as do papel dele,
+// ou nenhuma se estiver desativado.
+type Effective struct {
+	UserID      uint               `json:"userId"`
+	Role        enums.Role         `json:"role"`
+	Active      bool               `json:"active"`
+	Permissions []enums.Permission `json:"permissions"`
+}
+
+// Service mantém a matriz papel → permissões em memória, para que a
+// checagem de cada requisição não vá ao banco. Alterações na tabela passam
+// a valer no próximo Load.
+type Service struct {
+	db *gorm.DB
+
+	mu     sync.RWMutex
+	matrix map[enums.Role][]enums.Permission
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, matrix: map[enums.Role][]enums.Permission{}}
+}
+
+// Load lê a matriz do banco. Cada concessão de Defaults é gravada uma única
+// vez (banco novo, ou concessão criada numa versão posterior) e fica marcada
+// em permission_seeds; depois disso a tabela é o que o administrador
+// configurou, e concessões revogadas não voltam.
+func (s *Service) Load() error {
+	if err := s.seed(); err != nil {
+		return err
+	}
+
+	var rows []models.RolePermission
+	if err := s.db.Order("role, permission").Find(&rows).Error; err != nil {
+		return fmt.Errorf("unable to load role permissions: %v", err)
+	}
+
+	matrix := map[enums.Role][]enums.Permission{}
+	for _, row := range rows {
+		if !slices.Contains(enums.Permissions, row.Permission) {
+			log.Printf("Permissão desconhecida %q para o papel %q ignorada", row.Permission, row.Role)
+			continue
+		}
+		matrix[row.Role] = append(matrix[row.Role], row.Permission)
+	}
+
+	s.mu.Lock()
+	s.matrix = matrix
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) seed() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var seeded []models.PermissionSeed
+		if err := tx.Find(&seeded).Error; err != nil {
+			return fmt.Errorf("unable to read permission seeds: %v", err)
+		}
+		done := map[models.RolePermission]bool{}
+		for _, seed := range seeded {
+			done[models.RolePermission{Role: seed.Role, Permission: seed.Permission}] = true
+		}
+
+		granted, err := legacyGrants(tx, len(seeded) == 0)
+		if err != nil {
+			return err
+		}
+
+		var rows []models.RolePermission
+		var seeds []models.PermissionSeed
+		for role, permissions := range Defaults {
+			for _, permission := range permissions {
+				key := models.RolePermission{Role: role, Permission: permission}
+				if done[key] {
+					continue
+				}
+				if !granted(role, permission) {
+					rows = append(rows, key)
+				}
+				seeds = append(seeds, models.PermissionSeed{Role: role, Permission: permission})
+			}
+		}
+
+		if len(rows) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+				return fmt.Errorf("unable to seed role permissions: %v", err)
+			}
+		}
+		if len(seeds) > 0 {
+			if err := tx.Create(&seeds).Error; err != nil {
+				return fmt.Errorf("unable to record permission seeds: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// legacyGrants cobre bancos preenchidos antes de permission_seeds existir:
+// ali, papéis e permissões que já aparecem em alguma linha contam como
+// semeados, como a versão anterior supunha, e só o que falta é concedido.
+func legacyGrants(tx *gorm.DB, unmarked bool) (func(enums.Role, enums.Permission) bool, error) {
+	if !unmarked {
+		return func(enums.Role, enums.Permission) bool { return false }, nil
+	}
+
+	var knownPermissions []enums.Permission
+	if err := tx.Model(&models.RolePermission{}).Distinct("permission").Pluck("permission", &knownPermissions).Error; err != nil {
+		return nil, fmt.Errorf("unable to read role permissions: %v", err)
+	}
+	var knownRoles []enums.Role
+	if err := tx.Model(&models.RolePermission{}).Distinct("role").Pluck("role", &knownRoles).Error; err != nil {
+		return nil, fmt.Errorf("unable to read role permissions: %v", err)
+	}
+
+	return func(role enums.Role, permission enums.Permission) bool {
+		return slices.Contains(knownRoles, role) && slices.Contains(knownPermissions, permission)
+	}, nil
+}
+
+func (s *Service) Allowed(role enums.Role, permission enums.Permission) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Contains(s.matrix[role], permission)
+}
+
+// ForRole devolve uma cópia das permissões do papel
+func (s *Service) ForRole(role enums.Role) []enums.Permission {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.matrix[role])
+}
+
+func (s *Service) Effective(userID uint64) (*Effective, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	effective := &Effective{UserID: user.ID, Role: user.Role, Active: user.IsActive(), Permissions: []enums.Permission{}}
+	if user.IsActive() {
+		effective.Permissions = append(effective.Permissions, s.ForRole(user.Role)...)
+	}
+	return effective, nil
+}
@@ -0,0 +1,8 @@
+package permissions
+
+import "github.com/andresidrim/cesupa-hospital/enums"
+
+type PermissionService interface {
+	Allowed(role enums.Role, permission enums.Permission) bool
+	Effective(userID uint64) (*Effective, error)
+}
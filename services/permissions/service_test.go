@@ -0,0 +1,134 @@
+package permissions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.User{}, &models.RolePermission{}, &models.PermissionSeed{})
+}
+
+func TestServiceLoad(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+
+	// nada carregado: nenhum papel tem permissão
+	assert.False(t, svc.Allowed(enums.Admin, enums.PermPacientRead))
+
+	assert.NoError(t, svc.Load())
+
+	tests := []struct {
+		name       string
+		role       enums.Role
+		permission enums.Permission
+		want       bool
+	}{
+		{name: "admin reads pacients", role: enums.Admin, permission: enums.PermPacientRead, want: true},
+		{name: "doctor lists doctors", role: enums.Doctor, permission: enums.PermDoctorRead, want: true},
+		{name: "doctor cannot create pacients", role: enums.Doctor, permission: enums.PermPacientCreate, want: false},
+		{name: "receptionist cannot read audit", role: enums.Receptionist, permission: enums.PermAuditRead, want: false},
+		{name: "nurse updates appointment status", role: enums.Nurse, permission: enums.PermAppointmentStatus, want: true},
+		{name: "pharmacist cannot schedule", role: enums.Pharmacist, permission: enums.PermAppointmentCreate, want: false},
+		{name: "lab technician reads any pacient", role: enums.LabTechnician, permission: enums.PermPacientReadAll, want: true},
+		{name: "unknown role", role: "janitor", permission: enums.PermPacientRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, svc.Allowed(tt.role, tt.permission))
+		})
+	}
+
+	// a tabela já preenchida não é sobrescrita: ajustes valem no próximo Load
+	assert.NoError(t, db.Where("role = ? AND permission = ?", enums.Doctor, enums.PermDoctorRead).Delete(&models.RolePermission{}).Error)
+	assert.NoError(t, db.Create(&models.RolePermission{Role: enums.Doctor, Permission: "pacient:fly"}).Error)
+	assert.True(t, svc.Allowed(enums.Doctor, enums.PermDoctorRead))
+
+	assert.NoError(t, svc.Load())
+	assert.False(t, svc.Allowed(enums.Doctor, enums.PermDoctorRead))
+	assert.NotContains(t, svc.ForRole(enums.Doctor), enums.Permission("pacient:fly"))
+	assert.ElementsMatch(t, Defaults[enums.Admin], svc.ForRole(enums.Admin))
+
+	// revogações não voltam, nem quando nenhum papel fica com a permissão
+	assert.NoError(t, db.Where("permission = ?", enums.PermPacientEmergency).Delete(&models.RolePermission{}).Error)
+	assert.NoError(t, db.Where("role = ?", enums.Nurse).Delete(&models.RolePermission{}).Error)
+	assert.NoError(t, svc.Load())
+	assert.False(t, svc.Allowed(enums.Doctor, enums.PermPacientEmergency))
+	assert.Empty(t, svc.ForRole(enums.Nurse))
+
+	// concessão padrão criada numa versão nova é gravada uma vez
+	assert.NoError(t, db.Where("role = ? AND permission = ?", enums.Doctor, enums.PermPacientEmergency).Delete(&models.PermissionSeed{}).Error)
+	assert.NoError(t, svc.Load())
+	assert.True(t, svc.Allowed(enums.Doctor, enums.PermPacientEmergency))
+	assert.False(t, svc.Allowed(enums.Receptionist, enums.PermPacientEmergency))
+	assert.Empty(t, svc.ForRole(enums.Nurse))
+}
+
+func TestServiceLoadAdoptsUnmarkedMatrix(t *testing.T) {
+	db := setupTestDB(t)
+
+	// matriz gravada antes de permission_seeds: o médico teve doctor:read
+	// revogado, e a enfermagem ainda não existia
+	for role, permissions := range Defaults {
+		if role == enums.Nurse {
+			continue
+		}
+		for _, permission := range permissions {
+			if role == enums.Doctor && permission == enums.PermDoctorRead {
+				continue
+			}
+			assert.NoError(t, db.Create(&models.RolePermission{Role: role, Permission: permission}).Error)
+		}
+	}
+
+	svc := NewService(db)
+	assert.NoError(t, svc.Load())
+	assert.False(t, svc.Allowed(enums.Doctor, enums.PermDoctorRead))
+	assert.ElementsMatch(t, Defaults[enums.Nurse], svc.ForRole(enums.Nurse))
+
+	var seeds int64
+	assert.NoError(t, db.Model(&models.PermissionSeed{}).Count(&seeds).Error)
+	var defaults int
+	for _, permissions := range Defaults {
+		defaults += len(permissions)
+	}
+	assert.EqualValues(t, defaults, seeds)
+
+	// a partir daqui vale o que está marcado
+	assert.NoError(t, db.Where("role = ?", enums.Nurse).Delete(&models.RolePermission{}).Error)
+	assert.NoError(t, svc.Load())
+	assert.Empty(t, svc.ForRole(enums.Nurse))
+}
+
+func TestServiceEffective(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+	assert.NoError(t, svc.Load())
+
+	deactivatedAt := time.Now()
+	doctor := models.User{Name: "Bob", CPF: "12345678902", Password: "x", Role: enums.Doctor}
+	inactive := models.User{Name: "Carol", CPF: "12345678903", Password: "x", Role: enums.Admin, DeactivatedAt: &deactivatedAt}
+	assert.NoError(t, db.Create(&doctor).Error)
+	assert.NoError(t, db.Create(&inactive).Error)
+
+	effective, err := svc.Effective(uint64(doctor.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, enums.Doctor, effective.Role)
+	assert.True(t, effective.Active)
+	assert.ElementsMatch(t, Defaults[enums.Doctor], effective.Permissions)
+
+	effective, err = svc.Effective(uint64(inactive.ID))
+	assert.NoError(t, err)
+	assert.False(t, effective.Active)
+	assert.Empty(t, effective.Permissions)
+
+	_, err = svc.Effective(9999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
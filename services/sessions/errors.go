@@ -0,0 +1,11 @@
+package sessions
+
+import "errors"
+
+var (
+	ErrInvalidToken   = errors.New("invalid refresh token")
+	ErrTokenReused    = errors.New("refresh token was already used; session revoked")
+	ErrSessionRevoked = errors.New("session was revoked")
+	ErrSessionExpired = errors.New("session expired")
+	ErrUserInactive   = errors.New("user is inactive")
+)
@@ -0,0 +1,207 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"gorm.io/gorm"
+)
+
+type Service struct {
+	db         *gorm.DB
+	refreshTTL time.Duration
+}
+
+func NewService(db *gorm.DB, cfg config.Session) *Service {
+	return &Service{db: db, refreshTTL: cfg.RefreshTokenTTL}
+}
+
+// Create abre uma sessão e devolve o refresh token em texto puro, que só
+// existe nesse momento.
+func (s *Service) Create(userID uint, userAgent, ip string) (*models.Session, string, error) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	session := models.Session{
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(token),
+		UserAgent:        userAgent,
+		IP:               ip,
+		ExpiresAt:        now.Add(s.refreshTTL),
+		LastUsedAt:       now,
+	}
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, "", fmt.Errorf("unable to create session: %v", err)
+	}
+
+	return &session, token, nil
+}
+
+// Rotate troca o refresh token por um novo e renova a validade da sessão.
+// Apresentar um token já trocado indica vazamento: a sessão é revogada. O dono
+// da sessão é conferido antes da troca, para que um usuário desativado não
+// ganhe um token novo.
+func (s *Service) Rotate(refreshToken string) (*models.Session, string, error) {
+	hash := utils.HashToken(refreshToken)
+
+	var session models.Session
+	var token string
+	reused := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if session.RevokedAt != nil {
+			return ErrSessionRevoked
+		}
+		if !session.IsActive(now) {
+			return ErrSessionExpired
+		}
+
+		var owner models.User
+		err := tx.Select("id", "deactivated_at").First(&owner, session.UserID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionRevoked
+		}
+		if err != nil {
+			return err
+		}
+		if !owner.IsActive() {
+			return ErrUserInactive
+		}
+
+		token, err = utils.GenerateOpaqueToken()
+		if err != nil {
+			return err
+		}
+
+		// A troca só vale se o token ainda for o atual: dois refreshes
+		// simultâneos com o mesmo token não geram duas continuações da sessão
+		result := tx.Model(&session).Where("refresh_token_hash = ?", hash).Updates(map[string]any{
+			"refresh_token_hash":  utils.HashToken(token),
+			"previous_token_hash": hash,
+			"expires_at":          now.Add(s.refreshTTL),
+			"last_used_at":        now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return tx.Model(&models.Session{}).Where("id = ?", session.ID).Update("revoked_at", now).Error
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", s.reuse(hash)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if reused {
+		return nil, "", ErrTokenReused
+	}
+
+	return &session, token, nil
+}
+
+// Validate confere, numa única consulta, se a sessão do access token ainda
+// vale e se o usuário dono dela continua ativo.
+func (s *Service) Validate(sessionID, userID uint) error {
+	var row struct {
+		models.Session
+		UserDeactivatedAt *time.Time
+	}
+	err := s.db.Model(&models.Session{}).
+		Select("sessions.*, users.deactivated_at AS user_deactivated_at").
+		Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL").
+		Where("sessions.id = ? AND sessions.user_id = ?", sessionID, userID).
+		Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+
+	if row.UserDeactivatedAt != nil {
+		return ErrUserInactive
+	}
+	if row.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+	if !row.IsActive(time.Now()) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// GetByUser lista as sessões ainda válidas do usuário, da mais recente para a mais antiga
+func (s *Service) GetByUser(userID uint64) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (s *Service) Revoke(userID, id uint64) error {
+	result := s.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return fmt.Errorf("unable to revoke session: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *Service) RevokeAll(userID uint64) error {
+	return RevokeAll(s.db, uint(userID))
+}
+
+// RevokeAll encerra todas as sessões do usuário. É exportada para ser usada
+// dentro de transações de outros serviços (ex.: ao desativar um usuário).
+func RevokeAll(tx *gorm.DB, userID uint) error {
+	err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now().UTC()).Error
+	if err != nil {
+		return fmt.Errorf("unable to revoke sessions: %v", err)
+	}
+	return nil
+}
+
+// reuse trata um token que não é o atual de nenhuma sessão: se for o anterior
+// de alguma, a sessão é revogada; senão o token é simplesmente inválido.
+func (s *Service) reuse(hash string) error {
+	var session models.Session
+	err := s.db.Where("previous_token_hash = ?", hash).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Model(&session).Update("revoked_at", time.Now().UTC()).Error; err != nil {
+		return err
+	}
+	return ErrTokenReused
+}
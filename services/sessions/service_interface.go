@@ -0,0 +1,12 @@
+package sessions
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type SessionService interface {
+	Create(userID uint, userAgent, ip string) (*models.Session, string, error)
+	Rotate(refreshToken string) (*models.Session, string, error)
+	Validate(sessionID, userID uint) error
+	GetByUser(userID uint64) ([]models.Session, error)
+	Revoke(userID, id uint64) error
+	RevokeAll(userID uint64) error
+}
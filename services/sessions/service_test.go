@@ -0,0 +1,184 @@
+package sessions
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t, &models.User{}, &models.Session{})
+
+	// donos das sessões: Validate confere o usuário junto com a sessão
+	for _, document := range []cpf.CPF{"12345678901", "12345678902"} {
+		assert.NoError(t, db.Create(&models.User{Name: "User", CPF: document, Password: "x", Role: enums.Doctor}).Error)
+	}
+	return db
+}
+
+func TestServiceRotate(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Session)
+
+	session, first, err := svc.Create(1, "agent", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, first)
+
+	var stored models.Session
+	assert.NoError(t, db.First(&stored, session.ID).Error)
+	assert.NotEqual(t, first, stored.RefreshTokenHash, "only the hash is stored")
+
+	rotated, second, err := svc.Rotate(first)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, rotated.ID)
+	assert.NotEqual(t, first, second)
+
+	_, _, err = svc.Rotate("unknown")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// reuso do token anterior revoga a sessão inteira
+	_, _, err = svc.Rotate(first)
+	assert.ErrorIs(t, err, ErrTokenReused)
+	_, _, err = svc.Rotate(second)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+	assert.ErrorIs(t, svc.Validate(session.ID, 1), ErrSessionRevoked)
+}
+
+func TestServiceRotateExpired(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Session)
+
+	session, token, err := svc.Create(1, "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(session).Update("expires_at", time.Now().Add(-time.Minute).UTC()).Error)
+
+	_, _, err = svc.Rotate(token)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	assert.ErrorIs(t, svc.Validate(session.ID, 1), ErrSessionExpired)
+}
+
+// TestServiceRotateConcurrent confere que refreshes simultâneos com o mesmo
+// token não abrem duas continuações: só um troca, os outros revogam a sessão
+func TestServiceRotateConcurrent(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Session)
+
+	session, token, err := svc.Create(1, "", "")
+	assert.NoError(t, err)
+
+	errs := make([]error, 4)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, errs[i] = svc.Rotate(token)
+		}()
+	}
+	wg.Wait()
+
+	rotated := 0
+	for _, err := range errs {
+		if err == nil {
+			rotated++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrTokenReused)
+	}
+	assert.Equal(t, 1, rotated)
+	assert.ErrorIs(t, svc.Validate(session.ID, 1), ErrSessionRevoked)
+}
+
+func TestServiceRotateInactiveUser(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Session)
+
+	session, token, err := svc.Create(1, "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(&models.User{}).Where("id = ?", 1).Update("deactivated_at", time.Now().UTC()).Error)
+
+	_, _, err = svc.Rotate(token)
+	assert.ErrorIs(t, err, ErrUserInactive)
+
+	// o token não é trocado
+	var stored models.Session
+	assert.NoError(t, db.First(&stored, session.ID).Error)
+	assert.Equal(t, session.RefreshTokenHash, stored.RefreshTokenHash)
+}
+
+func TestServiceValidate(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Session)
+
+	session, _, err := svc.Create(1, "", "")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		sessionID  uint
+		userID     uint
+		deactivate bool
+		wantErr    error
+	}{
+		{name: "valid", sessionID: session.ID, userID: 1},
+		{name: "other user", sessionID: session.ID, userID: 2, wantErr: ErrSessionRevoked},
+		{name: "unknown session", sessionID: 999, userID: 1, wantErr: ErrSessionRevoked},
+		{name: "inactive user", sessionID: session.ID, userID: 1, deactivate: true, wantErr: ErrUserInactive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.deactivate {
+				assert.NoError(t, db.Model(&models.User{}).Where("id = ?", tt.userID).Update("deactivated_at", time.Now()).Error)
+			}
+
+			err := svc.Validate(tt.sessionID, tt.userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestServiceRevoke(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Session)
+
+	a, _, err := svc.Create(1, "", "")
+	assert.NoError(t, err)
+	b, _, err := svc.Create(1, "", "")
+	assert.NoError(t, err)
+	other, _, err := svc.Create(2, "", "")
+	assert.NoError(t, err)
+
+	sessions, err := svc.GetByUser(1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	// sessão de outro usuário não é revogada pela rota do usuário 1
+	assert.ErrorIs(t, svc.Revoke(1, uint64(other.ID)), gorm.ErrRecordNotFound)
+
+	assert.NoError(t, svc.Revoke(1, uint64(a.ID)))
+	assert.ErrorIs(t, svc.Revoke(1, uint64(a.ID)), gorm.ErrRecordNotFound)
+
+	sessions, err = svc.GetByUser(1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, b.ID, sessions[0].ID)
+
+	assert.NoError(t, svc.RevokeAll(1))
+	sessions, err = svc.GetByUser(1)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+	assert.NoError(t, svc.Validate(other.ID, 2))
+}
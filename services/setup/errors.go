@@ -0,0 +1,8 @@
+package setup
+
+import "errors"
+
+var (
+	ErrSetupClosed       = errors.New("setup is not available: the first admin already exists")
+	ErrInvalidSetupToken = errors.New("invalid setup token")
+)
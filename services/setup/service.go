@@ -0,0 +1,115 @@
+package setup
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"gorm.io/gorm"
+)
+
+// Service cuida da criação do primeiro admin em um banco sem usuários. O
+// token de setup é gerado ao iniciar a API, mostrado só no log do servidor
+// e guardado apenas como hash; depois do primeiro admin ele deixa de valer.
+type Service struct {
+	db        *gorm.DB
+	policy    passwords.Policy
+	mu        sync.Mutex
+	tokenHash string
+}
+
+func NewService(db *gorm.DB, cfg config.Password) *Service {
+	return &Service{db: db, policy: passwords.NewPolicy(cfg)}
+}
+
+// Start gera o token de setup se ainda não houver nenhum usuário (nem
+// removido). Devolve "" quando o setup não é necessário.
+func (s *Service) Start() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	empty, err := noUsers(s.db)
+	if err != nil || !empty {
+		s.tokenHash = ""
+		return "", err
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	s.tokenHash = utils.HashToken(token)
+	return token, nil
+}
+
+// Available informa se o setup ainda aceita criar o primeiro admin
+func (s *Service) Available() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tokenHash == "" {
+		return false, nil
+	}
+	return noUsers(s.db)
+}
+
+// CreateAdmin confere o token e cria o admin com a senha escolhida por ele,
+// validada pela política. Só funciona enquanto não houver usuários; o lock
+// impede que duas requisições simultâneas criem dois admins.
+func (s *Service) CreateAdmin(token string, admin *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tokenHash == "" {
+		return ErrSetupClosed
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(token)), []byte(s.tokenHash)) != 1 {
+		return ErrInvalidSetupToken
+	}
+
+	hashed, err := s.policy.Hash(admin.Password)
+	if err != nil {
+		return err
+	}
+	admin.Password = hashed
+	admin.Role = enums.Admin
+	admin.MustChangePassword = false
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		empty, err := noUsers(tx)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return ErrSetupClosed
+		}
+
+		if err := tx.Create(admin).Error; err != nil {
+			return fmt.Errorf("unable to create admin: %v", err)
+		}
+		return s.policy.Remember(tx, admin.ID, hashed)
+	})
+	if err != nil {
+		if errors.Is(err, ErrSetupClosed) {
+			s.tokenHash = ""
+		}
+		return err
+	}
+
+	s.tokenHash = ""
+	return nil
+}
+
+func noUsers(db *gorm.DB) (bool, error) {
+	var count int64
+	if err := db.Model(&models.User{}).Unscoped().Count(&count).Error; err != nil {
+		return false, fmt.Errorf("unable to count users: %v", err)
+	}
+	return count == 0, nil
+}
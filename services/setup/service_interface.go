@@ -0,0 +1,8 @@
+package setup
+
+import "github.com/andresidrim/cesupa-hospital/models"
+
+type SetupService interface {
+	Available() (bool, error)
+	CreateAdmin(token string, admin *models.User) error
+}
@@ -0,0 +1,107 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.User{}, &models.PasswordHistory{})
+}
+
+func newAdmin(password string) *models.User {
+	return &models.User{Name: "Alice", CPF: "52998224725", Password: password, Role: enums.Receptionist}
+}
+
+func TestServiceCreateAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Password)
+
+	available, err := svc.Available()
+	assert.NoError(t, err)
+	assert.False(t, available, "closed until Start")
+	assert.ErrorIs(t, svc.CreateAdmin("", newAdmin("Admin-secret1")), ErrSetupClosed)
+
+	token, err := svc.Start()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	available, err = svc.Available()
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		check    func(t *testing.T, err error)
+	}{
+		{name: "wrong token", token: "guess", password: "Admin-secret1", check: func(t *testing.T, err error) {
+			assert.ErrorIs(t, err, ErrInvalidSetupToken)
+		}},
+		{name: "weak password", token: token, password: "short", check: func(t *testing.T, err error) {
+			var policy *passwords.PolicyError
+			assert.ErrorAs(t, err, &policy)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, svc.CreateAdmin(tt.token, newAdmin(tt.password)))
+		})
+	}
+
+	admin := newAdmin("Admin-secret1")
+	assert.NoError(t, svc.CreateAdmin(token, admin))
+	assert.Equal(t, enums.Admin, admin.Role)
+	assert.False(t, admin.MustChangePassword)
+
+	var saved models.User
+	assert.NoError(t, db.First(&saved, admin.ID).Error)
+	assert.NoError(t, utils.CheckPassword(saved.Password, "Admin-secret1"))
+
+	var history int64
+	assert.NoError(t, db.Model(&models.PasswordHistory{}).Where("user_id = ?", admin.ID).Count(&history).Error)
+	assert.Equal(t, int64(1), history)
+
+	// o token só vale uma vez
+	other := newAdmin("Admin-secret1")
+	other.CPF = "11144477735"
+	assert.ErrorIs(t, svc.CreateAdmin(token, other), ErrSetupClosed)
+	available, err = svc.Available()
+	assert.NoError(t, err)
+	assert.False(t, available)
+}
+
+func TestServiceStartWithUsers(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.Create(&models.User{Name: "Bob", CPF: "11144477735", Password: "x", Role: enums.Admin}).Error)
+
+	svc := NewService(db, config.Default().Password)
+	token, err := svc.Start()
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+	assert.ErrorIs(t, svc.CreateAdmin("", newAdmin("Admin-secret1")), ErrSetupClosed)
+}
+
+func TestServiceClosesWhenUsersAppear(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().Password)
+	token, err := svc.Start()
+	assert.NoError(t, err)
+
+	// alguém criou um usuário por fora (ex.: cmd/seed) depois do Start
+	assert.NoError(t, db.Create(&models.User{Name: "Bob", CPF: "11144477735", Password: "x", Role: enums.Admin}).Error)
+
+	available, err := svc.Available()
+	assert.NoError(t, err)
+	assert.False(t, available)
+	assert.ErrorIs(t, svc.CreateAdmin(token, newAdmin("Admin-secret1")), ErrSetupClosed)
+}
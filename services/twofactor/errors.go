@@ -0,0 +1,11 @@
+package twofactor
+
+import "errors"
+
+var (
+	ErrInvalidCode    = errors.New("invalid two-factor code")
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrSetupRequired  = errors.New("two-factor setup was not started")
+	ErrRequiredByRole = errors.New("two-factor authentication is mandatory for this role")
+)
@@ -0,0 +1,282 @@
+package twofactor
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/sessions"
+	"github.com/andresidrim/cesupa-hospital/totp"
+	"github.com/andresidrim/cesupa-hospital/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// skew aceita o código do passo anterior e do seguinte
+	skew = 1
+
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Enrollment é o segredo gerado no início do cadastro, com a URI otpauth://
+// que o cliente transforma em QR code.
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+type Service struct {
+	db     *gorm.DB
+	config config.TOTP
+}
+
+func NewService(db *gorm.DB, cfg config.TOTP) *Service {
+	return &Service{db: db, config: cfg}
+}
+
+// Required informa se o papel não pode usar o sistema sem segundo fator
+func Required(cfg config.TOTP, role enums.Role) bool {
+	return slices.Contains(cfg.RequiredRoles, role)
+}
+
+// Setup gera um novo segredo para o usuário. Ele só passa a valer no login
+// depois de confirmado em Enable; chamar de novo troca o segredo pendente.
+func (s *Service) Setup(userID uint) (*Enrollment, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.HasTOTP() {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&user).Update("totp_secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("unable to save totp secret: %v", err)
+	}
+
+	return &Enrollment{Secret: secret, URI: totp.ProvisioningURI(secret, s.config.Issuer, user.Name)}, nil
+}
+
+// Enable confirma o cadastro com um código do aplicativo e devolve os
+// códigos de recuperação, que só existem em texto puro nesse momento. As
+// sessões abertas sem o segundo fator são encerradas.
+func (s *Service) Enable(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.HasTOTP() {
+			return ErrAlreadyEnabled
+		}
+		if user.TOTPSecret == nil {
+			return ErrSetupRequired
+		}
+
+		step, ok := totp.Validate(*user.TOTPSecret, normalize(code), time.Now(), skew)
+		if !ok {
+			return ErrInvalidCode
+		}
+
+		err := tx.Model(&user).Updates(map[string]any{
+			"totp_enabled_at": time.Now().UTC(),
+			"totp_last_step":  step,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("unable to enable totp: %v", err)
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		return sessions.RevokeAll(tx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable desliga o segundo fator mediante um código válido. Papéis com
+// TOTP obrigatório não podem desligar.
+func (s *Service) Disable(userID uint, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if Required(s.config, user.Role) {
+			return ErrRequiredByRole
+		}
+		if err := Verify(tx, &user, code); err != nil {
+			return err
+		}
+
+		return disable(tx, user.ID)
+	})
+}
+
+// RegenerateRecoveryCodes troca todos os códigos de recuperação, inclusive
+// os não usados, mediante um código válido.
+func (s *Service) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := Verify(tx, &user, code); err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Reset é usado pelo admin quando o usuário perde o aplicativo e os códigos
+// de recuperação: remove o segundo fator e encerra as sessões. Se o papel
+// exigir TOTP, o usuário cadastra um novo no próximo acesso.
+func (s *Service) Reset(userID uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.TOTPSecret == nil {
+			return ErrNotEnabled
+		}
+
+		if err := disable(tx, user.ID); err != nil {
+			return err
+		}
+		return sessions.RevokeAll(tx, user.ID)
+	})
+}
+
+// Verify confere o segundo fator do usuário: um código do aplicativo, que
+// não pode ser reaproveitado, ou um código de recuperação, que é consumido.
+func Verify(tx *gorm.DB, user *models.User, code string) error {
+	if !user.HasTOTP() {
+		return ErrNotEnabled
+	}
+
+	code = normalize(code)
+	if isTOTPCode(code) {
+		step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), skew)
+		if !ok || step <= user.TOTPLastStep {
+			return ErrInvalidCode
+		}
+
+		// a condição no UPDATE impede que duas requisições aceitem o mesmo código
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidCode
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(code)).
+		Update("used_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func disable(tx *gorm.DB, userID uint) error {
+	err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"totp_secret":     nil,
+		"totp_enabled_at": nil,
+		"totp_last_step":  0,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("unable to disable totp: %v", err)
+	}
+
+	return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("unable to remove recovery codes: %v", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(normalize(code))})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("unable to save recovery codes: %v", err)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode gera um código no formato xxxxx-xxxxx, sem letras e
+// dígitos que se confundem (0/o, 1/l/i)
+func generateRecoveryCode() (string, error) {
+	size := big.NewInt(int64(len(recoveryCodeAlphabet)))
+
+	var b strings.Builder
+	for i := range recoveryCodeLength {
+		if i == recoveryCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+func normalize(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	return !strings.ContainsFunc(code, func(r rune) bool { return r < '0' || r > '9' })
+}
@@ -0,0 +1,9 @@
+package twofactor
+
+type TwoFactorService interface {
+	Setup(userID uint) (*Enrollment, error)
+	Enable(userID uint, code string) ([]string, error)
+	Disable(userID uint, code string) error
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	Reset(userID uint64) error
+}
@@ -0,0 +1,149 @@
+package twofactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/totp"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &models.User{}, &models.Session{}, &models.RecoveryCode{})
+}
+
+func createUser(t *testing.T, db *gorm.DB, role enums.Role) models.User {
+	user := models.User{Name: "Alice", CPF: "52998224725", Password: "x", Role: role}
+	assert.NoError(t, db.Create(&user).Error)
+	return user
+}
+
+func TestServiceEnable(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().TOTP)
+	user := createUser(t, db, enums.Doctor)
+
+	session := models.Session{UserID: user.ID, RefreshTokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, db.Create(&session).Error)
+
+	_, err := svc.Enable(user.ID, "123456")
+	assert.ErrorIs(t, err, ErrSetupRequired)
+
+	enrollment, err := svc.Setup(user.ID)
+	assert.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	_, err = svc.Enable(user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	assert.NoError(t, err)
+	codes, err := svc.Enable(user.ID, code)
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	var saved models.User
+	assert.NoError(t, db.First(&saved, user.ID).Error)
+	assert.True(t, saved.HasTOTP())
+
+	// sessões abertas antes do segundo fator são encerradas
+	assert.NoError(t, db.First(&session, session.ID).Error)
+	assert.NotNil(t, session.RevokedAt)
+
+	_, err = svc.Setup(user.ID)
+	assert.ErrorIs(t, err, ErrAlreadyEnabled)
+}
+
+func TestVerify(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().TOTP)
+	user := createUser(t, db, enums.Doctor)
+
+	assert.ErrorIs(t, Verify(db, &user, "123456"), ErrNotEnabled)
+
+	enrollment, err := svc.Setup(user.ID)
+	assert.NoError(t, err)
+	code, _ := totp.Code(enrollment.Secret, time.Now())
+	codes, err := svc.Enable(user.ID, code)
+	assert.NoError(t, err)
+	assert.NoError(t, db.First(&user, user.ID).Error)
+
+	next, _ := totp.Code(enrollment.Secret, time.Now().Add(totp.Period))
+
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{name: "wrong code", code: "000000", want: ErrInvalidCode},
+		{name: "code already used at enrollment", code: code, want: ErrInvalidCode},
+		{name: "next step", code: next, want: nil},
+		{name: "replayed code", code: next, want: ErrInvalidCode},
+		{name: "recovery code", code: codes[0], want: nil},
+		{name: "recovery code without dash, upper case", code: "  " + codes[1][:5] + " " + codes[1][6:] + " ", want: nil},
+		{name: "used recovery code", code: codes[0], want: ErrInvalidCode},
+		{name: "unknown recovery code", code: "aaaaa-bbbbb", want: ErrInvalidCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(db, &user, tt.code)
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+
+	// códigos novos invalidam os antigos
+	regenerated, err := svc.RegenerateRecoveryCodes(user.ID, codes[2])
+	assert.NoError(t, err)
+	assert.ErrorIs(t, Verify(db, &user, codes[3]), ErrInvalidCode)
+	assert.NoError(t, Verify(db, &user, regenerated[0]))
+}
+
+func TestServiceDisableAndReset(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db, config.Default().TOTP)
+
+	enable := func(user models.User) []string {
+		enrollment, err := svc.Setup(user.ID)
+		assert.NoError(t, err)
+		code, _ := totp.Code(enrollment.Secret, time.Now())
+		codes, err := svc.Enable(user.ID, code)
+		assert.NoError(t, err)
+		return codes
+	}
+
+	doctor := createUser(t, db, enums.Doctor)
+	codes := enable(doctor)
+	assert.ErrorIs(t, svc.Disable(doctor.ID, "aaaaa-bbbbb"), ErrInvalidCode)
+	assert.NoError(t, svc.Disable(doctor.ID, codes[0]))
+
+	var saved models.User
+	assert.NoError(t, db.First(&saved, doctor.ID).Error)
+	assert.False(t, saved.HasTOTP())
+	assert.Nil(t, saved.TOTPSecret)
+	var remaining int64
+	db.Model(&models.RecoveryCode{}).Where("user_id = ?", doctor.ID).Count(&remaining)
+	assert.Zero(t, remaining)
+
+	// admin tem TOTP obrigatório: só outro admin remove
+	admin := models.User{Name: "Bob", CPF: "39053344705", Password: "x", Role: enums.Admin}
+	assert.NoError(t, db.Create(&admin).Error)
+	codes = enable(admin)
+	assert.ErrorIs(t, svc.Disable(admin.ID, codes[0]), ErrRequiredByRole)
+
+	assert.NoError(t, svc.Reset(uint64(admin.ID)))
+	var reset models.User
+	assert.NoError(t, db.First(&reset, admin.ID).Error)
+	assert.False(t, reset.HasTOTP())
+	assert.ErrorIs(t, svc.Reset(uint64(admin.ID)), ErrNotEnabled)
+	assert.ErrorIs(t, svc.Reset(9999), gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,9 @@
+package users
+
+import "errors"
+
+var (
+	ErrLastAdmin    = errors.New("the last active admin cannot be demoted or deactivated")
+	ErrDuplicateCPF = errors.New("cpf already belongs to another user")
+	ErrInactive     = errors.New("user is already inactive")
+)
@@ -1,22 +1,43 @@
 package users
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/services/sessions"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
-	db *gorm.DB
+	db     *gorm.DB
+	policy passwords.Policy
+}
+
+func NewService(db *gorm.DB, cfg config.Password) *Service {
+	return &Service{db: db, policy: passwords.NewPolicy(cfg)}
 }
 
-func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+// UserUpdate lista as alterações de um usuário; campos nil não mudam.
+// Password recebe a senha em texto puro e é gravada com hash.
+type UserUpdate struct {
+	Name     *string
+	CPF      *cpf.CPF
+	Role     *enums.Role
+	Password *string
+	Active   *bool
 }
 
 func (s *Service) Get(id uint64) (*models.User, error) {
 	var user models.User
-	if err := s.db.Preload("Appointments").First(&user, id).Error; err != nil {
+	if err := s.db.Preload("Appointments").Preload("DoctorProfile.Specialties").First(&user, id).Error; err != nil {
 		return nil, err
 	}
 
@@ -37,3 +58,143 @@ func (s *Service) GetAll(filterRoles []enums.Role) ([]models.User, error) {
 
 	return users, nil
 }
+
+// GetDoctors lista os médicos ativos com o perfil profissional, em ordem de
+// nome. specialty, se informada, filtra pelo nome da especialidade, sem
+// diferenciar maiúsculas.
+func (s *Service) GetDoctors(specialty string) ([]models.User, error) {
+	query := s.db.Model(&models.User{}).
+		Preload("Appointments").
+		Preload("DoctorProfile.Specialties").
+		Where("role = ? AND deactivated_at IS NULL", enums.Doctor)
+
+	if specialty != "" {
+		withSpecialty := s.db.Table("doctor_profiles").
+			Select("1").
+			Joins("JOIN doctor_specialties ON doctor_specialties.doctor_profile_id = doctor_profiles.id").
+			Joins("JOIN specialties ON specialties.id = doctor_specialties.specialty_id").
+			Where("doctor_profiles.user_id = users.id AND LOWER(specialties.name) = LOWER(?)", strings.TrimSpace(specialty))
+		query = query.Where("EXISTS (?)", withSpecialty)
+	}
+
+	var doctors []models.User
+	if err := query.Order("name").Find(&doctors).Error; err != nil {
+		return nil, err
+	}
+
+	return doctors, nil
+}
+
+func (s *Service) Update(id uint64, changes UserUpdate) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+
+		previousRole := user.Role
+		updates := map[string]any{}
+		if changes.Name != nil {
+			updates["name"] = *changes.Name
+		}
+		if changes.CPF != nil {
+			if err := ensureUniqueCPF(tx, *changes.CPF, user.ID); err != nil {
+				return err
+			}
+			updates["cpf"] = *changes.CPF
+		}
+		if changes.Role != nil {
+			updates["role"] = *changes.Role
+		}
+		if changes.Active != nil && *changes.Active != user.IsActive() {
+			if *changes.Active {
+				updates["deactivated_at"] = nil
+			} else {
+				updates["deactivated_at"] = time.Now().UTC()
+			}
+		}
+
+		losesAdmin := user.Role == enums.Admin && user.IsActive() &&
+			((changes.Role != nil && *changes.Role != enums.Admin) || (changes.Active != nil && !*changes.Active))
+		if losesAdmin {
+			if err := ensureAnotherAdmin(tx, user.ID); err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&user).Updates(updates).Error; err != nil {
+				return fmt.Errorf("unable to update user: %v", err)
+			}
+		}
+
+		// Senha definida pelo admin: segue a política e precisa ser trocada
+		// pelo usuário no próximo acesso. Set também encerra as sessões.
+		if changes.Password != nil {
+			return s.policy.Set(tx, &user, *changes.Password, true)
+		}
+
+		// Papel trocado ou usuário desativado: os logins existentes caem,
+		// pois o papel viaja no access token
+		roleChanged := changes.Role != nil && *changes.Role != previousRole
+		if roleChanged || !user.IsActive() {
+			return sessions.RevokeAll(tx, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// Deactivate desliga o usuário sem apagá-lo, preservando as consultas em que
+// ele aparece.
+func (s *Service) Deactivate(id uint64) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, ErrInactive
+	}
+
+	active := false
+	return s.Update(id, UserUpdate{Active: &active})
+}
+
+// ensureAnotherAdmin garante que, tirando o usuário informado, ainda resta
+// algum admin ativo no sistema. As linhas dos admins ativos ficam travadas até
+// o fim da transação, sempre na ordem do id, para que dois admins rebaixados
+// ao mesmo tempo não vejam um ao outro ainda ativo. O SQLite ignora o FOR
+// UPDATE, mas lá a escrita já é serializada pelo banco.
+func ensureAnotherAdmin(tx *gorm.DB, userID uint) error {
+	var admins []uint
+	err := tx.Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND deactivated_at IS NULL", enums.Admin).
+		Order("id").
+		Pluck("id", &admins).Error
+	if err != nil {
+		return fmt.Errorf("unable to lock admins: %v", err)
+	}
+	for _, id := range admins {
+		if id != userID {
+			return nil
+		}
+	}
+	return ErrLastAdmin
+}
+
+func ensureUniqueCPF(tx *gorm.DB, document cpf.CPF, userID uint) error {
+	var existing models.User
+	err := tx.Unscoped().Where("cpf = ? AND id <> ?", document, userID).First(&existing).Error
+	switch {
+	case err == nil:
+		return ErrDuplicateCPF
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("unable to check cpf: %v", err)
+	}
+	return nil
+}
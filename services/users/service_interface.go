@@ -8,4 +8,7 @@ import (
 type UserService interface {
 	Get(id uint64) (*models.User, error)
 	GetAll(filterRoles []enums.Role) ([]models.User, error)
+	GetDoctors(specialty string) ([]models.User, error)
+	Update(id uint64, changes UserUpdate) (*models.User, error)
+	Deactivate(id uint64) (*models.User, error)
 }
@@ -1,23 +1,24 @@
 package users
 
 import (
+	"sync"
+	"time"
+
 	"testing"
 
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/database/dbtest"
 	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/andresidrim/cesupa-hospital/models"
+	"github.com/andresidrim/cesupa-hospital/services/passwords"
+	"github.com/andresidrim/cesupa-hospital/utils"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	assert.NoError(t, err)
-
-	err = db.AutoMigrate(&models.User{}, &models.Appointment{})
-	assert.NoError(t, err)
-
-	return db
+	return dbtest.Open(t, &models.User{}, &models.Appointment{}, &models.Session{}, &models.PasswordHistory{}, &models.Specialty{}, &models.DoctorProfile{})
 }
 
 func createTestUsers(db *gorm.DB) {
@@ -34,7 +35,7 @@ func createTestUsers(db *gorm.DB) {
 func TestServiceGetAll(t *testing.T) {
 	db := setupTestDB(t)
 	createTestUsers(db)
-	service := NewService(db)
+	service := NewService(db, config.Default().Password)
 
 	tests := []struct {
 		name        string
@@ -58,7 +59,7 @@ func TestServiceGetAll(t *testing.T) {
 
 func TestServiceGet(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewService(db)
+	service := NewService(db, config.Default().Password)
 
 	// Arrange: crie um usuário para o cenário de sucesso
 	existing := models.User{
@@ -102,3 +103,195 @@ func TestServiceGet(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceUpdate(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, config.Default().Password)
+
+	admin := models.User{Name: "Alice", CPF: "12345678901", Password: "x", Role: enums.Admin}
+	doctor := models.User{Name: "Bob", CPF: "12345678902", Password: "x", Role: enums.Doctor}
+	assert.NoError(t, db.Create(&admin).Error)
+	assert.NoError(t, db.Create(&doctor).Error)
+
+	name := func(v string) *string { return &v }
+	role := func(v enums.Role) *enums.Role { return &v }
+	active := func(v bool) *bool { return &v }
+	document := func(v cpf.CPF) *cpf.CPF { return &v }
+
+	t.Run("partial update keeps other fields", func(t *testing.T) {
+		updated, err := service.Update(uint64(doctor.ID), UserUpdate{Name: name("Dr. Bob")})
+		assert.NoError(t, err)
+		assert.Equal(t, "Dr. Bob", updated.Name)
+		assert.Equal(t, enums.Doctor, updated.Role)
+		assert.Equal(t, cpf.CPF("12345678902"), updated.CPF)
+	})
+
+	t.Run("password is hashed", func(t *testing.T) {
+		_, err := service.Update(uint64(doctor.ID), UserUpdate{Password: name("New-secret1")})
+		assert.NoError(t, err)
+
+		var saved models.User
+		assert.NoError(t, db.First(&saved, doctor.ID).Error)
+		assert.NoError(t, utils.CheckPassword(saved.Password, "New-secret1"))
+		assert.True(t, saved.MustChangePassword)
+	})
+
+	t.Run("password follows policy and history", func(t *testing.T) {
+		_, err := service.Update(uint64(doctor.ID), UserUpdate{Password: name("short")})
+		var policy *passwords.PolicyError
+		assert.ErrorAs(t, err, &policy)
+
+		_, err = service.Update(uint64(doctor.ID), UserUpdate{Password: name("New-secret1")})
+		assert.ErrorIs(t, err, passwords.ErrPasswordReused)
+	})
+
+	t.Run("duplicate cpf", func(t *testing.T) {
+		_, err := service.Update(uint64(doctor.ID), UserUpdate{CPF: document(admin.CPF)})
+		assert.ErrorIs(t, err, ErrDuplicateCPF)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := service.Update(9999, UserUpdate{Name: name("Ghost")})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("last admin cannot be demoted or deactivated", func(t *testing.T) {
+		_, err := service.Update(uint64(admin.ID), UserUpdate{Role: role(enums.Doctor)})
+		assert.ErrorIs(t, err, ErrLastAdmin)
+
+		_, err = service.Update(uint64(admin.ID), UserUpdate{Active: active(false)})
+		assert.ErrorIs(t, err, ErrLastAdmin)
+
+		_, err = service.Deactivate(uint64(admin.ID))
+		assert.ErrorIs(t, err, ErrLastAdmin)
+	})
+
+	t.Run("admin can be demoted when another admin exists", func(t *testing.T) {
+		_, err := service.Update(uint64(doctor.ID), UserUpdate{Role: role(enums.Admin)})
+		assert.NoError(t, err)
+
+		updated, err := service.Update(uint64(admin.ID), UserUpdate{Role: role(enums.Receptionist)})
+		assert.NoError(t, err)
+		assert.Equal(t, enums.Receptionist, updated.Role)
+
+		_, err = service.Deactivate(uint64(doctor.ID))
+		assert.ErrorIs(t, err, ErrLastAdmin)
+	})
+
+	t.Run("deactivate and reactivate", func(t *testing.T) {
+		session := models.Session{UserID: admin.ID, RefreshTokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+		assert.NoError(t, db.Create(&session).Error)
+
+		deactivated, err := service.Deactivate(uint64(admin.ID))
+		assert.NoError(t, err)
+		assert.False(t, deactivated.IsActive())
+
+		// desativar derruba os logins existentes
+		assert.NoError(t, db.First(&session, session.ID).Error)
+		assert.NotNil(t, session.RevokedAt)
+
+		_, err = service.Deactivate(uint64(admin.ID))
+		assert.ErrorIs(t, err, ErrInactive)
+
+		reactivated, err := service.Update(uint64(admin.ID), UserUpdate{Active: active(true)})
+		assert.NoError(t, err)
+		assert.True(t, reactivated.IsActive())
+	})
+}
+
+// TestServiceConcurrentAdminDemotion confere que dois admins rebaixados ao
+// mesmo tempo não deixam o sistema sem nenhum admin ativo
+func TestServiceConcurrentAdminDemotion(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, config.Default().Password)
+
+	admins := []models.User{
+		{Name: "Alice", CPF: "12345678901", Password: "x", Role: enums.Admin},
+		{Name: "Bob", CPF: "12345678902", Password: "x", Role: enums.Admin},
+	}
+	assert.NoError(t, db.Create(&admins).Error)
+
+	errs := make([]error, len(admins))
+	var wg sync.WaitGroup
+	for i, admin := range admins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = service.Deactivate(uint64(admin.ID))
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, ErrLastAdmin)
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+
+	var active int64
+	assert.NoError(t, db.Model(&models.User{}).Where("role = ? AND deactivated_at IS NULL", enums.Admin).Count(&active).Error)
+	assert.Equal(t, int64(1), active)
+}
+
+func TestServiceGetDoctors(t *testing.T) {
+	db := dbtest.Open(t, &models.User{}, &models.Appointment{}, &models.Specialty{}, &models.DoctorProfile{})
+	service := NewService(db, config.Default().Password)
+
+	cardiology := models.Specialty{Name: "Cardiologia"}
+	pediatrics := models.Specialty{Name: "Pediatria"}
+	assert.NoError(t, db.Create(&cardiology).Error)
+	assert.NoError(t, db.Create(&pediatrics).Error)
+
+	deactivatedAt := time.Now()
+	users := []models.User{
+		{Name: "Dr. House", CPF: "12345678901", Password: "x", Role: enums.Doctor},
+		{Name: "Dr. Grey", CPF: "12345678902", Password: "x", Role: enums.Doctor},
+		{Name: "Dr. Who", CPF: "12345678903", Password: "x", Role: enums.Doctor},
+		{Name: "Dr. Gone", CPF: "12345678904", Password: "x", Role: enums.Doctor, DeactivatedAt: &deactivatedAt},
+		{Name: "Alice", CPF: "12345678905", Password: "x", Role: enums.Admin},
+	}
+	for i := range users {
+		assert.NoError(t, db.Create(&users[i]).Error)
+	}
+
+	profiles := []models.DoctorProfile{
+		{UserID: users[0].ID, CRM: "1001", CRMUF: "PA", Specialties: []models.Specialty{cardiology}},
+		{UserID: users[1].ID, CRM: "1002", CRMUF: "PA", Specialties: []models.Specialty{cardiology, pediatrics}},
+		{UserID: users[3].ID, CRM: "1003", CRMUF: "PA", Specialties: []models.Specialty{cardiology}},
+	}
+	for i := range profiles {
+		assert.NoError(t, db.Create(&profiles[i]).Error)
+	}
+
+	tests := []struct {
+		name      string
+		specialty string
+		want      []string
+	}{
+		{name: "all active doctors", want: []string{"Dr. Grey", "Dr. House", "Dr. Who"}},
+		{name: "by specialty, any case", specialty: "cardiologia", want: []string{"Dr. Grey", "Dr. House"}},
+		{name: "second specialty", specialty: "Pediatria", want: []string{"Dr. Grey"}},
+		{name: "unknown specialty", specialty: "Ortopedia", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doctors, err := service.GetDoctors(tt.specialty)
+			assert.NoError(t, err)
+			var names []string
+			for _, d := range doctors {
+				names = append(names, d.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	doctors, err := service.GetDoctors("")
+	assert.NoError(t, err)
+	assert.Equal(t, "1002", doctors[0].DoctorProfile.CRM)
+	assert.Len(t, doctors[0].DoctorProfile.Specialties, 2)
+	assert.Nil(t, doctors[2].DoctorProfile)
+}
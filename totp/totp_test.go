@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret é a chave SHA1 dos vetores de teste da RFC 6238 (apêndice B)
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	key, err := decode(rfcSecret)
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		// a RFC usa 8 dígitos; o código de 6 dígitos são os últimos 6
+		step := Step(time.Unix(tt.unix, 0))
+		assert.Equal(t, tt.code, hotp(key, uint64(step), 8))
+
+		code, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.code[2:], code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	step, ok := Validate(secret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// um passo de atraso é tolerado, dois não
+	_, ok = Validate(secret, code, now.Add(Period), 1)
+	assert.True(t, ok)
+	_, ok = Validate(secret, code, now.Add(2*Period), 1)
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 1)
+	assert.False(t, ok)
+	_, ok = Validate("not base32!", code, now, 1)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("JBSWY3DPEHPK3PXP", "CESUPA Hospital", "52998224725")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/CESUPA%20Hospital:52998224725?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=CESUPA+Hospital")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
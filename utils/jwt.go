@@ -1,32 +1,97 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/andresidrim/cesupa-hospital/env"
+	"github.com/andresidrim/cesupa-hospital/enums"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(env.SECRET_KEY)
+// Claims são as informações carregadas pelo access token. O usuário vai no
+// "sub"; papel e sessão permitem autorizar sem buscar o usuário no banco.
+// MustChangePassword e MustEnrollTOTP restringem o token à troca de senha e
+// ao cadastro do segundo fator.
+type Claims struct {
+	Role               enums.Role `json:"role"`
+	SessionID          uint       `json:"sid"`
+	MustChangePassword bool       `json:"mcp,omitempty"`
+	MustEnrollTOTP     bool       `json:"mfa_setup,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenSubject descreve o usuário e a sessão para quem o token é emitido
+type TokenSubject struct {
+	UserID             uint
+	Role               enums.Role
+	SessionID          uint
+	MustChangePassword bool
+	MustEnrollTOTP     bool
+}
+
+// UserID devolve o usuário do "sub", já validado por Parse
+func (c *Claims) UserID() uint {
+	id, _ := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id)
+}
+
+// Generate emite o access token de curta duração ligado à sessão do usuário
+func (s *JWTSigner) Generate(subject TokenSubject) (string, error) {
+	jti, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	settings := s.settings
+	now := time.Now()
+	claims := Claims{
+		Role:               subject.Role,
+		SessionID:          subject.SessionID,
+		MustChangePassword: subject.MustChangePassword,
+		MustEnrollTOTP:     subject.MustEnrollTOTP,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    settings.Issuer,
+			Audience:  jwt.ClaimStrings{settings.Audience},
+			Subject:   strconv.FormatUint(uint64(subject.UserID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(settings.AccessTokenTTL)),
+		},
+	}
 
-func GenerateJWT(userID uint) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+	keys := s.keys
+	token := jwt.NewWithClaims(keys.method, claims)
+	if keys.kid != "" {
+		token.Header["kid"] = keys.kid
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(keys.signing)
 }
 
-func ParseJWT(tokenString string) (uint, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-		return jwtSecret, nil
-	})
+// Parse valida assinatura, algoritmo, emissor, audiência e validade do
+// token e devolve as claims.
+func (s *JWTSigner) Parse(tokenString string) (*Claims, error) {
+	keys, settings := s.keys, s.settings
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		id := uint(claims["user_id"].(float64))
-		return id, nil
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, keys.verificationKey,
+		jwt.WithValidMethods([]string{keys.method.Alg()}),
+		jwt.WithIssuer(settings.Issuer),
+		jwt.WithAudience(settings.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if id, err := strconv.ParseUint(claims.Subject, 10, 64); err != nil || id == 0 {
+		return nil, fmt.Errorf("%w: invalid subject", jwt.ErrTokenInvalidClaims)
+	}
+	if claims.SessionID == 0 || claims.Role == "" {
+		return nil, errors.New("token is missing session or role")
 	}
 
-	return 0, err
+	return &claims, nil
 }
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtKeys é o conjunto de chaves em uso: a de assinatura, identificada por
+// kid, e as de verificação por kid, que incluem chaves anteriores ainda
+// válidas durante uma rotação.
+type jwtKeys struct {
+	method    jwt.SigningMethod
+	kid       string
+	signing   any
+	verifying map[string]any
+}
+
+// JWTSigner emite e valida os access tokens com as chaves, o emissor, a
+// audiência e a validade da configuração. É criado uma vez na inicialização e
+// repassado ao serviço de autenticação e ao middleware.
+type JWTSigner struct {
+	keys     jwtKeys
+	settings config.JWT
+}
+
+// verificationKey escolhe a chave pelo kid do cabeçalho. Em HS256 o segredo
+// é único; em RS256 o kid é obrigatório e precisa ser conhecido.
+func (k jwtKeys) verificationKey(t *jwt.Token) (any, error) {
+	if k.method == jwt.SigningMethodHS256 {
+		return k.signing, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token is missing kid")
+	}
+	key, ok := k.verifying[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	return key, nil
+}
+
+// NewJWTSigner carrega as chaves conforme o algoritmo: o segredo em HS256, ou
+// a chave privada e as públicas anteriores em RS256.
+func NewJWTSigner(cfg config.JWT) (*JWTSigner, error) {
+	var keys jwtKeys
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "HS256":
+		if len(cfg.SecretKey) < config.MinSecretKeyLength {
+			return nil, fmt.Errorf("SECRET_KEY must have at least %d characters for HS256", config.MinSecretKeyLength)
+		}
+		keys = jwtKeys{method: jwt.SigningMethodHS256, kid: cfg.KeyID, signing: []byte(cfg.SecretKey)}
+	case "RS256":
+		var err error
+		if keys, err = loadRSAKeys(cfg.KeyID, cfg.PrivateKeyFile, cfg.PublicKeysDir); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.Algorithm)
+	}
+
+	return &JWTSigner{keys: keys, settings: cfg}, nil
+}
+
+// AccessTokenTTL é a validade dos tokens emitidos
+func (s *JWTSigner) AccessTokenTTL() time.Duration {
+	return s.settings.AccessTokenTTL
+}
+
+func loadRSAKeys(kid, privateKeyFile, publicKeysDir string) (jwtKeys, error) {
+	if kid == "" || privateKeyFile == "" {
+		return jwtKeys{}, errors.New("RS256 requires JWT_KEY_ID and JWT_PRIVATE_KEY_FILE")
+	}
+
+	pemBytes, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return jwtKeys{}, fmt.Errorf("unable to read private key: %v", err)
+	}
+	private, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return jwtKeys{}, fmt.Errorf("invalid private key: %v", err)
+	}
+
+	verifying := map[string]any{}
+	if publicKeysDir != "" {
+		files, err := filepath.Glob(filepath.Join(publicKeysDir, "*.pem"))
+		if err != nil {
+			return jwtKeys{}, err
+		}
+		for _, file := range files {
+			public, err := readRSAPublicKey(file)
+			if err != nil {
+				return jwtKeys{}, err
+			}
+			verifying[strings.TrimSuffix(filepath.Base(file), ".pem")] = public
+		}
+	}
+	verifying[kid] = &private.PublicKey
+
+	return jwtKeys{method: jwt.SigningMethodRS256, kid: kid, signing: private, verifying: verifying}, nil
+}
+
+func readRSAPublicKey(file string) (*rsa.PublicKey, error) {
+	pemBytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key %s: %v", file, err)
+	}
+	public, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %v", file, err)
+	}
+	return public, nil
+}
@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andresidrim/cesupa-hospital/config"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestSigner cria um signer HS256 com a configuração padrão
+func newTestSigner(t *testing.T) *JWTSigner {
+	cfg := config.Default().JWT
+	cfg.SecretKey = testSecret
+	signer, err := NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	return signer
+}
+
+// newRS256Signer cria um signer RS256 com a chave privada <kid>.key de dir
+func newRS256Signer(t *testing.T, kid, dir, publicKeysDir string) *JWTSigner {
+	cfg := config.Default().JWT
+	cfg.Algorithm = "RS256"
+	cfg.KeyID = kid
+	cfg.PrivateKeyFile = filepath.Join(dir, kid+".key")
+	cfg.PublicKeysDir = publicKeysDir
+	signer, err := NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	return signer
+}
+
+func validClaims() Claims {
+	now := time.Now()
+	return Claims{
+		Role:      enums.Doctor,
+		SessionID: 3,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    config.Default().JWT.Issuer,
+			Audience:  jwt.ClaimStrings{config.Default().JWT.Audience},
+			Subject:   "7",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+}
+
+func TestGenerateAndParseJWT(t *testing.T) {
+	signer := newTestSigner(t)
+
+	token, err := signer.Generate(TokenSubject{UserID: 7, Role: enums.Admin, SessionID: 3})
+	assert.NoError(t, err)
+
+	claims, err := signer.Parse(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID())
+	assert.Equal(t, uint(3), claims.SessionID)
+	assert.Equal(t, enums.Admin, claims.Role)
+	assert.NotEmpty(t, claims.ID)
+	assert.Equal(t, config.Default().JWT.Issuer, claims.Issuer)
+}
+
+func TestParseJWTRejects(t *testing.T) {
+	secret := []byte(testSecret)
+	signer := newTestSigner(t)
+
+	sign := func(method jwt.SigningMethod, key any, change func(c *Claims)) string {
+		claims := validClaims()
+		if change != nil {
+			change(&claims)
+		}
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		assert.NoError(t, err)
+		return token
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "alg none", token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, nil)},
+		{name: "other hmac alg", token: sign(jwt.SigningMethodHS512, secret, nil)},
+		{name: "rs256 when pinned to hs256", token: sign(jwt.SigningMethodRS256, rsaKey, nil)},
+		{name: "wrong secret", token: sign(jwt.SigningMethodHS256, []byte("other"), nil)},
+		{name: "wrong issuer", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.Issuer = "someone-else" })},
+		{name: "wrong audience", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.Audience = jwt.ClaimStrings{"other-api"} })},
+		{name: "expired", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) })},
+		{name: "missing exp", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.ExpiresAt = nil })},
+		{name: "missing subject", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.Subject = "" })},
+		{name: "non numeric subject", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.Subject = "alice" })},
+		{name: "missing session", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.SessionID = 0 })},
+		{name: "missing role", token: sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.Role = "" })},
+		{name: "garbage", token: "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := signer.Parse(tt.token)
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func writeRSAKeys(t *testing.T, dir, name string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), private, 0o600))
+
+	publicBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), public, 0o644))
+
+	return key
+}
+
+func TestRS256KeyRotation(t *testing.T) {
+	keysDir := t.TempDir()
+	privateDir := t.TempDir()
+	writeRSAKeys(t, privateDir, "2025-01")
+	writeRSAKeys(t, privateDir, "2025-02")
+
+	// chave antiga ativa
+	oldSigner := newRS256Signer(t, "2025-01", privateDir, keysDir)
+	oldToken, err := oldSigner.Generate(TokenSubject{UserID: 1, Role: enums.Admin, SessionID: 1})
+	assert.NoError(t, err)
+
+	header, _, err := jwt.NewParser().ParseUnverified(oldToken, &Claims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01", header.Header["kid"])
+	assert.Equal(t, "RS256", header.Method.Alg())
+
+	// rotação sem publicar a chave antiga: tokens antigos deixam de valer
+	_, err = newRS256Signer(t, "2025-02", privateDir, keysDir).Parse(oldToken)
+	assert.Error(t, err)
+
+	// com a chave pública antiga publicada, os dois convivem
+	assert.NoError(t, os.Rename(filepath.Join(privateDir, "2025-01.pem"), filepath.Join(keysDir, "2025-01.pem")))
+	signer := newRS256Signer(t, "2025-02", privateDir, keysDir)
+
+	_, err = signer.Parse(oldToken)
+	assert.NoError(t, err)
+
+	newToken, err := signer.Generate(TokenSubject{UserID: 2, Role: enums.Doctor, SessionID: 5})
+	assert.NoError(t, err)
+	claims, err := signer.Parse(newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), claims.UserID())
+
+	// token RS256 sem kid é recusado
+	noKid := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+	signed, err := noKid.SignedString(signer.keys.signing)
+	assert.NoError(t, err)
+	_, err = signer.Parse(signed)
+	assert.Error(t, err)
+}
+
+func TestLoadRSAKeysRequiresKid(t *testing.T) {
+	_, err := loadRSAKeys("", "key.pem", "")
+	assert.Error(t, err)
+	_, err = loadRSAKeys("k1", filepath.Join(t.TempDir(), "missing.pem"), "")
+	assert.Error(t, err)
+}
+
+func TestNewJWTSigner(t *testing.T) {
+	cfg := config.Default().JWT
+	cfg.SecretKey = "short"
+	_, err := NewJWTSigner(cfg)
+	assert.Error(t, err)
+
+	cfg.Algorithm = "HS512"
+	cfg.SecretKey = testSecret
+	_, err = NewJWTSigner(cfg)
+	assert.Error(t, err)
+
+	cfg.Algorithm = "HS256"
+	cfg.Issuer = "other-hospital"
+	cfg.AccessTokenTTL = 2 * time.Minute
+	signer, err := NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, signer.AccessTokenTTL())
+
+	token, err := signer.Generate(TokenSubject{UserID: 7, Role: enums.Admin, SessionID: 3})
+	assert.NoError(t, err)
+	claims, err := signer.Parse(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "other-hospital", claims.Issuer)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+
+	// outro signer com a mesma configuração aceita o token; com outro segredo, não
+	other, err := NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	_, err = other.Parse(token)
+	assert.NoError(t, err)
+
+	cfg.SecretKey = "fedcba9876543210fedcba9876543210"
+	other, err = NewJWTSigner(cfg)
+	assert.NoError(t, err)
+	_, err = other.Parse(token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package validators
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/andresidrim/cesupa-hospital/cpf"
+	"github.com/andresidrim/cesupa-hospital/crm"
+	"github.com/andresidrim/cesupa-hospital/enums"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Register adiciona ao validador do gin as tags customizadas do projeto
+// (ex.: binding:"cpf", binding:"role", binding:"crm"). Deve ser chamado
+// antes de registrar as rotas.
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unexpected validator engine %T", binding.Validator.Engine())
+	}
+
+	if err := v.RegisterValidation("cpf", func(fl validator.FieldLevel) bool {
+		return cpf.IsValid(fl.Field().String())
+	}); err != nil {
+		return err
+	}
+
+	if err := v.RegisterValidation("role", func(fl validator.FieldLevel) bool {
+		return slices.Contains(enums.Roles, enums.Role(fl.Field().String()))
+	}); err != nil {
+		return err
+	}
+
+	if err := v.RegisterValidation("crm", func(fl validator.FieldLevel) bool {
+		return crm.IsValid(fl.Field().String())
+	}); err != nil {
+		return err
+	}
+
+	return v.RegisterValidation("uf", func(fl validator.FieldLevel) bool {
+		return crm.IsValidUF(fl.Field().String())
+	})
+}